@@ -2,96 +2,275 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/erwin-lovecraft/pistol/internal/adapters/handler"
+	"github.com/erwin-lovecraft/pistol/internal/adapters/metrics"
 	"github.com/erwin-lovecraft/pistol/internal/adapters/repository"
 	"github.com/erwin-lovecraft/pistol/internal/config"
+	"github.com/erwin-lovecraft/pistol/internal/core/domain"
 	"github.com/erwin-lovecraft/pistol/internal/core/services"
 	"github.com/erwin-lovecraft/pistol/internal/web"
-	"github.com/erwin-lovecraft/pistol/migrations"
 	pkgmiddleware "github.com/erwin-lovecraft/pistol/pkg/middleware"
+	"github.com/erwin-lovecraft/pistol/pkg/ssehub"
+	"github.com/erwin-lovecraft/pistol/pkg/ssehub/pgbackplane"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/httprate"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/jackc/pgx/v5/stdlib"
-	"github.com/pressly/goose/v3"
+	"gopkg.in/yaml.v3"
 )
 
 func main() {
-	if err := run(context.Background()); err != nil {
+	args := os.Args[1:]
+	if len(args) >= 2 && args[0] == "config" && args[1] == "print" {
+		if err := printConfig(os.Stdout, args[2:]); err != nil && !errors.Is(err, flag.ErrHelp) {
+			log.Printf("print config: %v", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Deploys send SIGTERM, Ctrl+C sends SIGINT
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, args); err != nil && !errors.Is(err, flag.ErrHelp) {
 		log.Printf("serve exit abnormally: %v", err)
 		os.Exit(1)
 	}
 }
 
-func run(ctx context.Context) error {
-	cfg := config.ReadFromENV()
+// printConfig writes the effective configuration as YAML, with its secrets masked.
+func printConfig(w io.Writer, args []string) error {
+	cfg, err := config.Load("serverd config print", args)
+	if err != nil {
+		return err
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(cfg.Masked()); err != nil {
+		return err
+	}
+	return enc.Close()
+}
 
-	// Setup DB connections
-	dbPool, err := pgxpool.New(ctx, cfg.PGURL)
+func run(ctx context.Context, args []string) error {
+	cfg, err := config.Load("serverd", args)
 	if err != nil {
-		return fmt.Errorf("create db connection: %w", err)
+		return err
 	}
-	defer dbPool.Close()
 
-	// Setup migrations
-	goose.SetBaseFS(migrations.MigrationFS)
-	if err := goose.SetDialect("postgres"); err != nil {
-		return fmt.Errorf("goose set dialect: %w", err)
+	// Setup DB connections, Postgres is only needed by its storage or backplane
+	var dbPool *pgxpool.Pool
+	if cfg.UsesPostgres() {
+		if dbPool, err = pgxpool.New(ctx, cfg.Database.URL); err != nil {
+			return fmt.Errorf("create db connection: %w", err)
+		}
+		defer dbPool.Close()
 	}
-	if err := goose.UpContext(ctx, stdlib.OpenDBFromPool(dbPool), "."); err != nil {
-		return fmt.Errorf("migrate up: %w", err)
+
+	// Setup storage, migrating its database
+	store, err := openStorage(ctx, cfg.Storage, dbPool)
+	if err != nil {
+		return fmt.Errorf("open %s storage: %w", cfg.Storage.Backend, err)
 	}
+	defer store.close()
 
 	// Setup ID generator
 	if err := repository.SetupIDGenerator(); err != nil {
 		return err
 	}
 
+	// Setup SSE hub
+	hubOpts := []ssehub.HubOption{
+		ssehub.WithDefaultDeliveryPolicy(ssehub.DeliveryPolicy(cfg.Hub.DeliveryPolicy)),
+		ssehub.WithBlockTimeout(cfg.Hub.BlockTimeout),
+		ssehub.WithHeartbeatInterval(cfg.Hub.HeartbeatInterval),
+		ssehub.WithSendBuffer(cfg.Hub.SendBuffer),
+	}
+	switch cfg.Hub.Backplane {
+	case config.BackplaneNone:
+	case config.BackplanePostgres:
+		hubOpts = append(hubOpts, ssehub.WithBackplane(pgbackplane.New(dbPool)))
+	default:
+		return fmt.Errorf("unknown hub backplane %q", cfg.Hub.Backplane)
+	}
+	hub := ssehub.NewHub(hubOpts...)
+
+	// Setup metrics, nil when turned off
+	var mtr *metrics.Metrics
+	if cfg.Metrics.Path != "" {
+		mtr = metrics.New()
+		store.events = mtr.EventRepository(store.events)
+		mtr.WatchHub(hub)
+		if dbPool != nil {
+			mtr.WatchPool(dbPool)
+		}
+		if store.db != nil {
+			mtr.WatchDB(store.db, cfg.Storage.Backend)
+		}
+	}
+
+	// Background workers outlive ctx, they're stopped once in-flight requests are drained so what those hand over
+	// still gets done
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	defer func() {
+		stopWorkers()
+		workers.Wait()
+	}()
+	startWorker := func(run func(ctx context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(workerCtx)
+		}()
+	}
+
+	startWorker(func(ctx context.Context) {
+		if err := hub.Run(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("hub exit abnormally: %v", err)
+		}
+	})
+	if store.run != nil {
+		startWorker(store.run)
+	}
+
 	// DI settings
-	roomRepo := repository.NewInMemoryRoomRepository()
-	eventRepo := repository.NewEventRepository(dbPool)
-	service := services.NewService(roomRepo, eventRepo)
-	hdl, err := handler.New(service)
+	redactor, err := services.NewRedactor(cfg.Redaction.Rules)
+	if err != nil {
+		return fmt.Errorf("invalid redaction rules: %w", err)
+	}
+	forwarder := services.NewForwarder(hub, services.NewHTTPClient(), store.replays, redactor, 0, 0, cfg.Server.ShutdownTimeout)
+	startWorker(forwarder.Run)
+	pruner := services.NewPruner(store.rooms, store.events, domain.RetentionPolicy{
+		MaxAgeSeconds: int64(cfg.Retention.MaxAge / time.Second),
+		MaxEvents:     cfg.Retention.MaxEvents,
+		MaxBytes:      cfg.Retention.MaxBytes,
+	}, cfg.Retention.PruneInterval, 0)
+	startWorker(pruner.Run)
+	service := services.NewService(hub, store.rooms, store.events, store.replays, store.tokens, forwarder, pruner, redactor)
+	if mtr != nil {
+		mtr.WatchPruner(pruner)
+		service = mtr.Service(service)
+	}
+	hdl, err := handler.New(service, handler.Options{
+		TemplateDir:    cfg.Server.TemplateDir,
+		TrustedProxies: cfg.Server.TrustedProxies,
+		SecretKey:      cfg.Auth.SecretKey,
+	})
 	if err != nil {
 		return err
 	}
 
 	// Start server
-	log.Printf("listening on port %s", cfg.Port)
+	log.Printf("listening on port %s", cfg.Server.Port)
 	srv := http.Server{
-		Addr:        fmt.Sprintf(":%s", cfg.Port),
-		Handler:     routes(hdl),
-		ReadTimeout: 5 * time.Second,
+		Addr:        fmt.Sprintf(":%s", cfg.Server.Port),
+		Handler:     routes(cfg, hdl, mtr),
+		ReadTimeout: cfg.Server.ReadTimeout,
 		//WriteTimeout: 10 * time.Second, // SSE Endpoint need keep-alive
-		IdleTimeout: 2 * time.Minute,
+		IdleTimeout: cfg.Server.IdleTimeout,
 	}
-	return srv.ListenAndServe()
+	// SSE streams never end on their own, ask their clients to reconnect elsewhere so the drain can complete
+	srv.RegisterOnShutdown(func() {
+		hubCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+		defer cancel()
+		if err := hub.Shutdown(hubCtx, cfg.Hub.ShutdownRetry); err != nil {
+			log.Printf("close sse clients: %v", err)
+		}
+	})
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.ListenAndServe()
+	}()
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	// Shutdown stops accepting connections, pushes included, and waits for the requests in flight
+	log.Printf("shutting down, draining requests for up to %s", cfg.Server.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		srv.Close()
+		return fmt.Errorf("drain requests: %w", err)
+	}
+	log.Printf("shut down")
+	return nil
 }
 
-func routes(hdl handler.Handler) http.Handler {
+func routes(cfg config.Config, hdl handler.Handler, mtr *metrics.Metrics) http.Handler {
 	r := chi.NewRouter()
 	r.Use(middleware.Logger)
-	r.Use(httprate.Limit(
-		100,
-		1*time.Second,
-		httprate.WithKeyFuncs(httprate.KeyByIP, httprate.KeyByEndpoint)),
-	)
+	if cfg.RateLimit.Requests > 0 {
+		r.Use(httprate.Limit(
+			cfg.RateLimit.Requests,
+			cfg.RateLimit.Window,
+			httprate.WithKeyFuncs(httprate.KeyByIP, httprate.KeyByEndpoint)),
+		)
+	}
 
 	r.Get("/healthz", healthz)
+	if mtr != nil {
+		// Series are labeled with room IDs, only admins get to list them
+		r.With(pkgmiddleware.AuthKey(cfg.Auth.SecretKey)).Method(http.MethodGet, cfg.Metrics.Path, mtr.Handler())
+	}
 	r.Get("/", hdl.Home())
 	r.Handle("/static/*", http.FileServer(http.FS(web.FS)))
 	r.Get("/rooms/{roomID}/views", hdl.ViewRoom())
 	r.Route("/api/v1", func(v1 chi.Router) {
-		v1.Get("/rooms/{roomID}/events", hdl.ListenEvents())
-		v1.Get("/rooms/{roomID}", hdl.ListEvents())
-		v1.Handle("/rooms/{roomID}/push", pkgmiddleware.AuthKey(hdl.PushEvent()))
+		adminAuth := pkgmiddleware.AuthKey(cfg.Auth.SecretKey)
+		readAuth := hdl.RoomAuth(domain.TokenScopeRead)
+		v1.With(adminAuth).Post("/rooms", hdl.CreateRoom())
+		v1.With(adminAuth).Get("/rooms", hdl.ListRooms())
+		// Room history has been served here from the start, the room itself is at /info
+		v1.With(readAuth).Get("/rooms/{roomID}", hdl.ListEvents())
+		v1.With(readAuth).Get("/rooms/{roomID}/info", hdl.GetRoom())
+		v1.With(adminAuth).Patch("/rooms/{roomID}", hdl.RenameRoom())
+		v1.With(adminAuth).Delete("/rooms/{roomID}", hdl.DeleteRoom())
+		v1.With(adminAuth).Put("/rooms/{roomID}/response", hdl.SetRoomResponse())
+		v1.With(adminAuth).Delete("/rooms/{roomID}/response", hdl.SetRoomResponse())
+		v1.With(adminAuth).Put("/rooms/{roomID}/forwards", hdl.SetRoomForwards())
+		v1.With(adminAuth).Put("/rooms/{roomID}/signature", hdl.SetRoomSignature())
+		v1.With(adminAuth).Delete("/rooms/{roomID}/signature", hdl.SetRoomSignature())
+		v1.With(adminAuth).Put("/rooms/{roomID}/retention", hdl.SetRoomRetention())
+		v1.With(adminAuth).Delete("/rooms/{roomID}/retention", hdl.SetRoomRetention())
+		v1.With(adminAuth).Get("/retention", hdl.GetRetention())
+		v1.With(adminAuth).Put("/rooms/{roomID}/redaction", hdl.SetRoomRedaction())
+		v1.With(adminAuth).Delete("/rooms/{roomID}/redaction", hdl.SetRoomRedaction())
+		v1.With(adminAuth).Get("/redaction", hdl.GetRedaction())
+		v1.With(adminAuth).Post("/rooms/{roomID}/tokens", hdl.CreateRoomToken())
+		v1.With(adminAuth).Get("/rooms/{roomID}/tokens", hdl.ListRoomTokens())
+		v1.With(adminAuth).Post("/rooms/{roomID}/tokens/{tokenID}/rotate", hdl.RotateRoomToken())
+		v1.With(adminAuth).Delete("/rooms/{roomID}/tokens/{tokenID}", hdl.RevokeRoomToken())
+		v1.With(readAuth).Get("/rooms/{roomID}/events", hdl.ListenEvents())
+		v1.With(readAuth).Get("/rooms/{roomID}/ws", hdl.ListenEventsWS())
+		v1.With(readAuth).Get("/rooms/{roomID}/export", hdl.ExportEvents())
+		v1.With(adminAuth).Post("/rooms/{roomID}/import", hdl.ImportEvents())
+		v1.With(adminAuth).Post("/rooms/{roomID}/events/{eventID}/replay", hdl.ReplayEvent())
+		v1.With(readAuth).Get("/rooms/{roomID}/events/{eventID}/replays", hdl.ListReplayAttempts())
+		v1.With(adminAuth).Get("/hub/rooms", hdl.ListHubRooms())
+		v1.With(adminAuth).Get("/hub/rooms/{roomID}/clients", hdl.ListHubClients())
+		v1.With(adminAuth).Delete("/hub/rooms/{roomID}/clients", hdl.DisconnectRoom())
+		v1.With(adminAuth).Delete("/hub/rooms/{roomID}/clients/{clientID}", hdl.DisconnectClient())
+		v1.Handle("/rooms/{roomID}/push", hdl.RoomAuth(domain.TokenScopeIngest)(hdl.PushEvent()))
+		v1.Handle("/rooms/{roomID}/push/*", hdl.RoomAuth(domain.TokenScopeIngest)(hdl.PushEvent()))
 	})
 	r.Handle("/*", hdl.NotFound())
 
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+
+	"github.com/erwin-lovecraft/pistol/internal/adapters/repository"
+	"github.com/erwin-lovecraft/pistol/internal/config"
+	"github.com/erwin-lovecraft/pistol/internal/core/ports"
+	"github.com/erwin-lovecraft/pistol/migrations"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
+	_ "modernc.org/sqlite"
+)
+
+// storage holds the repositories of the configured backend.
+type storage struct {
+	rooms   ports.RoomRepository
+	events  ports.EventRepository
+	replays ports.ReplayRepository
+	tokens  ports.TokenRepository
+	// db is the database/sql database of the backend, nil when it has none
+	db *sql.DB
+	// run does the backend's housekeeping until ctx is done, nil when it has none
+	run func(ctx context.Context)
+	// close releases the backend, the Postgres pool is closed by its owner
+	close func() error
+}
+
+// openStorage sets up the repositories of the configured backend, migrating its database first.
+func openStorage(ctx context.Context, cfg config.StorageConfig, dbPool *pgxpool.Pool) (storage, error) {
+	switch cfg.Backend {
+	case config.StorageMemory:
+		events := repository.NewInMemoryEventRepository(cfg.MemoryTTL, cfg.MemoryCleanupInterval)
+		tokens := repository.NewInMemoryTokenRepository()
+		return storage{
+			rooms:   repository.NewInMemoryRoomRepository(tokens),
+			events:  events,
+			replays: repository.NewInMemoryReplayRepository(),
+			tokens:  tokens,
+			run:     events.Run,
+			close:   func() error { return nil },
+		}, nil
+
+	case config.StorageSQLite:
+		// Times are written in a fixed format so they compare as strings
+		db, err := sql.Open("sqlite", cfg.SQLitePath+
+			"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_time_format=sqlite")
+		if err != nil {
+			return storage{}, fmt.Errorf("open sqlite database: %w", err)
+		}
+		// SQLite has a single writer, sharing one connection avoids busy errors
+		db.SetMaxOpenConns(1)
+		if err := migrate(ctx, db, migrations.SQLiteFS, "sqlite3", "sqlite"); err != nil {
+			db.Close()
+			return storage{}, err
+		}
+		return storage{
+			rooms:   repository.NewSQLiteRoomRepository(db),
+			events:  repository.NewSQLiteEventRepository(db),
+			replays: repository.NewSQLiteReplayRepository(db),
+			tokens:  repository.NewSQLiteTokenRepository(db),
+			db:      db,
+			close:   db.Close,
+		}, nil
+
+	case config.StoragePostgres:
+		if err := migrate(ctx, stdlib.OpenDBFromPool(dbPool), migrations.MigrationFS, "postgres", "."); err != nil {
+			return storage{}, err
+		}
+		return storage{
+			rooms:   repository.NewRoomRepository(dbPool),
+			events:  repository.NewEventRepository(dbPool),
+			replays: repository.NewReplayRepository(dbPool),
+			tokens:  repository.NewTokenRepository(dbPool),
+			close:   func() error { return nil },
+		}, nil
+
+	default:
+		return storage{}, fmt.Errorf("unknown storage backend %q", cfg.Backend)
+	}
+}
+
+func migrate(ctx context.Context, db *sql.DB, fsys fs.FS, dialect string, dir string) error {
+	goose.SetBaseFS(fsys)
+	if err := goose.SetDialect(dialect); err != nil {
+		return fmt.Errorf("goose set dialect: %w", err)
+	}
+	if err := goose.UpContext(ctx, db, dir); err != nil {
+		return fmt.Errorf("migrate up: %w", err)
+	}
+	return nil
+}
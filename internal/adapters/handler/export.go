@@ -0,0 +1,369 @@
+package handler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/erwin-lovecraft/pistol/internal/core/domain"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	exportFormatNDJSON = "ndjson"
+	exportFormatHAR    = "har"
+	exportFormatCurl   = "curl"
+)
+
+var (
+	// skippedExportHeaders are set by whoever sends the request again
+	skippedExportHeaders = []string{"Accept-Encoding", "Connection", "Content-Length", "Host", "Transfer-Encoding"}
+)
+
+// eventExporter writes events in an export format, one at a time so exports are streamed.
+type eventExporter interface {
+	contentType() string
+	extension() string
+	begin(w io.Writer) error
+	write(w io.Writer, event domain.Event) error
+	end(w io.Writer) error
+}
+
+func (h Handler) ExportEvents() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		roomID := chi.URLParam(r, "roomID")
+		if roomID == "" {
+			http.Error(w, "roomID is required", http.StatusBadRequest)
+			return
+		}
+
+		pushURL := deriveBaseURL(r) + "api/v1/rooms/" + url.PathEscape(roomID) + "/push"
+
+		var exporter eventExporter
+		switch format := r.URL.Query().Get("format"); format {
+		case "", exportFormatNDJSON:
+			exporter = &ndjsonExporter{}
+		case exportFormatHAR:
+			exporter = &harExporter{pushURL: pushURL}
+		case exportFormatCurl:
+			exporter = &curlExporter{roomID: roomID, pushURL: pushURL}
+		default:
+			http.Error(w, "invalid format, expected ndjson, har or curl", http.StatusBadRequest)
+			return
+		}
+
+		filter, err := eventFilterFromRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Headers are only sent once the room is known to exist, errors can't change the status afterwards
+		var started bool
+		start := func() error {
+			started = true
+			w.Header().Set("Content-Type", exporter.contentType())
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "room-"+roomID+exporter.extension()))
+			w.WriteHeader(http.StatusOK)
+			return exporter.begin(w)
+		}
+
+		flusher, _ := w.(http.Flusher)
+		err = h.svc.ExportEvents(r.Context(), roomID, filter, func(event domain.Event) error {
+			if !started {
+				if err := start(); err != nil {
+					return err
+				}
+			}
+			if err := exporter.write(w, event); err != nil {
+				return err
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+			return nil
+		})
+		if err == nil && !started {
+			err = start()
+		}
+		if err != nil {
+			if started {
+				log.Printf("failed to export events of room %s: %v", roomID, err)
+				return
+			}
+			if errors.Is(err, domain.ErrRoomNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if err := exporter.end(w); err != nil {
+			log.Printf("failed to export events of room %s: %v", roomID, err)
+		}
+	}
+}
+
+// ndjsonExporter writes one JSON encoded domain.Event per line.
+type ndjsonExporter struct{}
+
+func (ndjsonExporter) contentType() string { return "application/x-ndjson" }
+
+func (ndjsonExporter) extension() string { return ".ndjson" }
+
+func (ndjsonExporter) begin(io.Writer) error { return nil }
+
+func (ndjsonExporter) write(w io.Writer, event domain.Event) error {
+	return json.NewEncoder(w).Encode(event)
+}
+
+func (ndjsonExporter) end(io.Writer) error { return nil }
+
+// harExporter writes an HTTP Archive 1.2, each event being an entry with the response the room sent back.
+type harExporter struct {
+	pushURL string
+	entries int
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	// Encoding isn't part of HAR 1.2 for requests, custom fields are prefixed with an underscore
+	Encoding string `json:"_encoding,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+type harLog struct {
+	Log struct {
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	ID              string      `json:"_id"`
+}
+
+func (*harExporter) contentType() string { return "application/json" }
+
+func (*harExporter) extension() string { return ".har" }
+
+func (*harExporter) begin(w io.Writer) error {
+	_, err := io.WriteString(w, `{"log":{"version":"1.2","creator":{"name":"pistol","version":"1.0"},"entries":[`)
+	return err
+}
+
+func (e *harExporter) write(w io.Writer, event domain.Event) error {
+	httpVersion := event.Proto
+	if httpVersion == "" {
+		httpVersion = "HTTP/1.1"
+	}
+
+	entry := harEntry{
+		StartedDateTime: event.CreatedAt.UTC().Format(time.RFC3339Nano),
+		ID:              fmt.Sprintf("%d", event.ID),
+		Request: harRequest{
+			Method:      event.Method,
+			URL:         e.pushURL + event.Path,
+			HTTPVersion: httpVersion,
+			Cookies:     []harNameValue{},
+			Headers:     toHARNameValues(event.Header),
+			QueryString: toHARNameValues(event.QueryParams),
+			HeadersSize: -1,
+			BodySize:    int64(len(event.Body)),
+		},
+		Response: harResponse{
+			HTTPVersion: httpVersion,
+			Cookies:     []harNameValue{},
+			Headers:     []harNameValue{},
+			HeadersSize: -1,
+		},
+		// Receiving the request is what the client spent sending it
+		Time:    float64(event.DurationUS) / 1000,
+		Timings: harTimings{Send: float64(event.DurationUS) / 1000},
+	}
+	if len(event.QueryParams) > 0 {
+		entry.Request.URL += "?" + url.Values(event.QueryParams).Encode()
+	}
+	if len(event.Body) > 0 {
+		entry.Request.PostData = &harPostData{
+			MimeType: event.ContentType,
+			Text:     string(event.Body),
+		}
+		if event.BodyBase64 {
+			entry.Request.PostData.Text = base64.StdEncoding.EncodeToString(event.Body)
+			entry.Request.PostData.Encoding = "base64"
+		}
+	}
+	if resp := event.Response; resp != nil {
+		entry.Time += float64(resp.DelayMS)
+		entry.Timings.Wait = float64(resp.DelayMS)
+		entry.Response.Status = resp.StatusCode
+		entry.Response.StatusText = http.StatusText(resp.StatusCode)
+		entry.Response.Content = harContent{
+			Size:     len(resp.Body),
+			MimeType: resp.Headers["Content-Type"],
+			Text:     resp.Body,
+		}
+		entry.Response.BodySize = len(resp.Body)
+		entry.Response.RedirectURL = resp.Headers["Location"]
+		for _, k := range sortedKeys(resp.Headers) {
+			entry.Response.Headers = append(entry.Response.Headers, harNameValue{Name: k, Value: resp.Headers[k]})
+		}
+	}
+
+	if e.entries > 0 {
+		if _, err := io.WriteString(w, ","); err != nil {
+			return err
+		}
+	}
+	e.entries++
+	return json.NewEncoder(w).Encode(entry)
+}
+
+func (*harExporter) end(w io.Writer) error {
+	_, err := io.WriteString(w, "]}}\n")
+	return err
+}
+
+func toHARNameValues(values map[string][]string) []harNameValue {
+	rs := []harNameValue{}
+	for _, k := range sortedKeys(values) {
+		for _, v := range values[k] {
+			rs = append(rs, harNameValue{Name: k, Value: v})
+		}
+	}
+	return rs
+}
+
+// curlExporter writes a shell script sending every event again with curl, to TARGET_URL when set.
+type curlExporter struct {
+	roomID  string
+	pushURL string
+}
+
+func (*curlExporter) contentType() string { return "text/x-shellscript; charset=utf-8" }
+
+func (*curlExporter) extension() string { return ".sh" }
+
+func (e *curlExporter) begin(w io.Writer) error {
+	// Quotes within "${TARGET_URL:-...}" are kept by some shells, the default is set apart instead
+	_, err := fmt.Fprintf(w, "#!/bin/sh\n"+
+		"# Events of pistol room %s, newest first.\n"+
+		"# Set TARGET_URL to send them somewhere else.\n"+
+		"if [ -z \"$TARGET_URL\" ]; then\n"+
+		"  TARGET_URL=%s\n"+
+		"fi\n",
+		shellQuote(e.roomID), shellQuote(e.pushURL))
+	return err
+}
+
+func (e *curlExporter) write(w io.Writer, event domain.Event) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n# Event %d captured at %s\n", event.ID, event.CreatedAt.UTC().Format(time.RFC3339))
+
+	if len(event.Body) > 0 {
+		if event.BodyBase64 {
+			fmt.Fprintf(&b, "printf '%%s' %s | base64 -d | ", shellQuote(base64.StdEncoding.EncodeToString(event.Body)))
+		} else {
+			fmt.Fprintf(&b, "printf '%%s' %s | ", shellQuote(string(event.Body)))
+		}
+	}
+
+	fmt.Fprintf(&b, "curl -sS -X %s", shellQuote(event.Method))
+	for _, k := range sortedKeys(event.Header) {
+		if slices.Contains(skippedExportHeaders, k) {
+			continue
+		}
+		for _, v := range event.Header[k] {
+			fmt.Fprintf(&b, " \\\n  -H %s", shellQuote(k+": "+v))
+		}
+	}
+	if len(event.Body) > 0 {
+		b.WriteString(" \\\n  --data-binary @-")
+	}
+
+	b.WriteString(" \\\n  \"$TARGET_URL\"")
+	suffix := event.Path
+	if len(event.QueryParams) > 0 {
+		suffix += "?" + url.Values(event.QueryParams).Encode()
+	}
+	if suffix != "" {
+		b.WriteString(shellQuote(suffix))
+	}
+	b.WriteString("\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (*curlExporter) end(io.Writer) error { return nil }
+
+// shellQuote quotes s for a POSIX shell, as a single quoted string.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
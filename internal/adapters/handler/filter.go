@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/erwin-lovecraft/pistol/internal/core/domain"
+	"github.com/erwin-lovecraft/pistol/internal/core/ports"
+	"github.com/erwin-lovecraft/pistol/pkg/jsonpath"
+)
+
+// eventFilterFromRequest reads the event filter from the query params:
+//
+//	signature=verified|failed|missing
+//	method=POST
+//	from=2025-01-01T00:00:00Z&to=2025-01-02T00:00:00Z
+//	header=X-GitHub-Event or header=X-GitHub-Event:push, repeatable
+//	query=ref or query=ref:main, repeatable
+//	body=invoice.payment_failed
+//	jsonpath=$.action == "opened"
+//	path=/github, a suffix of what follows /push
+func eventFilterFromRequest(r *http.Request) (ports.EventFilter, error) {
+	return eventFilterFromQuery(r.URL.Query())
+}
+
+func eventFilterFromQuery(query url.Values) (ports.EventFilter, error) {
+	var filter ports.EventFilter
+	switch v := query.Get("signature"); v {
+	case "", domain.SignatureVerified, domain.SignatureFailed, domain.SignatureMissing:
+		filter.SignatureVerdict = v
+	default:
+		return ports.EventFilter{}, errors.New("invalid signature filter")
+	}
+
+	filter.Method = strings.ToUpper(strings.TrimSpace(query.Get("method")))
+
+	var err error
+	if v := query.Get("from"); v != "" {
+		if filter.From, err = time.Parse(time.RFC3339, v); err != nil {
+			return ports.EventFilter{}, errors.New("invalid from, expected RFC 3339 time")
+		}
+	}
+	if v := query.Get("to"); v != "" {
+		if filter.To, err = time.Parse(time.RFC3339, v); err != nil {
+			return ports.EventFilter{}, errors.New("invalid to, expected RFC 3339 time")
+		}
+	}
+
+	if filter.Headers, err = parseValueMatches(query["header"], http.CanonicalHeaderKey); err != nil {
+		return ports.EventFilter{}, fmt.Errorf("invalid header filter: %w", err)
+	}
+	if filter.QueryParams, err = parseValueMatches(query["query"], func(s string) string { return s }); err != nil {
+		return ports.EventFilter{}, fmt.Errorf("invalid query filter: %w", err)
+	}
+
+	if v := query.Get("body"); v != "" {
+		filter.BodyContains = []byte(v)
+	}
+
+	filter.PathSuffix = query.Get("path")
+
+	if v := query.Get("jsonpath"); v != "" {
+		if filter.BodyPath, err = jsonpath.Parse(v); err != nil {
+			return ports.EventFilter{}, err
+		}
+	}
+
+	return filter, nil
+}
+
+// parseValueMatches reads "name" or "name:value" matches, several values of a name must all be present.
+func parseValueMatches(raw []string, canonical func(string) string) (map[string][]string, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	matches := make(map[string][]string, len(raw))
+	for _, v := range raw {
+		name, value, hasValue := strings.Cut(v, ":")
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, errors.New("missing name")
+		}
+		name = canonical(name)
+
+		if !hasValue {
+			if _, ok := matches[name]; !ok {
+				matches[name] = nil
+			}
+			continue
+		}
+		matches[name] = append(matches[name], strings.TrimSpace(value))
+	}
+	return matches, nil
+}
@@ -2,12 +2,17 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"html/template"
 	"io"
+	"log"
 	"net/http"
+	"net/netip"
+	"time"
 
 	"github.com/erwin-lovecraft/pistol/internal/core/domain"
 	"github.com/erwin-lovecraft/pistol/internal/core/services"
+	"github.com/erwin-lovecraft/pistol/pkg/ssehub"
 	"github.com/go-chi/chi/v5"
 )
 
@@ -17,17 +22,31 @@ var (
 
 type Handler struct {
 	svc services.Service
+	// trustedProxies may set X-Forwarded-For, see clientIP
+	trustedProxies []netip.Prefix
+	secretKey      string
 }
 
-func New(svc services.Service) (Handler, error) {
-	tpl, err := loadTemplates("internal/web")
+type Options struct {
+	// TemplateDir holds the HTML templates of the web UI
+	TemplateDir string
+	// TrustedProxies may set X-Forwarded-For on pushes
+	TrustedProxies []netip.Prefix
+	// SecretKey is the admin key, accepted in place of any room token
+	SecretKey string
+}
+
+func New(svc services.Service, opts Options) (Handler, error) {
+	tpl, err := loadTemplates(opts.TemplateDir)
 	if err != nil {
 		return Handler{}, err
 	}
 	webTpl = tpl
 
 	return Handler{
-		svc: svc,
+		svc:            svc,
+		trustedProxies: opts.TrustedProxies,
+		secretKey:      opts.SecretKey,
 	}, nil
 }
 
@@ -39,16 +58,69 @@ func (h Handler) ListenEvents() http.HandlerFunc {
 			return
 		}
 
-		cl, err := h.svc.ListenEvents(r.Context(), roomID, w)
+		opts, err := h.listenOptionsFromRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		t, err := ssehub.NewSSETransport(w)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
+		cl, err := h.svc.ListenEvents(r.Context(), roomID, opts, t)
+		if err != nil {
+			if errors.Is(err, domain.ErrRoomNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			if errors.Is(err, ssehub.ErrHubClosed) {
+				http.Error(w, err.Error(), http.StatusServiceUnavailable)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
 		<-cl.Wait()
 	}
 }
 
+// listenOptionsFromRequest reads how the client wants to be streamed the room's events.
+func (h Handler) listenOptionsFromRequest(r *http.Request) (services.ListenOptions, error) {
+	// Browsers send Last-Event-ID when reconnecting, polyfills may only be able to pass it as query param
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("lastEventId")
+	}
+
+	var (
+		policy ssehub.DeliveryPolicy
+		err    error
+	)
+	if v := r.URL.Query().Get("delivery"); v != "" {
+		if policy, err = ssehub.ParseDeliveryPolicy(v); err != nil {
+			return services.ListenOptions{}, err
+		}
+	}
+
+	// Streams take the history's filters, the hub drops what doesn't match before queueing it
+	filter, err := eventFilterFromRequest(r)
+	if err != nil {
+		return services.ListenOptions{}, err
+	}
+
+	return services.ListenOptions{
+		LastEventID:    lastEventID,
+		DeliveryPolicy: policy,
+		RemoteAddr:     h.clientIP(r),
+		UserAgent:      r.UserAgent(),
+		Filter:         filter,
+	}, nil
+}
+
 func (h Handler) ListEvents() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		roomID := chi.URLParam(r, "roomID")
@@ -63,7 +135,13 @@ func (h Handler) ListEvents() http.HandlerFunc {
 			return
 		}
 
-		rs, hasMore, err := h.svc.ListEvents(r.Context(), roomID, pagination.Page, pagination.Size)
+		filter, err := eventFilterFromRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		rs, hasMore, err := h.svc.ListEvents(r.Context(), roomID, filter, pagination.Cursor())
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -72,10 +150,10 @@ func (h Handler) ListEvents() http.HandlerFunc {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		if err := json.NewEncoder(w).Encode(map[string]interface{}{
-			"data":    rs,
-			"page":    pagination.Page,
-			"size":    pagination.Size,
-			"hasMore": hasMore,
+			"data":        rs,
+			"size":        pagination.Size,
+			"hasMore":     hasMore,
+			"next_cursor": pagination.NextCursor(rs, hasMore),
 		}); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 		}
@@ -83,10 +161,6 @@ func (h Handler) ListEvents() http.HandlerFunc {
 }
 
 func (h Handler) PushEvent() http.HandlerFunc {
-	type response struct {
-		Message string `json:"message"`
-	}
-
 	return func(w http.ResponseWriter, r *http.Request) {
 		roomID := chi.URLParam(r, "roomID")
 		if roomID == "" {
@@ -94,26 +168,50 @@ func (h Handler) PushEvent() http.HandlerFunc {
 			return
 		}
 
+		start := time.Now()
 		reqBody, err := io.ReadAll(r.Body)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		if err := h.svc.PushEvent(r.Context(), roomID, domain.Event{
-			Method:      r.Method,
-			Header:      r.Header,
-			QueryParams: r.URL.Query(),
-			Body:        reqBody,
-		}); err != nil {
+		resp, err := h.svc.PushEvent(r.Context(), roomID, domain.Event{
+			Method:        r.Method,
+			Header:        r.Header,
+			QueryParams:   r.URL.Query(),
+			Body:          reqBody,
+			ContentType:   r.Header.Get("Content-Type"),
+			ContentLength: int64(len(reqBody)),
+			Path:          pushPath(r),
+			RemoteAddr:    h.clientIP(r),
+			Proto:         r.Proto,
+			Host:          r.Host,
+			TLS:           toTLSInfo(r.TLS),
+			DurationUS:    time.Since(start).Microseconds(),
+		})
+		if err != nil {
+			if errors.Is(err, domain.ErrRoomNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
 			http.Error(w, "relay error", http.StatusInternalServerError)
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		if err := json.NewEncoder(w).Encode(response{Message: "ok"}); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+		if resp.DelayMS > 0 {
+			select {
+			case <-time.After(time.Duration(resp.DelayMS) * time.Millisecond):
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		for k, v := range resp.Headers {
+			w.Header().Set(k, v)
+		}
+		w.WriteHeader(resp.StatusCode)
+		if _, err := io.WriteString(w, resp.Body); err != nil {
+			log.Printf("failed to write response: %v", err)
 		}
 	}
 }
@@ -126,6 +224,19 @@ func (h Handler) ViewRoom() http.HandlerFunc {
 			return
 		}
 
+		if _, err := h.svc.GetRoom(r.Context(), roomID); err != nil {
+			if errors.Is(err, domain.ErrRoomNotFound) {
+				w.Header().Set("Content-Type", "text/html; charset=utf-8")
+				w.WriteHeader(http.StatusNotFound)
+				if err := webTpl.ExecuteTemplate(w, "notfound.html", nil); err != nil {
+					log.Printf("failed to render template: %v", err)
+				}
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		if err := webTpl.ExecuteTemplate(w, "view.html", map[string]string{
 			"RoomID": roomID,
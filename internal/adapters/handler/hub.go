@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/erwin-lovecraft/pistol/pkg/ssehub"
+	"github.com/go-chi/chi/v5"
+)
+
+// ListHubRooms lists the SSE clients by room. Like the other hub endpoints it only sees the clients connected to this
+// instance, not those of other replicas.
+func (h Handler) ListHubRooms() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"data": h.svc.HubRooms(),
+		})
+	}
+}
+
+func (h Handler) ListHubClients() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		roomID := chi.URLParam(r, "roomID")
+		if roomID == "" {
+			http.Error(w, "roomID is required", http.StatusBadRequest)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"data": h.svc.HubClients(roomID),
+		})
+	}
+}
+
+func (h Handler) DisconnectRoom() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		roomID := chi.URLParam(r, "roomID")
+		if roomID == "" {
+			http.Error(w, "roomID is required", http.StatusBadRequest)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"data": map[string]interface{}{
+				"disconnected": h.svc.DisconnectRoom(roomID),
+			},
+		})
+	}
+}
+
+func (h Handler) DisconnectClient() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		roomID := chi.URLParam(r, "roomID")
+		if roomID == "" {
+			http.Error(w, "roomID is required", http.StatusBadRequest)
+			return
+		}
+		clientID := chi.URLParam(r, "clientID")
+		if clientID == "" {
+			http.Error(w, "clientID is required", http.StatusBadRequest)
+			return
+		}
+
+		if err := h.svc.DisconnectClient(roomID, clientID); err != nil {
+			if errors.Is(err, ssehub.ErrClientNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
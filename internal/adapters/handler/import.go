@@ -0,0 +1,236 @@
+package handler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/erwin-lovecraft/pistol/internal/core/domain"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	maxImportBytes = 32 << 20
+)
+
+var (
+	// pistolPushPath matches the URLs of HAR archives exported by pistol, what follows is the event's path
+	pistolPushPath = regexp.MustCompile(`^/api/v1/rooms/[^/]+/push`)
+)
+
+// ImportEvents stores the events of a HAR archive or of NDJSON as exported by pistol into the room.
+// The file is the request body, or the "file" field of a multipart form.
+func (h Handler) ImportEvents() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		roomID := chi.URLParam(r, "roomID")
+		if roomID == "" {
+			http.Error(w, "roomID is required", http.StatusBadRequest)
+			return
+		}
+
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = exportFormatNDJSON
+		}
+		if format != exportFormatNDJSON && format != exportFormatHAR {
+			http.Error(w, "invalid format, expected ndjson or har", http.StatusBadRequest)
+			return
+		}
+
+		var broadcast bool
+		if v := r.URL.Query().Get("broadcast"); v != "" {
+			var err error
+			if broadcast, err = strconv.ParseBool(v); err != nil {
+				http.Error(w, "invalid broadcast", http.StatusBadRequest)
+				return
+			}
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+		var src io.Reader = r.Body
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+			file, _, err := r.FormFile("file")
+			if err != nil {
+				writeImportError(w, fmt.Errorf("%w: missing file: %v", domain.ErrInvalidImport, err))
+				return
+			}
+			defer file.Close()
+			src = file
+		}
+
+		var (
+			events []domain.Event
+			err    error
+		)
+		if format == exportFormatHAR {
+			events, err = readHAREvents(src)
+		} else {
+			events, err = readNDJSONEvents(src)
+		}
+		if err != nil {
+			writeImportError(w, err)
+			return
+		}
+
+		imported, err := h.svc.ImportEvents(r.Context(), roomID, events, broadcast)
+		if err != nil {
+			switch {
+			case errors.Is(err, domain.ErrRoomNotFound):
+				http.Error(w, err.Error(), http.StatusNotFound)
+			case errors.Is(err, domain.ErrInvalidImport):
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			default:
+				http.Error(w, fmt.Sprintf("imported %d events: %v", imported, err), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, map[string]interface{}{
+			"data": map[string]interface{}{
+				"imported": imported,
+			},
+		})
+	}
+}
+
+func writeImportError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		http.Error(w, fmt.Sprintf("import is larger than %d bytes", maxBytesErr.Limit), http.StatusRequestEntityTooLarge)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusBadRequest)
+}
+
+func readNDJSONEvents(r io.Reader) ([]domain.Event, error) {
+	dec := json.NewDecoder(r)
+
+	var events []domain.Event
+	for {
+		var event domain.Event
+		if err := dec.Decode(&event); err != nil {
+			if errors.Is(err, io.EOF) {
+				return events, nil
+			}
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("%w: event %d: %v", domain.ErrInvalidImport, len(events)+1, err)
+		}
+		if len(events) == domain.MaxImportEvents {
+			return nil, fmt.Errorf("%w: more than %d events", domain.ErrInvalidImport, domain.MaxImportEvents)
+		}
+		events = append(events, event)
+	}
+}
+
+func readHAREvents(r io.Reader) ([]domain.Event, error) {
+	var har harLog
+	if err := json.NewDecoder(r).Decode(&har); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidImport, err)
+	}
+	if len(har.Log.Entries) > domain.MaxImportEvents {
+		return nil, fmt.Errorf("%w: more than %d events", domain.ErrInvalidImport, domain.MaxImportEvents)
+	}
+
+	events := make([]domain.Event, 0, len(har.Log.Entries))
+	for idx, entry := range har.Log.Entries {
+		event, err := harEntryToEvent(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%w: entry %d: %v", domain.ErrInvalidImport, idx+1, err)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func harEntryToEvent(entry harEntry) (domain.Event, error) {
+	createdAt, err := time.Parse(time.RFC3339Nano, entry.StartedDateTime)
+	if err != nil {
+		return domain.Event{}, errors.New("invalid startedDateTime")
+	}
+
+	header := http.Header{}
+	for _, h := range entry.Request.Headers {
+		// HTTP/2 pseudo headers, like :authority, aren't headers of the request
+		if strings.HasPrefix(h.Name, ":") {
+			continue
+		}
+		header.Add(h.Name, h.Value)
+	}
+
+	reqURL, err := url.Parse(entry.Request.URL)
+	if err != nil {
+		return domain.Event{}, errors.New("invalid request url")
+	}
+
+	query := make(map[string][]string)
+	for _, q := range entry.Request.QueryString {
+		query[q.Name] = append(query[q.Name], q.Value)
+	}
+	if len(query) == 0 {
+		query = reqURL.Query()
+	}
+
+	path := reqURL.Path
+	if loc := pistolPushPath.FindStringIndex(path); loc != nil {
+		path = path[loc[1]:]
+	}
+
+	event := domain.Event{
+		Method:      entry.Request.Method,
+		Header:      header,
+		QueryParams: query,
+		ContentType: header.Get("Content-Type"),
+		Path:        path,
+		Proto:       entry.Request.HTTPVersion,
+		Host:        reqURL.Host,
+		CreatedAt:   createdAt,
+	}
+	if pd := entry.Request.PostData; pd != nil {
+		event.Body = []byte(pd.Text)
+		if pd.Encoding == "base64" {
+			if event.Body, err = base64.StdEncoding.DecodeString(pd.Text); err != nil {
+				return domain.Event{}, errors.New("invalid base64 postData")
+			}
+		}
+		if pd.MimeType != "" {
+			event.ContentType = pd.MimeType
+		}
+	}
+
+	if resp := entry.Response; resp.Status != 0 {
+		event.Response = &domain.EventResponse{
+			StatusCode: resp.Status,
+			Headers:    make(map[string]string, len(resp.Headers)),
+			Body:       resp.Content.Text,
+			DelayMS:    int64(entry.Timings.Wait),
+		}
+		for _, h := range resp.Headers {
+			event.Response.Headers[h.Name] = h.Value
+		}
+		if resp.Content.Encoding == "base64" {
+			// The response body is kept as text, binary ones stay encoded
+			if body, err := base64.StdEncoding.DecodeString(resp.Content.Text); err == nil && utf8.Valid(body) {
+				event.Response.Body = string(body)
+			}
+		}
+	}
+
+	return event, nil
+}
@@ -1,30 +1,69 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+
+	"github.com/erwin-lovecraft/pistol/internal/core/domain"
+	"github.com/erwin-lovecraft/pistol/internal/core/ports"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
 )
 
+// Pagination is a keyset cursor on event IDs, given as before or after the ID of an event already seen.
 type Pagination struct {
-	Page, Size int
+	Before, After int64
+	Size          int
 }
 
 func (p *Pagination) FromRequest(r *http.Request) error {
-	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
-		page, err := strconv.Atoi(pageStr)
-		if err != nil {
-			return err
+	var err error
+	if beforeStr := r.URL.Query().Get("before"); beforeStr != "" {
+		if p.Before, err = strconv.ParseInt(beforeStr, 10, 64); err != nil || p.Before <= 0 {
+			return errors.New("invalid before cursor")
 		}
-		p.Page = page
+	}
+	if afterStr := r.URL.Query().Get("after"); afterStr != "" {
+		if p.After, err = strconv.ParseInt(afterStr, 10, 64); err != nil || p.After <= 0 {
+			return errors.New("invalid after cursor")
+		}
+	}
+	if p.Before > 0 && p.After > 0 {
+		return errors.New("before and after cursors can't be used together")
 	}
 
+	p.Size = defaultPageSize
 	if sizeStr := r.URL.Query().Get("size"); sizeStr != "" {
 		size, err := strconv.Atoi(sizeStr)
-		if err != nil {
-			return err
+		if err != nil || size <= 0 {
+			return errors.New("invalid size")
 		}
-		p.Size = size
+		p.Size = min(size, maxPageSize)
 	}
 
 	return nil
 }
+
+func (p Pagination) Cursor() ports.EventCursor {
+	return ports.EventCursor{
+		Before: p.Before,
+		After:  p.After,
+		Limit:  p.Size,
+	}
+}
+
+// NextCursor is the cursor of the page following events in the same direction, empty when there's none.
+// It's a string as event IDs don't fit in a JavaScript number.
+func (p Pagination) NextCursor(events []domain.Event, hasMore bool) string {
+	if !hasMore || len(events) == 0 {
+		return ""
+	}
+	if p.After > 0 {
+		return strconv.FormatInt(events[0].ID, 10)
+	}
+	return strconv.FormatInt(events[len(events)-1].ID, 10)
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/erwin-lovecraft/pistol/internal/core/domain"
+	"github.com/go-chi/chi/v5"
+)
+
+func (h Handler) ReplayEvent() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		roomID := chi.URLParam(r, "roomID")
+		if roomID == "" {
+			http.Error(w, "roomID is required", http.StatusBadRequest)
+			return
+		}
+		eventID, err := strconv.ParseInt(chi.URLParam(r, "eventID"), 10, 64)
+		if err != nil {
+			http.Error(w, "invalid eventID", http.StatusBadRequest)
+			return
+		}
+
+		var req domain.ReplayRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		attempt, err := h.svc.ReplayEvent(r.Context(), roomID, eventID, req)
+		if err != nil {
+			switch {
+			case errors.Is(err, domain.ErrEventNotFound):
+				http.Error(w, err.Error(), http.StatusNotFound)
+			case errors.Is(err, domain.ErrInvalidReplayTarget):
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			default:
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"data": attempt,
+		})
+	}
+}
+
+func (h Handler) ListReplayAttempts() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		roomID := chi.URLParam(r, "roomID")
+		if roomID == "" {
+			http.Error(w, "roomID is required", http.StatusBadRequest)
+			return
+		}
+		eventID, err := strconv.ParseInt(chi.URLParam(r, "eventID"), 10, 64)
+		if err != nil {
+			http.Error(w, "invalid eventID", http.StatusBadRequest)
+			return
+		}
+
+		attempts, err := h.svc.ListReplayAttempts(r.Context(), roomID, eventID)
+		if err != nil {
+			if errors.Is(err, domain.ErrEventNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if attempts == nil {
+			attempts = []domain.ReplayAttempt{}
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"data": attempts,
+		})
+	}
+}
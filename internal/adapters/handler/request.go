@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/erwin-lovecraft/pistol/internal/core/domain"
+	"github.com/go-chi/chi/v5"
+)
+
+// pushPath returns what follows /push in the URL, empty when pushed to the room itself.
+func pushPath(r *http.Request) string {
+	path := chi.URLParam(r, "*")
+	if path == "" {
+		return ""
+	}
+	return "/" + path
+}
+
+// clientIP returns the address of the client sending r. X-Forwarded-For is only believed when the connection comes
+// from a trusted proxy, and is walked right to left past the trusted hops, so clients can't spoof it.
+func (h Handler) clientIP(r *http.Request) string {
+	remote := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		remote = host
+	}
+	addr, err := netip.ParseAddr(remote)
+	if err != nil || !h.isTrustedProxy(addr) {
+		return remote
+	}
+
+	var hops []string
+	for _, v := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(v, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			// Whatever is left of a malformed hop can't be trusted
+			break
+		}
+		addr = hop
+		if !h.isTrustedProxy(hop) {
+			break
+		}
+	}
+	return addr.Unmap().String()
+}
+
+func (h Handler) isTrustedProxy(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range h.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func toTLSInfo(cs *tls.ConnectionState) *domain.TLSInfo {
+	if cs == nil {
+		return nil
+	}
+	return &domain.TLSInfo{
+		Version:            tls.VersionName(cs.Version),
+		CipherSuite:        tls.CipherSuiteName(cs.CipherSuite),
+		ServerName:         cs.ServerName,
+		NegotiatedProtocol: cs.NegotiatedProtocol,
+	}
+}
@@ -0,0 +1,348 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/erwin-lovecraft/pistol/internal/core/domain"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	maxRoomNameLength = 100
+)
+
+type roomRequest struct {
+	Name   string `json:"name"`
+	Avatar string `json:"avatar"`
+}
+
+func (req *roomRequest) validate() error {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(req.Name) > maxRoomNameLength {
+		return errors.New("name is too long")
+	}
+	return nil
+}
+
+func (h Handler) CreateRoom() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req roomRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		if err := req.validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		room, link, tokens, err := h.svc.CreateRoom(r.Context(), req.Name, req.Avatar)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, map[string]interface{}{
+			"data":   room.Redacted(),
+			"link":   link,
+			"tokens": tokens,
+		})
+	}
+}
+
+func (h Handler) GetRoom() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		roomID := chi.URLParam(r, "roomID")
+		if roomID == "" {
+			http.Error(w, "roomID is required", http.StatusBadRequest)
+			return
+		}
+
+		room, err := h.svc.GetRoom(r.Context(), roomID)
+		if err != nil {
+			if errors.Is(err, domain.ErrRoomNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"data": room.Redacted(),
+		})
+	}
+}
+
+func (h Handler) ListRooms() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rooms, err := h.svc.ListRoom(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		redacted := make([]domain.Room, len(rooms))
+		for idx, room := range rooms {
+			redacted[idx] = room.Redacted()
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"data": redacted,
+		})
+	}
+}
+
+func (h Handler) RenameRoom() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		roomID := chi.URLParam(r, "roomID")
+		if roomID == "" {
+			http.Error(w, "roomID is required", http.StatusBadRequest)
+			return
+		}
+
+		var req roomRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		if err := req.validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		room, err := h.svc.RenameRoom(r.Context(), roomID, req.Name)
+		if err != nil {
+			if errors.Is(err, domain.ErrRoomNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"data": room.Redacted(),
+		})
+	}
+}
+
+func (h Handler) DeleteRoom() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		roomID := chi.URLParam(r, "roomID")
+		if roomID == "" {
+			http.Error(w, "roomID is required", http.StatusBadRequest)
+			return
+		}
+
+		if err := h.svc.DeleteRoom(r.Context(), roomID); err != nil {
+			if errors.Is(err, domain.ErrRoomNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h Handler) SetRoomResponse() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		roomID := chi.URLParam(r, "roomID")
+		if roomID == "" {
+			http.Error(w, "roomID is required", http.StatusBadRequest)
+			return
+		}
+
+		var rule *domain.ResponseRule
+		if r.Method != http.MethodDelete {
+			if err := json.NewDecoder(r.Body).Decode(&rule); err != nil || rule == nil {
+				http.Error(w, "invalid request body", http.StatusBadRequest)
+				return
+			}
+		}
+
+		room, err := h.svc.SetRoomResponse(r.Context(), roomID, rule)
+		if err != nil {
+			switch {
+			case errors.Is(err, domain.ErrRoomNotFound):
+				http.Error(w, err.Error(), http.StatusNotFound)
+			case errors.Is(err, domain.ErrInvalidResponseRule):
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			default:
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"data": room.Redacted(),
+		})
+	}
+}
+
+func (h Handler) SetRoomForwards() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		roomID := chi.URLParam(r, "roomID")
+		if roomID == "" {
+			http.Error(w, "roomID is required", http.StatusBadRequest)
+			return
+		}
+
+		var rules []domain.ForwardRule
+		if err := json.NewDecoder(r.Body).Decode(&rules); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		room, err := h.svc.SetRoomForwards(r.Context(), roomID, rules)
+		if err != nil {
+			switch {
+			case errors.Is(err, domain.ErrRoomNotFound):
+				http.Error(w, err.Error(), http.StatusNotFound)
+			case errors.Is(err, domain.ErrInvalidForwardRule):
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			default:
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"data": room.Redacted(),
+		})
+	}
+}
+
+func (h Handler) SetRoomSignature() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		roomID := chi.URLParam(r, "roomID")
+		if roomID == "" {
+			http.Error(w, "roomID is required", http.StatusBadRequest)
+			return
+		}
+
+		var profile *domain.SignatureProfile
+		if r.Method != http.MethodDelete {
+			if err := json.NewDecoder(r.Body).Decode(&profile); err != nil || profile == nil {
+				http.Error(w, "invalid request body", http.StatusBadRequest)
+				return
+			}
+		}
+
+		room, err := h.svc.SetRoomSignature(r.Context(), roomID, profile)
+		if err != nil {
+			switch {
+			case errors.Is(err, domain.ErrRoomNotFound):
+				http.Error(w, err.Error(), http.StatusNotFound)
+			case errors.Is(err, domain.ErrInvalidSignatureProfile):
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			default:
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"data": room.Redacted(),
+		})
+	}
+}
+
+func (h Handler) SetRoomRetention() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		roomID := chi.URLParam(r, "roomID")
+		if roomID == "" {
+			http.Error(w, "roomID is required", http.StatusBadRequest)
+			return
+		}
+
+		var policy *domain.RetentionPolicy
+		if r.Method != http.MethodDelete {
+			if err := json.NewDecoder(r.Body).Decode(&policy); err != nil || policy == nil {
+				http.Error(w, "invalid request body", http.StatusBadRequest)
+				return
+			}
+		}
+
+		room, err := h.svc.SetRoomRetention(r.Context(), roomID, policy)
+		if err != nil {
+			switch {
+			case errors.Is(err, domain.ErrRoomNotFound):
+				http.Error(w, err.Error(), http.StatusNotFound)
+			case errors.Is(err, domain.ErrInvalidRetentionPolicy):
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			default:
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"data": room.Redacted(),
+		})
+	}
+}
+
+func (h Handler) SetRoomRedaction() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		roomID := chi.URLParam(r, "roomID")
+		if roomID == "" {
+			http.Error(w, "roomID is required", http.StatusBadRequest)
+			return
+		}
+
+		var rules []domain.RedactionRule
+		if r.Method != http.MethodDelete {
+			if err := json.NewDecoder(r.Body).Decode(&rules); err != nil {
+				http.Error(w, "invalid request body", http.StatusBadRequest)
+				return
+			}
+		}
+
+		room, err := h.svc.SetRoomRedaction(r.Context(), roomID, rules)
+		if err != nil {
+			switch {
+			case errors.Is(err, domain.ErrRoomNotFound):
+				http.Error(w, err.Error(), http.StatusNotFound)
+			case errors.Is(err, domain.ErrInvalidRedactionRule):
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			default:
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"data": room.Redacted(),
+		})
+	}
+}
+
+func (h Handler) GetRedaction() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"data": h.svc.RedactionRules(),
+		})
+	}
+}
+
+func (h Handler) GetRetention() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		policy, stats := h.svc.Retention()
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"data": map[string]interface{}{
+				"policy": policy,
+				"stats":  stats,
+			},
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/erwin-lovecraft/pistol/internal/core/domain"
+	pkgmiddleware "github.com/erwin-lovecraft/pistol/pkg/middleware"
+	"github.com/go-chi/chi/v5"
+)
+
+type roomTokenRequest struct {
+	Name      string     `json:"name"`
+	Scope     string     `json:"scope"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// RoomAuth only lets through requests carrying a room token of the scope, or the admin secret key.
+func (h Handler) RoomAuth(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if pkgmiddleware.HasSecretKey(r, h.secretKey) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			token := r.Header.Get(domain.RoomTokenHeader)
+			if token == "" {
+				token = r.URL.Query().Get(domain.RoomTokenParam)
+			}
+
+			if err := h.svc.VerifyRoomToken(r.Context(), chi.URLParam(r, "roomID"), scope, token); err != nil {
+				if errors.Is(err, domain.ErrUnauthorized) {
+					http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+					return
+				}
+				log.Printf("failed to verify room token: %v", err)
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (h Handler) CreateRoomToken() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		roomID := chi.URLParam(r, "roomID")
+		if roomID == "" {
+			http.Error(w, "roomID is required", http.StatusBadRequest)
+			return
+		}
+
+		var req roomTokenRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		token, err := h.svc.CreateRoomToken(r.Context(), roomID, req.Name, req.Scope, req.ExpiresAt)
+		if err != nil {
+			switch {
+			case errors.Is(err, domain.ErrRoomNotFound):
+				http.Error(w, err.Error(), http.StatusNotFound)
+			case errors.Is(err, domain.ErrInvalidToken):
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			default:
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, map[string]interface{}{
+			"data": token,
+		})
+	}
+}
+
+func (h Handler) ListRoomTokens() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		roomID := chi.URLParam(r, "roomID")
+		if roomID == "" {
+			http.Error(w, "roomID is required", http.StatusBadRequest)
+			return
+		}
+
+		tokens, err := h.svc.ListRoomTokens(r.Context(), roomID)
+		if err != nil {
+			if errors.Is(err, domain.ErrRoomNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"data": tokens,
+		})
+	}
+}
+
+func (h Handler) RotateRoomToken() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		roomID := chi.URLParam(r, "roomID")
+		if roomID == "" {
+			http.Error(w, "roomID is required", http.StatusBadRequest)
+			return
+		}
+		tokenID, err := strconv.ParseInt(chi.URLParam(r, "tokenID"), 10, 64)
+		if err != nil {
+			http.Error(w, "invalid tokenID", http.StatusBadRequest)
+			return
+		}
+
+		token, err := h.svc.RotateRoomToken(r.Context(), roomID, tokenID)
+		if err != nil {
+			if errors.Is(err, domain.ErrTokenNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, map[string]interface{}{
+			"data": token,
+		})
+	}
+}
+
+func (h Handler) RevokeRoomToken() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		roomID := chi.URLParam(r, "roomID")
+		if roomID == "" {
+			http.Error(w, "roomID is required", http.StatusBadRequest)
+			return
+		}
+		tokenID, err := strconv.ParseInt(chi.URLParam(r, "tokenID"), 10, 64)
+		if err != nil {
+			http.Error(w, "invalid tokenID", http.StatusBadRequest)
+			return
+		}
+
+		token, err := h.svc.RevokeRoomToken(r.Context(), roomID, tokenID)
+		if err != nil {
+			if errors.Is(err, domain.ErrTokenNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"data": token,
+		})
+	}
+}
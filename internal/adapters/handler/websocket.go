@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/erwin-lovecraft/pistol/internal/core/domain"
+	"github.com/erwin-lovecraft/pistol/internal/core/services"
+	"github.com/erwin-lovecraft/pistol/pkg/ssehub"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	wsWriteTimeout = 10 * time.Second
+)
+
+const (
+	wsCommandPause  = "pause"
+	wsCommandResume = "resume"
+	wsCommandFilter = "filter"
+	// wsTypeError answers a command that can't be run
+	wsTypeError = "error"
+)
+
+// wsMessage is a hub message as sent over WebSocket, JSON data is embedded as is.
+type wsMessage struct {
+	Type  string          `json:"type"`
+	ID    string          `json:"id,omitempty"`
+	Data  json.RawMessage `json:"data,omitempty"`
+	Retry int64           `json:"retry,omitempty"`
+}
+
+// wsCommand is what WebSocket clients send to control their stream:
+//
+//	{"type": "pause"}
+//	{"type": "resume"}
+//	{"type": "filter", "filter": "method=POST&header=X-GitHub-Event:push"}
+//
+// Filters take history's query params, an empty filter lets every event through again.
+type wsCommand struct {
+	Type   string `json:"type"`
+	Filter string `json:"filter"`
+}
+
+// wsTransport writes hub messages as JSON text frames.
+type wsTransport struct {
+	ctx  context.Context
+	conn *websocket.Conn
+}
+
+func (t wsTransport) Open() error {
+	return nil
+}
+
+func (t wsTransport) Write(e ssehub.Message) error {
+	msg := wsMessage{
+		Type:  e.Event,
+		ID:    e.ID,
+		Retry: e.Retry,
+	}
+	if msg.Type == "" {
+		msg.Type = services.EventTypeMessage
+	}
+	if json.Valid([]byte(e.Data)) {
+		msg.Data = json.RawMessage(e.Data)
+	} else {
+		msg.Data, _ = json.Marshal(e.Data)
+	}
+	return t.write(msg)
+}
+
+func (t wsTransport) write(msg wsMessage) error {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(t.ctx, wsWriteTimeout)
+	defer cancel()
+	return t.conn.Write(ctx, websocket.MessageText, b)
+}
+
+// ListenEventsWS streams the room's events over WebSocket, for clients behind proxies buffering SSE or that prefer
+// it. It takes the same params as the SSE stream and the commands of wsCommand.
+func (h Handler) ListenEventsWS() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		roomID := chi.URLParam(r, "roomID")
+		if roomID == "" {
+			http.Error(w, "roomID is required", http.StatusBadRequest)
+			return
+		}
+
+		opts, err := h.listenOptionsFromRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Answer unknown rooms before the upgrade, a closed WebSocket says little about why
+		if _, err := h.svc.GetRoom(r.Context(), roomID); err != nil {
+			if errors.Is(err, domain.ErrRoomNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+			// Clients authenticate with tokens rather than cookies, other origins can't act on their behalf
+			InsecureSkipVerify: true,
+		})
+		if err != nil {
+			// Accept has answered already
+			log.Printf("failed to accept websocket: %v", err)
+			return
+		}
+		defer conn.CloseNow()
+
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+
+		t := wsTransport{ctx: ctx, conn: conn}
+		cl, err := h.svc.ListenEvents(ctx, roomID, opts, t)
+		if err != nil {
+			if errors.Is(err, ssehub.ErrHubClosed) {
+				conn.Close(websocket.StatusTryAgainLater, err.Error())
+				return
+			}
+			conn.Close(websocket.StatusInternalError, err.Error())
+			return
+		}
+
+		go func() {
+			// The client is gone once its connection can't be read anymore
+			defer cancel()
+			readWSCommands(ctx, t, cl)
+		}()
+
+		<-cl.Wait()
+		conn.Close(websocket.StatusNormalClosure, "")
+	}
+}
+
+// readWSCommands runs the client's commands until its connection is closed.
+func readWSCommands(ctx context.Context, t wsTransport, cl *ssehub.Client) {
+	for {
+		_, b, err := t.conn.Read(ctx)
+		if err != nil {
+			return
+		}
+
+		if err := runWSCommand(cl, b); err != nil {
+			data, _ := json.Marshal(err.Error())
+			if err := t.write(wsMessage{Type: wsTypeError, Data: data}); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func runWSCommand(cl *ssehub.Client, b []byte) error {
+	var cmd wsCommand
+	if err := json.Unmarshal(b, &cmd); err != nil {
+		return errors.New("invalid command, expected JSON")
+	}
+
+	switch cmd.Type {
+	case wsCommandPause:
+		cl.Pause()
+	case wsCommandResume:
+		cl.Resume()
+	case wsCommandFilter:
+		query, err := url.ParseQuery(cmd.Filter)
+		if err != nil {
+			return fmt.Errorf("invalid filter: %w", err)
+		}
+		filter, err := eventFilterFromQuery(query)
+		if err != nil {
+			return err
+		}
+		cl.SetFilter(services.StreamFilter(filter))
+	default:
+		return fmt.Errorf("unknown command %q", cmd.Type)
+	}
+	return nil
+}
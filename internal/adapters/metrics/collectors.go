@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"github.com/erwin-lovecraft/pistol/internal/core/services"
+	"github.com/erwin-lovecraft/pistol/pkg/ssehub"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	hubRoomsDesc       = newDesc("sse_rooms", "Rooms with at least one SSE client.")
+	hubConnectionsDesc = newDesc("sse_connections", "SSE clients connected.")
+	hubConnectsDesc    = newDesc("sse_connects_total", "SSE clients that subscribed.")
+	hubDisconnectsDesc = newDesc("sse_disconnects_total", "SSE clients that left or were disconnected.")
+	hubHeartbeatsDesc  = newDesc("sse_heartbeats_total", "Heartbeats sent to SSE clients.")
+	hubDroppedDesc     = newDesc("sse_dropped_messages_total", "Messages dropped because SSE clients were too slow.")
+
+	pruneRunsDesc         = newDesc("prune_runs_total", "Retention prunes run.")
+	prunedEventsDesc      = newDesc("pruned_events_total", "Events deleted by retention.")
+	prunedBytesDesc       = newDesc("pruned_bytes_total", "Event body bytes deleted by retention.")
+	pruneFailuresDesc     = newDesc("prune_failures_total", "Retention prunes that failed, for a room or altogether.")
+	pruneLastRunDesc      = newDesc("prune_last_run_timestamp_seconds", "When the last retention prune started.")
+	pruneLastDurationDesc = newDesc("prune_last_run_duration_seconds", "How long the last retention prune took.")
+
+	poolAcquiredDesc        = newDesc("db_pool_acquired_conns", "Postgres connections in use.")
+	poolIdleDesc            = newDesc("db_pool_idle_conns", "Postgres connections idle.")
+	poolTotalDesc           = newDesc("db_pool_total_conns", "Postgres connections open, constructing ones included.")
+	poolMaxDesc             = newDesc("db_pool_max_conns", "Postgres connections the pool may open.")
+	poolAcquiresDesc        = newDesc("db_pool_acquires_total", "Postgres connections acquired from the pool.")
+	poolAcquireSecondsDesc  = newDesc("db_pool_acquire_seconds_total", "Time spent acquiring Postgres connections.")
+	poolEmptyAcquiresDesc   = newDesc("db_pool_empty_acquires_total", "Acquires that had to wait for a connection.")
+	poolCanceledAcquireDesc = newDesc("db_pool_canceled_acquires_total", "Acquires canceled while waiting for a connection.")
+)
+
+func newDesc(name string, help string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "", name), help, nil, nil)
+}
+
+// hubCollector reads the hub's stats on every scrape.
+type hubCollector struct {
+	hub *ssehub.Hub
+}
+
+func (c hubCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- hubRoomsDesc
+	ch <- hubConnectionsDesc
+	ch <- hubConnectsDesc
+	ch <- hubDisconnectsDesc
+	ch <- hubHeartbeatsDesc
+	ch <- hubDroppedDesc
+}
+
+func (c hubCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.hub.Stats()
+	ch <- prometheus.MustNewConstMetric(hubRoomsDesc, prometheus.GaugeValue, float64(stats.Rooms))
+	ch <- prometheus.MustNewConstMetric(hubConnectionsDesc, prometheus.GaugeValue, float64(stats.Connections))
+	ch <- prometheus.MustNewConstMetric(hubConnectsDesc, prometheus.CounterValue, float64(stats.Connects))
+	ch <- prometheus.MustNewConstMetric(hubDisconnectsDesc, prometheus.CounterValue, float64(stats.Disconnects))
+	ch <- prometheus.MustNewConstMetric(hubHeartbeatsDesc, prometheus.CounterValue, float64(stats.Heartbeats))
+	ch <- prometheus.MustNewConstMetric(hubDroppedDesc, prometheus.CounterValue, float64(stats.Dropped))
+}
+
+// prunerCollector reads the pruner's stats on every scrape.
+type prunerCollector struct {
+	pruner *services.Pruner
+}
+
+func (c prunerCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- pruneRunsDesc
+	ch <- prunedEventsDesc
+	ch <- prunedBytesDesc
+	ch <- pruneFailuresDesc
+	ch <- pruneLastRunDesc
+	ch <- pruneLastDurationDesc
+}
+
+func (c prunerCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.pruner.Stats()
+	ch <- prometheus.MustNewConstMetric(pruneRunsDesc, prometheus.CounterValue, float64(stats.Runs))
+	ch <- prometheus.MustNewConstMetric(prunedEventsDesc, prometheus.CounterValue, float64(stats.PrunedEvents))
+	ch <- prometheus.MustNewConstMetric(prunedBytesDesc, prometheus.CounterValue, float64(stats.PrunedBytes))
+	ch <- prometheus.MustNewConstMetric(pruneFailuresDesc, prometheus.CounterValue, float64(stats.Failures))
+	if !stats.LastRunAt.IsZero() {
+		ch <- prometheus.MustNewConstMetric(pruneLastRunDesc, prometheus.GaugeValue, float64(stats.LastRunAt.UnixNano())/1e9)
+		ch <- prometheus.MustNewConstMetric(pruneLastDurationDesc, prometheus.GaugeValue, float64(stats.LastRunMillis)/1e3)
+	}
+}
+
+// poolCollector reads the Postgres pool's stats on every scrape.
+type poolCollector struct {
+	pool *pgxpool.Pool
+}
+
+func (c poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- poolAcquiredDesc
+	ch <- poolIdleDesc
+	ch <- poolTotalDesc
+	ch <- poolMaxDesc
+	ch <- poolAcquiresDesc
+	ch <- poolAcquireSecondsDesc
+	ch <- poolEmptyAcquiresDesc
+	ch <- poolCanceledAcquireDesc
+}
+
+func (c poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+	ch <- prometheus.MustNewConstMetric(poolAcquiredDesc, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(poolIdleDesc, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(poolTotalDesc, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(poolMaxDesc, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(poolAcquiresDesc, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolAcquireSecondsDesc, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(poolEmptyAcquiresDesc, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolCanceledAcquireDesc, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+}
@@ -0,0 +1,29 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/erwin-lovecraft/pistol/internal/core/domain"
+	"github.com/erwin-lovecraft/pistol/internal/core/ports"
+)
+
+// EventRepository records the saves made through repo.
+func (m *Metrics) EventRepository(repo ports.EventRepository) ports.EventRepository {
+	return eventRepository{EventRepository: repo, metrics: m}
+}
+
+type eventRepository struct {
+	ports.EventRepository
+	metrics *Metrics
+}
+
+func (r eventRepository) Save(ctx context.Context, roomID string, ev *domain.Event) error {
+	start := time.Now()
+	err := r.EventRepository.Save(ctx, roomID, ev)
+	r.metrics.saveDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		r.metrics.saveErrors.Inc()
+	}
+	return err
+}
@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/erwin-lovecraft/pistol/internal/core/services"
+	"github.com/erwin-lovecraft/pistol/pkg/ssehub"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	namespace = "pistol"
+)
+
+var (
+	// payloadBuckets go from 64B to 4MiB
+	payloadBuckets = prometheus.ExponentialBuckets(64, 4, 9)
+)
+
+// Metrics collects what serverd reports to Prometheus, in its own registry.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	eventsIngested *prometheus.CounterVec
+	payloadBytes   *prometheus.HistogramVec
+	pushDuration   prometheus.Histogram
+	pushErrors     prometheus.Counter
+	saveDuration   prometheus.Histogram
+	saveErrors     prometheus.Counter
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		eventsIngested: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "events_ingested_total",
+			Help:      "Events pushed and stored, by room and method.",
+		}, []string{"room", "method"}),
+		payloadBytes: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "event_payload_bytes",
+			Help:      "Body size of the pushed events, by method.",
+			Buckets:   payloadBuckets,
+		}, []string{"method"}),
+		pushDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "push_duration_seconds",
+			Help:      "Time taken to store a pushed event and hand it to its listeners and forwards.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+		pushErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "push_errors_total",
+			Help:      "Pushes to existing rooms that failed.",
+		}),
+		saveDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "event_save_duration_seconds",
+			Help:      "Time taken by the storage to save an event.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+		saveErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "event_save_errors_total",
+			Help:      "Events the storage failed to save.",
+		}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.eventsIngested,
+		m.payloadBytes,
+		m.pushDuration,
+		m.pushErrors,
+		m.saveDuration,
+		m.saveErrors,
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// WatchHub reports the SSE connections and deliveries of the hub.
+func (m *Metrics) WatchHub(hub *ssehub.Hub) {
+	m.registry.MustRegister(hubCollector{hub: hub})
+}
+
+// WatchPruner reports what the pruner deleted.
+func (m *Metrics) WatchPruner(pruner *services.Pruner) {
+	m.registry.MustRegister(prunerCollector{pruner: pruner})
+}
+
+// WatchPool reports the connections of the Postgres pool.
+func (m *Metrics) WatchPool(pool *pgxpool.Pool) {
+	m.registry.MustRegister(poolCollector{pool: pool})
+}
+
+// WatchDB reports the connections of a database/sql database, labeled with its name.
+func (m *Metrics) WatchDB(db *sql.DB, name string) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/erwin-lovecraft/pistol/internal/core/domain"
+	"github.com/erwin-lovecraft/pistol/internal/core/services"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// methodOther labels pushes made with a non-standard method, anyone able to push must not be able to add series.
+const methodOther = "OTHER"
+
+// Service records the pushes handled by svc.
+func (m *Metrics) Service(svc services.Service) services.Service {
+	return service{Service: svc, metrics: m}
+}
+
+type service struct {
+	services.Service
+	metrics *Metrics
+}
+
+func (s service) PushEvent(ctx context.Context, roomID string, event domain.Event) (domain.EventResponse, error) {
+	start := time.Now()
+	resp, err := s.Service.PushEvent(ctx, roomID, event)
+	s.metrics.pushDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		if !errors.Is(err, domain.ErrRoomNotFound) {
+			s.metrics.pushErrors.Inc()
+		}
+		return resp, err
+	}
+
+	method := methodLabel(event.Method)
+	s.metrics.eventsIngested.WithLabelValues(roomID, method).Inc()
+	s.metrics.payloadBytes.WithLabelValues(method).Observe(float64(event.ContentLength))
+	return resp, nil
+}
+
+func (s service) DeleteRoom(ctx context.Context, roomID string) error {
+	if err := s.Service.DeleteRoom(ctx, roomID); err != nil {
+		return err
+	}
+
+	// The room's series would be exported until restart otherwise
+	s.metrics.eventsIngested.DeletePartialMatch(prometheus.Labels{"room": roomID})
+	return nil
+}
+
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	default:
+		return methodOther
+	}
+}
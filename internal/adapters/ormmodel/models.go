@@ -9,11 +9,65 @@ import (
 )
 
 type Event struct {
-	ID          int64
-	Method      string
-	Header      []byte
-	QueryParams []byte
-	Body        []byte
-	CreatedAt   pgtype.Timestamptz
-	RoomID      pgtype.UUID
+	ID               int64
+	Method           string
+	Header           []byte
+	QueryParams      []byte
+	Body             []byte
+	CreatedAt        pgtype.Timestamptz
+	RoomID           pgtype.UUID
+	BodyBase64       bool
+	ContentType      string
+	ContentLength    int64
+	Response         []byte
+	SignatureVerdict string
+	SignatureReason  string
+	Path             string
+	RemoteAddr       string
+	Proto            string
+	Host             string
+	Tls              []byte
+	DurationUs       int64
+	Redacted         []byte
+}
+
+type ReplayAttempt struct {
+	ID                 int64
+	EventID            int64
+	TargetUrl          string
+	RequestHeader      []byte
+	StatusCode         int32
+	ResponseHeader     []byte
+	ResponseBody       string
+	ResponseBodyBase64 bool
+	LatencyMs          int64
+	Error              string
+	CreatedAt          pgtype.Timestamptz
+	Source             string
+	Attempt            int32
+}
+
+type Room struct {
+	ID        pgtype.UUID
+	Name      string
+	Avatar    string
+	CreatedAt pgtype.Timestamptz
+	UpdatedAt pgtype.Timestamptz
+	Response  []byte
+	Forwards  []byte
+	Signature []byte
+	Retention []byte
+	Redaction []byte
+}
+
+type RoomToken struct {
+	ID        int64
+	RoomID    pgtype.UUID
+	Name      string
+	Scope     string
+	Prefix    string
+	TokenHash []byte
+	ExpiresAt pgtype.Timestamptz
+	RevokedAt pgtype.Timestamptz
+	CreatedAt pgtype.Timestamptz
 }
@@ -11,18 +11,377 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const deleteRoom = `-- name: DeleteRoom :execrows
+DELETE FROM rooms WHERE id = $1
+`
+
+func (q *Queries) DeleteRoom(ctx context.Context, id pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteRoom, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getEvent = `-- name: GetEvent :one
+SELECT id, method, header, query_params, body, created_at, room_id, body_base64, content_type, content_length, response, signature_verdict, signature_reason, path, remote_addr, proto, host, tls, duration_us, redacted FROM events WHERE room_id = $1 AND id = $2
+`
+
+type GetEventParams struct {
+	RoomID pgtype.UUID
+	ID     int64
+}
+
+func (q *Queries) GetEvent(ctx context.Context, arg GetEventParams) (Event, error) {
+	row := q.db.QueryRow(ctx, getEvent, arg.RoomID, arg.ID)
+	var i Event
+	err := row.Scan(
+		&i.ID,
+		&i.Method,
+		&i.Header,
+		&i.QueryParams,
+		&i.Body,
+		&i.CreatedAt,
+		&i.RoomID,
+		&i.BodyBase64,
+		&i.ContentType,
+		&i.ContentLength,
+		&i.Response,
+		&i.SignatureVerdict,
+		&i.SignatureReason,
+		&i.Path,
+		&i.RemoteAddr,
+		&i.Proto,
+		&i.Host,
+		&i.Tls,
+		&i.DurationUs,
+		&i.Redacted,
+	)
+	return i, err
+}
+
+const getEventBytesCutoff = `-- name: GetEventBytesCutoff :one
+SELECT id FROM (
+    SELECT id, SUM(content_length) OVER (ORDER BY id DESC) AS total_bytes
+    FROM events WHERE room_id = $1
+) AS sized
+WHERE total_bytes > $2::BIGINT
+ORDER BY id DESC
+LIMIT 1
+`
+
+type GetEventBytesCutoffParams struct {
+	RoomID   pgtype.UUID
+	MaxBytes int64
+}
+
+func (q *Queries) GetEventBytesCutoff(ctx context.Context, arg GetEventBytesCutoffParams) (int64, error) {
+	row := q.db.QueryRow(ctx, getEventBytesCutoff, arg.RoomID, arg.MaxBytes)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const getEventCountCutoff = `-- name: GetEventCountCutoff :one
+SELECT id FROM events WHERE room_id = $1 ORDER BY id DESC OFFSET $2::BIGINT LIMIT 1
+`
+
+type GetEventCountCutoffParams struct {
+	RoomID    pgtype.UUID
+	MaxEvents int64
+}
+
+func (q *Queries) GetEventCountCutoff(ctx context.Context, arg GetEventCountCutoffParams) (int64, error) {
+	row := q.db.QueryRow(ctx, getEventCountCutoff, arg.RoomID, arg.MaxEvents)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const getRoom = `-- name: GetRoom :one
+SELECT id, name, avatar, created_at, updated_at, response, forwards, signature, retention, redaction FROM rooms WHERE id = $1
+`
+
+func (q *Queries) GetRoom(ctx context.Context, id pgtype.UUID) (Room, error) {
+	row := q.db.QueryRow(ctx, getRoom, id)
+	var i Room
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Avatar,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Response,
+		&i.Forwards,
+		&i.Signature,
+		&i.Retention,
+		&i.Redaction,
+	)
+	return i, err
+}
+
+const getRoomToken = `-- name: GetRoomToken :one
+SELECT id, room_id, name, scope, prefix, token_hash, expires_at, revoked_at, created_at FROM room_tokens WHERE room_id = $1 AND id = $2
+`
+
+type GetRoomTokenParams struct {
+	RoomID pgtype.UUID
+	ID     int64
+}
+
+func (q *Queries) GetRoomToken(ctx context.Context, arg GetRoomTokenParams) (RoomToken, error) {
+	row := q.db.QueryRow(ctx, getRoomToken, arg.RoomID, arg.ID)
+	var i RoomToken
+	err := row.Scan(
+		&i.ID,
+		&i.RoomID,
+		&i.Name,
+		&i.Scope,
+		&i.Prefix,
+		&i.TokenHash,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listActiveRoomTokens = `-- name: ListActiveRoomTokens :many
+SELECT id, room_id, name, scope, prefix, token_hash, expires_at, revoked_at, created_at FROM room_tokens
+WHERE room_id = $1 AND scope = $2 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())
+`
+
+type ListActiveRoomTokensParams struct {
+	RoomID pgtype.UUID
+	Scope  string
+}
+
+func (q *Queries) ListActiveRoomTokens(ctx context.Context, arg ListActiveRoomTokensParams) ([]RoomToken, error) {
+	rows, err := q.db.Query(ctx, listActiveRoomTokens, arg.RoomID, arg.Scope)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RoomToken
+	for rows.Next() {
+		var i RoomToken
+		if err := rows.Scan(
+			&i.ID,
+			&i.RoomID,
+			&i.Name,
+			&i.Scope,
+			&i.Prefix,
+			&i.TokenHash,
+			&i.ExpiresAt,
+			&i.RevokedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listEvents = `-- name: ListEvents :many
-SELECT id, method, header, query_params, body, created_at, room_id FROM events WHERE room_id = $1 ORDER BY created_at DESC OFFSET $2 LIMIT $3
+SELECT id, method, header, query_params, body, created_at, room_id, body_base64, content_type, content_length, response, signature_verdict, signature_reason, path, remote_addr, proto, host, tls, duration_us, redacted FROM events
+WHERE room_id = $1
+    AND ($2::BIGINT IS NULL OR id < $2)
+    AND ($3::TEXT IS NULL OR signature_verdict = $3)
+    AND ($4::TEXT IS NULL OR method = $4)
+    AND ($5::TIMESTAMPTZ IS NULL OR created_at >= $5)
+    AND ($6::TIMESTAMPTZ IS NULL OR created_at < $6)
+    AND ($7::TEXT[] IS NULL OR header ?& $7)
+    AND ($8::JSONB IS NULL OR header @> $8)
+    AND ($9::TEXT[] IS NULL OR query_params ?& $9)
+    AND ($10::JSONB IS NULL OR query_params @> $10)
+    AND ($11::BYTEA IS NULL OR position($11 IN body) > 0)
+    AND ($12::TEXT IS NULL OR event_body_json(body) @@ $12::TEXT::JSONPATH)
+    AND ($13::TEXT IS NULL OR right(path, length($13)) = $13)
+ORDER BY id DESC
+LIMIT $14
 `
 
 type ListEventsParams struct {
+	RoomID           pgtype.UUID
+	Before           pgtype.Int8
+	SignatureVerdict pgtype.Text
+	Method           pgtype.Text
+	CreatedFrom      pgtype.Timestamptz
+	CreatedTo        pgtype.Timestamptz
+	HeaderNames      []string
+	HeaderValues     []byte
+	QueryNames       []string
+	QueryValues      []byte
+	BodyContains     []byte
+	BodyPath         pgtype.Text
+	PathSuffix       pgtype.Text
+	Limit            int32
+}
+
+func (q *Queries) ListEvents(ctx context.Context, arg ListEventsParams) ([]Event, error) {
+	rows, err := q.db.Query(ctx, listEvents,
+		arg.RoomID,
+		arg.Before,
+		arg.SignatureVerdict,
+		arg.Method,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.HeaderNames,
+		arg.HeaderValues,
+		arg.QueryNames,
+		arg.QueryValues,
+		arg.BodyContains,
+		arg.BodyPath,
+		arg.PathSuffix,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Event
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.ID,
+			&i.Method,
+			&i.Header,
+			&i.QueryParams,
+			&i.Body,
+			&i.CreatedAt,
+			&i.RoomID,
+			&i.BodyBase64,
+			&i.ContentType,
+			&i.ContentLength,
+			&i.Response,
+			&i.SignatureVerdict,
+			&i.SignatureReason,
+			&i.Path,
+			&i.RemoteAddr,
+			&i.Proto,
+			&i.Host,
+			&i.Tls,
+			&i.DurationUs,
+			&i.Redacted,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEventsAfter = `-- name: ListEventsAfter :many
+SELECT id, method, header, query_params, body, created_at, room_id, body_base64, content_type, content_length, response, signature_verdict, signature_reason, path, remote_addr, proto, host, tls, duration_us, redacted FROM events WHERE room_id = $1 AND id > $2 ORDER BY id ASC LIMIT $3
+`
+
+type ListEventsAfterParams struct {
 	RoomID pgtype.UUID
-	Offset int32
+	ID     int64
 	Limit  int32
 }
 
-func (q *Queries) ListEvents(ctx context.Context, arg ListEventsParams) ([]Event, error) {
-	rows, err := q.db.Query(ctx, listEvents, arg.RoomID, arg.Offset, arg.Limit)
+func (q *Queries) ListEventsAfter(ctx context.Context, arg ListEventsAfterParams) ([]Event, error) {
+	rows, err := q.db.Query(ctx, listEventsAfter, arg.RoomID, arg.ID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Event
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.ID,
+			&i.Method,
+			&i.Header,
+			&i.QueryParams,
+			&i.Body,
+			&i.CreatedAt,
+			&i.RoomID,
+			&i.BodyBase64,
+			&i.ContentType,
+			&i.ContentLength,
+			&i.Response,
+			&i.SignatureVerdict,
+			&i.SignatureReason,
+			&i.Path,
+			&i.RemoteAddr,
+			&i.Proto,
+			&i.Host,
+			&i.Tls,
+			&i.DurationUs,
+			&i.Redacted,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEventsNewer = `-- name: ListEventsNewer :many
+SELECT id, method, header, query_params, body, created_at, room_id, body_base64, content_type, content_length, response, signature_verdict, signature_reason, path, remote_addr, proto, host, tls, duration_us, redacted FROM events
+WHERE room_id = $1
+    AND id > $2
+    AND ($3::TEXT IS NULL OR signature_verdict = $3)
+    AND ($4::TEXT IS NULL OR method = $4)
+    AND ($5::TIMESTAMPTZ IS NULL OR created_at >= $5)
+    AND ($6::TIMESTAMPTZ IS NULL OR created_at < $6)
+    AND ($7::TEXT[] IS NULL OR header ?& $7)
+    AND ($8::JSONB IS NULL OR header @> $8)
+    AND ($9::TEXT[] IS NULL OR query_params ?& $9)
+    AND ($10::JSONB IS NULL OR query_params @> $10)
+    AND ($11::BYTEA IS NULL OR position($11 IN body) > 0)
+    AND ($12::TEXT IS NULL OR event_body_json(body) @@ $12::TEXT::JSONPATH)
+    AND ($13::TEXT IS NULL OR right(path, length($13)) = $13)
+ORDER BY id ASC
+LIMIT $14
+`
+
+type ListEventsNewerParams struct {
+	RoomID           pgtype.UUID
+	After            int64
+	SignatureVerdict pgtype.Text
+	Method           pgtype.Text
+	CreatedFrom      pgtype.Timestamptz
+	CreatedTo        pgtype.Timestamptz
+	HeaderNames      []string
+	HeaderValues     []byte
+	QueryNames       []string
+	QueryValues      []byte
+	BodyContains     []byte
+	BodyPath         pgtype.Text
+	PathSuffix       pgtype.Text
+	Limit            int32
+}
+
+func (q *Queries) ListEventsNewer(ctx context.Context, arg ListEventsNewerParams) ([]Event, error) {
+	rows, err := q.db.Query(ctx, listEventsNewer,
+		arg.RoomID,
+		arg.After,
+		arg.SignatureVerdict,
+		arg.Method,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.HeaderNames,
+		arg.HeaderValues,
+		arg.QueryNames,
+		arg.QueryValues,
+		arg.BodyContains,
+		arg.BodyPath,
+		arg.PathSuffix,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -38,6 +397,96 @@ func (q *Queries) ListEvents(ctx context.Context, arg ListEventsParams) ([]Event
 			&i.Body,
 			&i.CreatedAt,
 			&i.RoomID,
+			&i.BodyBase64,
+			&i.ContentType,
+			&i.ContentLength,
+			&i.Response,
+			&i.SignatureVerdict,
+			&i.SignatureReason,
+			&i.Path,
+			&i.RemoteAddr,
+			&i.Proto,
+			&i.Host,
+			&i.Tls,
+			&i.DurationUs,
+			&i.Redacted,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReplayAttempts = `-- name: ListReplayAttempts :many
+SELECT id, event_id, target_url, request_header, status_code, response_header, response_body, response_body_base64, latency_ms, error, created_at, source, attempt FROM replay_attempts WHERE event_id = $1 ORDER BY id DESC LIMIT $2
+`
+
+type ListReplayAttemptsParams struct {
+	EventID int64
+	Limit   int32
+}
+
+func (q *Queries) ListReplayAttempts(ctx context.Context, arg ListReplayAttemptsParams) ([]ReplayAttempt, error) {
+	rows, err := q.db.Query(ctx, listReplayAttempts, arg.EventID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ReplayAttempt
+	for rows.Next() {
+		var i ReplayAttempt
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventID,
+			&i.TargetUrl,
+			&i.RequestHeader,
+			&i.StatusCode,
+			&i.ResponseHeader,
+			&i.ResponseBody,
+			&i.ResponseBodyBase64,
+			&i.LatencyMs,
+			&i.Error,
+			&i.CreatedAt,
+			&i.Source,
+			&i.Attempt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRoomTokens = `-- name: ListRoomTokens :many
+SELECT id, room_id, name, scope, prefix, token_hash, expires_at, revoked_at, created_at FROM room_tokens WHERE room_id = $1 ORDER BY id DESC
+`
+
+func (q *Queries) ListRoomTokens(ctx context.Context, roomID pgtype.UUID) ([]RoomToken, error) {
+	rows, err := q.db.Query(ctx, listRoomTokens, roomID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RoomToken
+	for rows.Next() {
+		var i RoomToken
+		if err := rows.Scan(
+			&i.ID,
+			&i.RoomID,
+			&i.Name,
+			&i.Scope,
+			&i.Prefix,
+			&i.TokenHash,
+			&i.ExpiresAt,
+			&i.RevokedAt,
+			&i.CreatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -49,24 +498,159 @@ func (q *Queries) ListEvents(ctx context.Context, arg ListEventsParams) ([]Event
 	return items, nil
 }
 
+const listRooms = `-- name: ListRooms :many
+SELECT id, name, avatar, created_at, updated_at, response, forwards, signature, retention, redaction FROM rooms ORDER BY created_at DESC
+`
+
+func (q *Queries) ListRooms(ctx context.Context) ([]Room, error) {
+	rows, err := q.db.Query(ctx, listRooms)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Room
+	for rows.Next() {
+		var i Room
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Avatar,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Response,
+			&i.Forwards,
+			&i.Signature,
+			&i.Retention,
+			&i.Redaction,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const pruneEvents = `-- name: PruneEvents :many
+DELETE FROM events
+WHERE id IN (
+    SELECT pruned.id FROM events AS pruned
+    WHERE pruned.room_id = $1
+        AND (pruned.id <= $2 OR pruned.created_at < $3)
+    ORDER BY pruned.id
+    LIMIT $4
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING content_length
+`
+
+type PruneEventsParams struct {
+	RoomID        pgtype.UUID
+	MaxID         pgtype.Int8
+	CreatedBefore pgtype.Timestamptz
+	Limit         int32
+}
+
+func (q *Queries) PruneEvents(ctx context.Context, arg PruneEventsParams) ([]int64, error) {
+	rows, err := q.db.Query(ctx, pruneEvents,
+		arg.RoomID,
+		arg.MaxID,
+		arg.CreatedBefore,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var content_length int64
+		if err := rows.Scan(&content_length); err != nil {
+			return nil, err
+		}
+		items = append(items, content_length)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const revokeRoomToken = `-- name: RevokeRoomToken :one
+UPDATE room_tokens SET revoked_at = NOW()
+WHERE room_id = $1 AND id = $2 AND revoked_at IS NULL
+RETURNING id, room_id, name, scope, prefix, token_hash, expires_at, revoked_at, created_at
+`
+
+type RevokeRoomTokenParams struct {
+	RoomID pgtype.UUID
+	ID     int64
+}
+
+func (q *Queries) RevokeRoomToken(ctx context.Context, arg RevokeRoomTokenParams) (RoomToken, error) {
+	row := q.db.QueryRow(ctx, revokeRoomToken, arg.RoomID, arg.ID)
+	var i RoomToken
+	err := row.Scan(
+		&i.ID,
+		&i.RoomID,
+		&i.Name,
+		&i.Scope,
+		&i.Prefix,
+		&i.TokenHash,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
 const saveEvent = `-- name: SaveEvent :one
-INSERT INTO events (id, method, header, query_params, body, room_id)
-VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (id) DO
+INSERT INTO events (id, method, header, query_params, body, body_base64, content_type, content_length, response, signature_verdict, signature_reason, room_id, created_at, path, remote_addr, proto, host, tls, duration_us, redacted)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, COALESCE($13::TIMESTAMPTZ, NOW()), $14, $15, $16, $17, $18, $19, $20) ON CONFLICT (id) DO
 UPDATE SET method = EXCLUDED.method,
     header = EXCLUDED.header,
     query_params = EXCLUDED.query_params,
     body = EXCLUDED.body,
-    room_id = EXCLUDED.room_id
+    body_base64 = EXCLUDED.body_base64,
+    content_type = EXCLUDED.content_type,
+    content_length = EXCLUDED.content_length,
+    response = EXCLUDED.response,
+    signature_verdict = EXCLUDED.signature_verdict,
+    signature_reason = EXCLUDED.signature_reason,
+    room_id = EXCLUDED.room_id,
+    path = EXCLUDED.path,
+    remote_addr = EXCLUDED.remote_addr,
+    proto = EXCLUDED.proto,
+    host = EXCLUDED.host,
+    tls = EXCLUDED.tls,
+    duration_us = EXCLUDED.duration_us,
+    redacted = EXCLUDED.redacted
 RETURNING created_at
 `
 
 type SaveEventParams struct {
-	ID          int64
-	Method      string
-	Header      []byte
-	QueryParams []byte
-	Body        []byte
-	RoomID      pgtype.UUID
+	ID               int64
+	Method           string
+	Header           []byte
+	QueryParams      []byte
+	Body             []byte
+	BodyBase64       bool
+	ContentType      string
+	ContentLength    int64
+	Response         []byte
+	SignatureVerdict string
+	SignatureReason  string
+	RoomID           pgtype.UUID
+	CreatedAt        pgtype.Timestamptz
+	Path             string
+	RemoteAddr       string
+	Proto            string
+	Host             string
+	Tls              []byte
+	DurationUs       int64
+	Redacted         []byte
 }
 
 func (q *Queries) SaveEvent(ctx context.Context, arg SaveEventParams) (pgtype.Timestamptz, error) {
@@ -76,9 +660,300 @@ func (q *Queries) SaveEvent(ctx context.Context, arg SaveEventParams) (pgtype.Ti
 		arg.Header,
 		arg.QueryParams,
 		arg.Body,
+		arg.BodyBase64,
+		arg.ContentType,
+		arg.ContentLength,
+		arg.Response,
+		arg.SignatureVerdict,
+		arg.SignatureReason,
 		arg.RoomID,
+		arg.CreatedAt,
+		arg.Path,
+		arg.RemoteAddr,
+		arg.Proto,
+		arg.Host,
+		arg.Tls,
+		arg.DurationUs,
+		arg.Redacted,
 	)
 	var created_at pgtype.Timestamptz
 	err := row.Scan(&created_at)
 	return created_at, err
 }
+
+const saveReplayAttempt = `-- name: SaveReplayAttempt :one
+INSERT INTO replay_attempts (id, event_id, target_url, request_header, status_code, response_header, response_body, response_body_base64, latency_ms, error, source, attempt)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+RETURNING created_at
+`
+
+type SaveReplayAttemptParams struct {
+	ID                 int64
+	EventID            int64
+	TargetUrl          string
+	RequestHeader      []byte
+	StatusCode         int32
+	ResponseHeader     []byte
+	ResponseBody       string
+	ResponseBodyBase64 bool
+	LatencyMs          int64
+	Error              string
+	Source             string
+	Attempt            int32
+}
+
+func (q *Queries) SaveReplayAttempt(ctx context.Context, arg SaveReplayAttemptParams) (pgtype.Timestamptz, error) {
+	row := q.db.QueryRow(ctx, saveReplayAttempt,
+		arg.ID,
+		arg.EventID,
+		arg.TargetUrl,
+		arg.RequestHeader,
+		arg.StatusCode,
+		arg.ResponseHeader,
+		arg.ResponseBody,
+		arg.ResponseBodyBase64,
+		arg.LatencyMs,
+		arg.Error,
+		arg.Source,
+		arg.Attempt,
+	)
+	var created_at pgtype.Timestamptz
+	err := row.Scan(&created_at)
+	return created_at, err
+}
+
+const saveRoom = `-- name: SaveRoom :one
+INSERT INTO rooms (id, name, avatar)
+VALUES ($1, $2, $3) ON CONFLICT (id) DO
+UPDATE SET name = EXCLUDED.name,
+    avatar = EXCLUDED.avatar,
+    updated_at = NOW()
+RETURNING id, name, avatar, created_at, updated_at, response, forwards, signature, retention, redaction
+`
+
+type SaveRoomParams struct {
+	ID     pgtype.UUID
+	Name   string
+	Avatar string
+}
+
+func (q *Queries) SaveRoom(ctx context.Context, arg SaveRoomParams) (Room, error) {
+	row := q.db.QueryRow(ctx, saveRoom, arg.ID, arg.Name, arg.Avatar)
+	var i Room
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Avatar,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Response,
+		&i.Forwards,
+		&i.Signature,
+		&i.Retention,
+		&i.Redaction,
+	)
+	return i, err
+}
+
+const saveRoomToken = `-- name: SaveRoomToken :one
+INSERT INTO room_tokens (id, room_id, name, scope, prefix, token_hash, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, room_id, name, scope, prefix, token_hash, expires_at, revoked_at, created_at
+`
+
+type SaveRoomTokenParams struct {
+	ID        int64
+	RoomID    pgtype.UUID
+	Name      string
+	Scope     string
+	Prefix    string
+	TokenHash []byte
+	ExpiresAt pgtype.Timestamptz
+}
+
+func (q *Queries) SaveRoomToken(ctx context.Context, arg SaveRoomTokenParams) (RoomToken, error) {
+	row := q.db.QueryRow(ctx, saveRoomToken,
+		arg.ID,
+		arg.RoomID,
+		arg.Name,
+		arg.Scope,
+		arg.Prefix,
+		arg.TokenHash,
+		arg.ExpiresAt,
+	)
+	var i RoomToken
+	err := row.Scan(
+		&i.ID,
+		&i.RoomID,
+		&i.Name,
+		&i.Scope,
+		&i.Prefix,
+		&i.TokenHash,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const updateRoomForwards = `-- name: UpdateRoomForwards :one
+UPDATE rooms SET forwards = $2, updated_at = NOW() WHERE id = $1 RETURNING id, name, avatar, created_at, updated_at, response, forwards, signature, retention, redaction
+`
+
+type UpdateRoomForwardsParams struct {
+	ID       pgtype.UUID
+	Forwards []byte
+}
+
+func (q *Queries) UpdateRoomForwards(ctx context.Context, arg UpdateRoomForwardsParams) (Room, error) {
+	row := q.db.QueryRow(ctx, updateRoomForwards, arg.ID, arg.Forwards)
+	var i Room
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Avatar,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Response,
+		&i.Forwards,
+		&i.Signature,
+		&i.Retention,
+		&i.Redaction,
+	)
+	return i, err
+}
+
+const updateRoomName = `-- name: UpdateRoomName :one
+UPDATE rooms SET name = $2, updated_at = NOW() WHERE id = $1 RETURNING id, name, avatar, created_at, updated_at, response, forwards, signature, retention, redaction
+`
+
+type UpdateRoomNameParams struct {
+	ID   pgtype.UUID
+	Name string
+}
+
+func (q *Queries) UpdateRoomName(ctx context.Context, arg UpdateRoomNameParams) (Room, error) {
+	row := q.db.QueryRow(ctx, updateRoomName, arg.ID, arg.Name)
+	var i Room
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Avatar,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Response,
+		&i.Forwards,
+		&i.Signature,
+		&i.Retention,
+		&i.Redaction,
+	)
+	return i, err
+}
+
+const updateRoomRedaction = `-- name: UpdateRoomRedaction :one
+UPDATE rooms SET redaction = $2, updated_at = NOW() WHERE id = $1 RETURNING id, name, avatar, created_at, updated_at, response, forwards, signature, retention, redaction
+`
+
+type UpdateRoomRedactionParams struct {
+	ID        pgtype.UUID
+	Redaction []byte
+}
+
+func (q *Queries) UpdateRoomRedaction(ctx context.Context, arg UpdateRoomRedactionParams) (Room, error) {
+	row := q.db.QueryRow(ctx, updateRoomRedaction, arg.ID, arg.Redaction)
+	var i Room
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Avatar,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Response,
+		&i.Forwards,
+		&i.Signature,
+		&i.Retention,
+		&i.Redaction,
+	)
+	return i, err
+}
+
+const updateRoomResponse = `-- name: UpdateRoomResponse :one
+UPDATE rooms SET response = $2, updated_at = NOW() WHERE id = $1 RETURNING id, name, avatar, created_at, updated_at, response, forwards, signature, retention, redaction
+`
+
+type UpdateRoomResponseParams struct {
+	ID       pgtype.UUID
+	Response []byte
+}
+
+func (q *Queries) UpdateRoomResponse(ctx context.Context, arg UpdateRoomResponseParams) (Room, error) {
+	row := q.db.QueryRow(ctx, updateRoomResponse, arg.ID, arg.Response)
+	var i Room
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Avatar,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Response,
+		&i.Forwards,
+		&i.Signature,
+		&i.Retention,
+		&i.Redaction,
+	)
+	return i, err
+}
+
+const updateRoomRetention = `-- name: UpdateRoomRetention :one
+UPDATE rooms SET retention = $2, updated_at = NOW() WHERE id = $1 RETURNING id, name, avatar, created_at, updated_at, response, forwards, signature, retention, redaction
+`
+
+type UpdateRoomRetentionParams struct {
+	ID        pgtype.UUID
+	Retention []byte
+}
+
+func (q *Queries) UpdateRoomRetention(ctx context.Context, arg UpdateRoomRetentionParams) (Room, error) {
+	row := q.db.QueryRow(ctx, updateRoomRetention, arg.ID, arg.Retention)
+	var i Room
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Avatar,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Response,
+		&i.Forwards,
+		&i.Signature,
+		&i.Retention,
+		&i.Redaction,
+	)
+	return i, err
+}
+
+const updateRoomSignature = `-- name: UpdateRoomSignature :one
+UPDATE rooms SET signature = $2, updated_at = NOW() WHERE id = $1 RETURNING id, name, avatar, created_at, updated_at, response, forwards, signature, retention, redaction
+`
+
+type UpdateRoomSignatureParams struct {
+	ID        pgtype.UUID
+	Signature []byte
+}
+
+func (q *Queries) UpdateRoomSignature(ctx context.Context, arg UpdateRoomSignatureParams) (Room, error) {
+	row := q.db.QueryRow(ctx, updateRoomSignature, arg.ID, arg.Signature)
+	var i Room
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Avatar,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Response,
+		&i.Forwards,
+		&i.Signature,
+		&i.Retention,
+		&i.Redaction,
+	)
+	return i, err
+}
@@ -3,13 +3,16 @@ package repository
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"slices"
 
 	"github.com/erwin-lovecraft/pistol/internal/adapters/ormmodel"
 	"github.com/erwin-lovecraft/pistol/internal/core/domain"
 	"github.com/erwin-lovecraft/pistol/internal/core/ports"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -39,6 +42,9 @@ func (repo eventRepository) Save(ctx context.Context, roomID string, ev *domain.
 		err             error
 		headerBytes     []byte
 		queryParamBytes []byte
+		responseBytes   []byte
+		tlsBytes        []byte
+		redactedBytes   []byte
 	)
 	if ev.Header != nil {
 		if headerBytes, err = json.Marshal(ev.Header); err != nil {
@@ -50,20 +56,54 @@ func (repo eventRepository) Save(ctx context.Context, roomID string, ev *domain.
 			return fmt.Errorf("marshal query param: %w", err)
 		}
 	}
+	if ev.Response != nil {
+		if responseBytes, err = json.Marshal(ev.Response); err != nil {
+			return fmt.Errorf("marshal response: %w", err)
+		}
+	}
+	if ev.TLS != nil {
+		if tlsBytes, err = json.Marshal(ev.TLS); err != nil {
+			return fmt.Errorf("marshal tls: %w", err)
+		}
+	}
+	if len(ev.Redacted) > 0 {
+		if redactedBytes, err = json.Marshal(ev.Redacted); err != nil {
+			return fmt.Errorf("marshal redacted fields: %w", err)
+		}
+	}
 
 	var pgRoomID pgtype.UUID
 	if err := pgRoomID.Scan(roomID); err != nil {
 		return fmt.Errorf("scan room id: %w", err)
 	}
 
-	createdAt, err := repo.queries.SaveEvent(ctx, ormmodel.SaveEventParams{
-		ID:          ev.ID,
-		Method:      ev.Method,
-		Header:      headerBytes,
-		QueryParams: queryParamBytes,
-		Body:        ev.Body,
-		RoomID:      pgRoomID,
-	})
+	params := ormmodel.SaveEventParams{
+		ID:            ev.ID,
+		Method:        ev.Method,
+		Header:        headerBytes,
+		QueryParams:   queryParamBytes,
+		Body:          ev.Body,
+		BodyBase64:    ev.BodyBase64,
+		ContentType:   ev.ContentType,
+		ContentLength: ev.ContentLength,
+		Response:      responseBytes,
+		RoomID:        pgRoomID,
+		Path:          ev.Path,
+		RemoteAddr:    ev.RemoteAddr,
+		Proto:         ev.Proto,
+		Host:          ev.Host,
+		Tls:           tlsBytes,
+		DurationUs:    ev.DurationUS,
+		Redacted:      redactedBytes,
+		// Imported events keep the time they were captured at
+		CreatedAt: pgtype.Timestamptz{Time: ev.CreatedAt, Valid: !ev.CreatedAt.IsZero()},
+	}
+	if ev.Signature != nil {
+		params.SignatureVerdict = ev.Signature.Verdict
+		params.SignatureReason = ev.Signature.Reason
+	}
+
+	createdAt, err := repo.queries.SaveEvent(ctx, params)
 	if err != nil {
 		return fmt.Errorf("save event: %w", err)
 	}
@@ -71,48 +111,313 @@ func (repo eventRepository) Save(ctx context.Context, roomID string, ev *domain.
 	return nil
 }
 
-func (repo eventRepository) List(ctx context.Context, roomID string, page, size int) ([]domain.Event, bool, error) {
-	offset := (page - 1) * size
-	limit := size
+func (repo eventRepository) Get(ctx context.Context, roomID string, id int64) (domain.Event, error) {
+	var pgRoomID pgtype.UUID
+	if err := pgRoomID.Scan(roomID); err != nil {
+		return domain.Event{}, domain.ErrEventNotFound
+	}
+
+	model, err := repo.queries.GetEvent(ctx, ormmodel.GetEventParams{
+		RoomID: pgRoomID,
+		ID:     id,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Event{}, domain.ErrEventNotFound
+		}
+		return domain.Event{}, fmt.Errorf("get event: %w", err)
+	}
+	return toDomainEvent(model)
+}
 
+func (repo eventRepository) List(ctx context.Context, roomID string, filter ports.EventFilter, cursor ports.EventCursor) ([]domain.Event, bool, error) {
 	var pgRoomID pgtype.UUID
 	if err := pgRoomID.Scan(roomID); err != nil {
 		return nil, false, fmt.Errorf("scan room id: %w", err)
 	}
 
-	models, err := repo.queries.ListEvents(ctx, ormmodel.ListEventsParams{
+	args, err := toEventFilterArgs(filter)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Fetch one more event than asked to tell whether there's another page
+	limit := int32(cursor.Limit + 1)
+
+	var models []ormmodel.Event
+	if cursor.After > 0 {
+		models, err = repo.queries.ListEventsNewer(ctx, ormmodel.ListEventsNewerParams{
+			RoomID:           pgRoomID,
+			After:            cursor.After,
+			SignatureVerdict: args.SignatureVerdict,
+			Method:           args.Method,
+			CreatedFrom:      args.CreatedFrom,
+			CreatedTo:        args.CreatedTo,
+			HeaderNames:      args.HeaderNames,
+			HeaderValues:     args.HeaderValues,
+			QueryNames:       args.QueryNames,
+			QueryValues:      args.QueryValues,
+			BodyContains:     args.BodyContains,
+			BodyPath:         args.BodyPath,
+			PathSuffix:       args.PathSuffix,
+			Limit:            limit,
+		})
+	} else {
+		models, err = repo.queries.ListEvents(ctx, ormmodel.ListEventsParams{
+			RoomID:           pgRoomID,
+			Before:           pgtype.Int8{Int64: cursor.Before, Valid: cursor.Before > 0},
+			SignatureVerdict: args.SignatureVerdict,
+			Method:           args.Method,
+			CreatedFrom:      args.CreatedFrom,
+			CreatedTo:        args.CreatedTo,
+			HeaderNames:      args.HeaderNames,
+			HeaderValues:     args.HeaderValues,
+			QueryNames:       args.QueryNames,
+			QueryValues:      args.QueryValues,
+			BodyContains:     args.BodyContains,
+			BodyPath:         args.BodyPath,
+			PathSuffix:       args.PathSuffix,
+			Limit:            limit,
+		})
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("list events: %w", err)
+	}
+
+	hasMore := len(models) > cursor.Limit
+	if hasMore {
+		models = models[:cursor.Limit]
+	}
+	if cursor.After > 0 {
+		// Newer events are fetched oldest first, to stay next to the cursor
+		slices.Reverse(models)
+	}
+
+	events := make([]domain.Event, 0, len(models))
+	for _, model := range models {
+		ev, err := toDomainEvent(model)
+		if err != nil {
+			log.Printf("convert event: %v", err)
+			continue
+		}
+		events = append(events, ev)
+	}
+
+	return events, hasMore, nil
+}
+
+func (repo eventRepository) ListAfter(ctx context.Context, roomID string, afterID int64, limit int) ([]domain.Event, error) {
+	var pgRoomID pgtype.UUID
+	if err := pgRoomID.Scan(roomID); err != nil {
+		return nil, fmt.Errorf("scan room id: %w", err)
+	}
+
+	models, err := repo.queries.ListEventsAfter(ctx, ormmodel.ListEventsAfterParams{
 		RoomID: pgRoomID,
-		Offset: int32(offset),
+		ID:     afterID,
 		Limit:  int32(limit),
 	})
 	if err != nil {
-		return nil, false, fmt.Errorf("list events: %w", err)
+		return nil, fmt.Errorf("list events after: %w", err)
+	}
+
+	events := make([]domain.Event, 0, len(models))
+	for _, model := range models {
+		ev, err := toDomainEvent(model)
+		if err != nil {
+			return nil, fmt.Errorf("convert event: %w", err)
+		}
+		events = append(events, ev)
+	}
+
+	return events, nil
+}
+
+func (repo eventRepository) Prune(ctx context.Context, roomID string, policy domain.RetentionPolicy, batchSize int) (ports.PruneResult, error) {
+	var pgRoomID pgtype.UUID
+	if err := pgRoomID.Scan(roomID); err != nil {
+		return ports.PruneResult{}, fmt.Errorf("scan room id: %w", err)
+	}
+
+	// Count and size limits resolve to the newest event to delete, everything up to it goes
+	var maxID pgtype.Int8
+	if policy.MaxEvents > 0 {
+		id, err := repo.queries.GetEventCountCutoff(ctx, ormmodel.GetEventCountCutoffParams{
+			RoomID:    pgRoomID,
+			MaxEvents: policy.MaxEvents,
+		})
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return ports.PruneResult{}, fmt.Errorf("get event count cutoff: %w", err)
+		}
+		if err == nil {
+			maxID = pgtype.Int8{Int64: id, Valid: true}
+		}
+	}
+	if policy.MaxBytes > 0 {
+		id, err := repo.queries.GetEventBytesCutoff(ctx, ormmodel.GetEventBytesCutoffParams{
+			RoomID:   pgRoomID,
+			MaxBytes: policy.MaxBytes,
+		})
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return ports.PruneResult{}, fmt.Errorf("get event bytes cutoff: %w", err)
+		}
+		if err == nil && id > maxID.Int64 {
+			maxID = pgtype.Int8{Int64: id, Valid: true}
+		}
+	}
+
+	var createdBefore pgtype.Timestamptz
+	if policy.MaxAge() > 0 {
+		createdBefore = pgtype.Timestamptz{Time: timeNowFunc().Add(-policy.MaxAge()), Valid: true}
+	}
+	if !maxID.Valid && !createdBefore.Valid {
+		return ports.PruneResult{}, nil
 	}
 
-	events := make([]domain.Event, len(models))
-	for idx, model := range models {
-		var (
-			evHeader  http.Header
-			evQueries map[string][]string
-		)
+	var result ports.PruneResult
+	for {
+		sizes, err := repo.queries.PruneEvents(ctx, ormmodel.PruneEventsParams{
+			RoomID:        pgRoomID,
+			MaxID:         maxID,
+			CreatedBefore: createdBefore,
+			Limit:         int32(batchSize),
+		})
+		if err != nil {
+			return result, fmt.Errorf("prune events: %w", err)
+		}
+		for _, size := range sizes {
+			result.Events++
+			result.Bytes += size
+		}
+		if len(sizes) < batchSize {
+			return result, nil
+		}
+	}
+}
+
+// eventFilterArgs holds the query arguments of an event filter, unset criteria are NULL.
+type eventFilterArgs struct {
+	SignatureVerdict pgtype.Text
+	Method           pgtype.Text
+	CreatedFrom      pgtype.Timestamptz
+	CreatedTo        pgtype.Timestamptz
+	HeaderNames      []string
+	HeaderValues     []byte
+	QueryNames       []string
+	QueryValues      []byte
+	BodyContains     []byte
+	BodyPath         pgtype.Text
+	PathSuffix       pgtype.Text
+}
+
+func toEventFilterArgs(filter ports.EventFilter) (eventFilterArgs, error) {
+	args := eventFilterArgs{
+		SignatureVerdict: pgtype.Text{String: filter.SignatureVerdict, Valid: filter.SignatureVerdict != ""},
+		Method:           pgtype.Text{String: filter.Method, Valid: filter.Method != ""},
+		CreatedFrom:      pgtype.Timestamptz{Time: filter.From, Valid: !filter.From.IsZero()},
+		CreatedTo:        pgtype.Timestamptz{Time: filter.To, Valid: !filter.To.IsZero()},
+		BodyContains:     filter.BodyContains,
+		PathSuffix:       pgtype.Text{String: filter.PathSuffix, Valid: filter.PathSuffix != ""},
+	}
+	if filter.BodyPath != nil {
+		args.BodyPath = pgtype.Text{String: filter.BodyPath.String(), Valid: true}
+	}
+
+	var err error
+	if args.HeaderNames, args.HeaderValues, err = toContainment(filter.Headers); err != nil {
+		return eventFilterArgs{}, fmt.Errorf("header filter: %w", err)
+	}
+	if args.QueryNames, args.QueryValues, err = toContainment(filter.QueryParams); err != nil {
+		return eventFilterArgs{}, fmt.Errorf("query param filter: %w", err)
+	}
+	return args, nil
+}
+
+// toContainment splits wanted values into the keys that must exist and a JSONB document the column must contain.
+func toContainment(wanted map[string][]string) ([]string, []byte, error) {
+	if len(wanted) == 0 {
+		return nil, nil, nil
+	}
+
+	names := make([]string, 0, len(wanted))
+	values := make(map[string][]string)
+	for k, v := range wanted {
+		names = append(names, k)
+		if len(v) > 0 {
+			values[k] = v
+		}
+	}
+	if len(values) == 0 {
+		return names, nil, nil
+	}
+
+	doc, err := json.Marshal(values)
+	if err != nil {
+		return nil, nil, err
+	}
+	return names, doc, nil
+}
+
+func toDomainEvent(model ormmodel.Event) (domain.Event, error) {
+	var (
+		evHeader   http.Header
+		evQueries  map[string][]string
+		evResponse *domain.EventResponse
+		evTLS      *domain.TLSInfo
+		evRedacted []domain.RedactedField
+	)
+	if len(model.Header) > 0 {
 		if err := json.Unmarshal(model.Header, &evHeader); err != nil {
-			log.Printf("unmarshal header: %v", err)
-			continue
+			return domain.Event{}, fmt.Errorf("unmarshal header: %w", err)
 		}
+	}
+	if len(model.QueryParams) > 0 {
 		if err := json.Unmarshal(model.QueryParams, &evQueries); err != nil {
-			log.Printf("unmarshal query params: %v", err)
-			continue
+			return domain.Event{}, fmt.Errorf("unmarshal query params: %w", err)
+		}
+	}
+	if len(model.Response) > 0 {
+		if err := json.Unmarshal(model.Response, &evResponse); err != nil {
+			return domain.Event{}, fmt.Errorf("unmarshal response: %w", err)
+		}
+	}
+	if len(model.Tls) > 0 {
+		if err := json.Unmarshal(model.Tls, &evTLS); err != nil {
+			return domain.Event{}, fmt.Errorf("unmarshal tls: %w", err)
 		}
+	}
+	if len(model.Redacted) > 0 {
+		if err := json.Unmarshal(model.Redacted, &evRedacted); err != nil {
+			return domain.Event{}, fmt.Errorf("unmarshal redacted fields: %w", err)
+		}
+	}
 
-		events[idx] = domain.Event{
-			ID:          model.ID,
-			Method:      model.Method,
-			Body:        model.Body,
-			Header:      evHeader,
-			QueryParams: evQueries,
-			CreatedAt:   model.CreatedAt.Time,
+	var evSignature *domain.SignatureCheck
+	if model.SignatureVerdict != "" {
+		evSignature = &domain.SignatureCheck{
+			Verdict: model.SignatureVerdict,
+			Reason:  model.SignatureReason,
 		}
 	}
 
-	return events, true, nil
+	return domain.Event{
+		ID:            model.ID,
+		Method:        model.Method,
+		Body:          model.Body,
+		BodyBase64:    model.BodyBase64,
+		ContentType:   model.ContentType,
+		ContentLength: model.ContentLength,
+		Header:        evHeader,
+		QueryParams:   evQueries,
+		Response:      evResponse,
+		Signature:     evSignature,
+		Redacted:      evRedacted,
+		Path:          model.Path,
+		RemoteAddr:    model.RemoteAddr,
+		Proto:         model.Proto,
+		Host:          model.Host,
+		TLS:           evTLS,
+		DurationUS:    model.DurationUs,
+		CreatedAt:     model.CreatedAt.Time,
+	}, nil
 }
@@ -0,0 +1,301 @@
+package repository
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/erwin-lovecraft/pistol/internal/core/domain"
+	"github.com/erwin-lovecraft/pistol/internal/core/ports"
+	"github.com/erwin-lovecraft/pistol/pkg/jsonpath"
+)
+
+// listedEvents are saved a minute apart, in order, by saveListedEvents.
+func listedEvents() []domain.Event {
+	return []domain.Event{
+		{
+			Method:      http.MethodGet,
+			Path:        "/a",
+			Header:      http.Header{"X-Kind": {"ping"}},
+			QueryParams: map[string][]string{"q": {"1"}},
+			Body:        []byte(`{"n":1}`),
+		},
+		{
+			Method:    http.MethodPost,
+			Path:      "/github/hooks",
+			Header:    http.Header{"X-Kind": {"push"}},
+			Body:      []byte(`{"n":2,"action":"opened"}`),
+			Signature: &domain.SignatureCheck{Verdict: domain.SignatureVerified},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/b",
+			Header:      http.Header{"X-Kind": {"push"}},
+			QueryParams: map[string][]string{"q": {"2"}},
+			Body:        []byte("plain text"),
+		},
+		{
+			Method: http.MethodPut,
+			Path:   "/github/hooks",
+			Body:   []byte(`{"n":4,"action":"closed"}`),
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/c",
+			Header:      http.Header{"X-Kind": {"push", "retry"}},
+			QueryParams: map[string][]string{"q": {"1"}},
+			Body:        []byte(`{"n":5,"action":"opened"}`),
+		},
+	}
+}
+
+func saveListedEvents(t *testing.T, b backend, roomID string, base time.Time) []domain.Event {
+	t.Helper()
+
+	events := listedEvents()
+	for idx := range events {
+		events[idx].CreatedAt = base.Add(time.Duration(idx) * time.Minute)
+		events[idx].ContentLength = int64(len(events[idx].Body))
+	}
+	saveEvents(t, b, roomID, events)
+	return events
+}
+
+func TestEventRepositoryListFilters(t *testing.T) {
+	eachBackend(t, func(t *testing.T, b backend) {
+		ctx := context.Background()
+		base := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+		roomID := newRoom(t, b)
+		events := saveListedEvents(t, b, roomID, base)
+		// Another room's events are never listed
+		saveListedEvents(t, b, newRoom(t, b), base)
+
+		opened, err := jsonpath.Parse(`$.action == "opened"`)
+		if err != nil {
+			t.Fatalf("parse jsonpath: %v", err)
+		}
+
+		tests := []struct {
+			name   string
+			filter ports.EventFilter
+			want   []int
+		}{
+			{name: "none", want: []int{4, 3, 2, 1, 0}},
+			{name: "method", filter: ports.EventFilter{Method: http.MethodPost}, want: []int{4, 2, 1}},
+			{name: "header", filter: ports.EventFilter{Headers: map[string][]string{"X-Kind": {"push"}}}, want: []int{4, 2, 1}},
+			{name: "header values", filter: ports.EventFilter{Headers: map[string][]string{"X-Kind": {"push", "retry"}}}, want: []int{4}},
+			{name: "header present", filter: ports.EventFilter{Headers: map[string][]string{"X-Kind": nil}}, want: []int{4, 2, 1, 0}},
+			{name: "query", filter: ports.EventFilter{QueryParams: map[string][]string{"q": {"1"}}}, want: []int{4, 0}},
+			{name: "body contains", filter: ports.EventFilter{BodyContains: []byte("opened")}, want: []int{4, 1}},
+			{name: "body path", filter: ports.EventFilter{BodyPath: opened}, want: []int{4, 1}},
+			{name: "time range", filter: ports.EventFilter{From: base.Add(time.Minute), To: base.Add(3 * time.Minute)}, want: []int{2, 1}},
+			{name: "signature", filter: ports.EventFilter{SignatureVerdict: domain.SignatureVerified}, want: []int{1}},
+			{name: "path suffix", filter: ports.EventFilter{PathSuffix: "/hooks"}, want: []int{3, 1}},
+			{
+				name:   "all of them",
+				filter: ports.EventFilter{Method: http.MethodPost, BodyPath: opened, QueryParams: map[string][]string{"q": {"1"}}},
+				want:   []int{4},
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, hasMore, err := b.events.List(ctx, roomID, tt.filter, ports.EventCursor{Limit: 10})
+				if err != nil {
+					t.Fatalf("list: %v", err)
+				}
+				if want := pickIDs(events, tt.want...); !equalIDs(eventIDs(got), want) {
+					t.Errorf("listed %v, want %v", eventIDs(got), want)
+				}
+				if hasMore {
+					t.Error("hasMore = true, want false")
+				}
+			})
+		}
+	})
+}
+
+func TestEventRepositoryListCursors(t *testing.T) {
+	eachBackend(t, func(t *testing.T, b backend) {
+		ctx := context.Background()
+		roomID := newRoom(t, b)
+		events := saveListedEvents(t, b, roomID, time.Now().UTC().Add(-time.Hour).Truncate(time.Second))
+
+		tests := []struct {
+			name     string
+			filter   ports.EventFilter
+			cursor   ports.EventCursor
+			want     []int
+			wantMore bool
+		}{
+			{name: "newest", cursor: ports.EventCursor{Limit: 2}, want: []int{4, 3}, wantMore: true},
+			{name: "before", cursor: ports.EventCursor{Before: events[3].ID, Limit: 2}, want: []int{2, 1}, wantMore: true},
+			{name: "before, last page", cursor: ports.EventCursor{Before: events[1].ID, Limit: 2}, want: []int{0}},
+			{name: "after", cursor: ports.EventCursor{After: events[0].ID, Limit: 2}, want: []int{2, 1}, wantMore: true},
+			{name: "after, last page", cursor: ports.EventCursor{After: events[2].ID, Limit: 2}, want: []int{4, 3}},
+			{name: "after the newest", cursor: ports.EventCursor{After: events[4].ID, Limit: 2}},
+			{
+				name:     "filtered",
+				filter:   ports.EventFilter{Headers: map[string][]string{"X-Kind": {"push"}}},
+				cursor:   ports.EventCursor{Before: events[4].ID, Limit: 1},
+				want:     []int{2},
+				wantMore: true,
+			},
+			{
+				name:   "filtered, after",
+				filter: ports.EventFilter{Method: http.MethodPost},
+				cursor: ports.EventCursor{After: events[1].ID, Limit: 2},
+				want:   []int{4, 2},
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, hasMore, err := b.events.List(ctx, roomID, tt.filter, tt.cursor)
+				if err != nil {
+					t.Fatalf("list: %v", err)
+				}
+				if want := pickIDs(events, tt.want...); !equalIDs(eventIDs(got), want) {
+					t.Errorf("listed %v, want %v", eventIDs(got), want)
+				}
+				if hasMore != tt.wantMore {
+					t.Errorf("hasMore = %v, want %v", hasMore, tt.wantMore)
+				}
+			})
+		}
+	})
+}
+
+func TestEventRepositoryListAfter(t *testing.T) {
+	eachBackend(t, func(t *testing.T, b backend) {
+		ctx := context.Background()
+		roomID := newRoom(t, b)
+		events := saveListedEvents(t, b, roomID, time.Now().UTC().Add(-time.Hour).Truncate(time.Second))
+
+		tests := []struct {
+			name    string
+			afterID int64
+			limit   int
+			want    []int
+		}{
+			{name: "from the start", limit: 10, want: []int{0, 1, 2, 3, 4}},
+			{name: "limited", afterID: events[1].ID, limit: 2, want: []int{2, 3}},
+			{name: "after the newest", afterID: events[4].ID, limit: 10},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := b.events.ListAfter(ctx, roomID, tt.afterID, tt.limit)
+				if err != nil {
+					t.Fatalf("list after: %v", err)
+				}
+				if want := pickIDs(events, tt.want...); !equalIDs(eventIDs(got), want) {
+					t.Errorf("listed %v, want %v", eventIDs(got), want)
+				}
+			})
+		}
+	})
+}
+
+func TestEventRepositoryPrune(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy domain.RetentionPolicy
+		// kept are the indexes of the events left, out of five of 10 bytes pushed an hour apart, 5h to 1h ago
+		kept []int
+	}{
+		{name: "unbounded", kept: []int{0, 1, 2, 3, 4}},
+		{name: "max events", policy: domain.RetentionPolicy{MaxEvents: 2}, kept: []int{3, 4}},
+		{name: "max bytes", policy: domain.RetentionPolicy{MaxBytes: 25}, kept: []int{3, 4}},
+		{name: "max age", policy: domain.RetentionPolicy{MaxAgeSeconds: int64(150 * time.Minute / time.Second)}, kept: []int{3, 4}},
+		{name: "tightest limit wins", policy: domain.RetentionPolicy{MaxEvents: 4, MaxBytes: 10, MaxAgeSeconds: 86400}, kept: []int{4}},
+		{name: "within limits", policy: domain.RetentionPolicy{MaxEvents: 5, MaxBytes: 50}, kept: []int{0, 1, 2, 3, 4}},
+	}
+
+	eachBackend(t, func(t *testing.T, b backend) {
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				ctx := context.Background()
+				roomID := newRoom(t, b)
+				now := time.Now().UTC().Truncate(time.Second)
+				events := make([]domain.Event, 5)
+				for idx := range events {
+					events[idx] = domain.Event{
+						Method:        http.MethodPost,
+						Body:          []byte("0123456789"),
+						ContentLength: 10,
+						CreatedAt:     now.Add(time.Duration(idx-5) * time.Hour),
+					}
+				}
+				saveEvents(t, b, roomID, events)
+
+				// Batches smaller than what's pruned make it take several rounds
+				result, err := b.events.Prune(ctx, roomID, tt.policy, 2)
+				if err != nil {
+					t.Fatalf("prune: %v", err)
+				}
+				pruned := int64(len(events) - len(tt.kept))
+				if want := (ports.PruneResult{Events: pruned, Bytes: 10 * pruned}); result != want {
+					t.Errorf("pruned %+v, want %+v", result, want)
+				}
+
+				left, err := b.events.ListAfter(ctx, roomID, 0, 10)
+				if err != nil {
+					t.Fatalf("list after: %v", err)
+				}
+				if want := pickIDs(events, tt.kept...); !equalIDs(eventIDs(left), want) {
+					t.Errorf("kept %v, want %v", eventIDs(left), want)
+				}
+			})
+		}
+	})
+}
+
+func TestInMemoryEventRepositoryPruneWhileSaving(t *testing.T) {
+	ctx := context.Background()
+	repo := NewInMemoryEventRepository(0, time.Minute)
+	policy := domain.RetentionPolicy{MaxEvents: 10}
+
+	// Every event saved while pruning is either pruned and counted, or kept
+	const saved = 2000
+	var pruned int64
+	var saves, prunes sync.WaitGroup
+	done := make(chan struct{})
+	prunes.Add(1)
+	go func() {
+		defer prunes.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			result, err := repo.Prune(ctx, "room", policy, 10)
+			if err != nil {
+				t.Errorf("prune: %v", err)
+			}
+			pruned += result.Events
+		}
+	}()
+	for range 4 {
+		saves.Add(1)
+		go func() {
+			defer saves.Done()
+			for range saved / 4 {
+				if err := repo.Save(ctx, "room", &domain.Event{}); err != nil {
+					t.Errorf("save: %v", err)
+				}
+			}
+		}()
+	}
+	saves.Wait()
+	close(done)
+	prunes.Wait()
+
+	left, err := repo.ListAfter(ctx, "room", 0, saved)
+	if err != nil {
+		t.Fatalf("list after: %v", err)
+	}
+	if got := pruned + int64(len(left)); got != saved {
+		t.Errorf("pruned %d and kept %d events, want %d in all", pruned, len(left), saved)
+	}
+}
@@ -3,7 +3,9 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
+	"slices"
 	"sync"
 	"time"
 
@@ -12,37 +14,62 @@ import (
 )
 
 var (
-	timeNowFunc     = time.Now
-	cleanupSchedule = 15 * time.Minute
-	defaultTTL      = time.Hour
+	timeNowFunc = time.Now
 )
 
 var _ ports.EventRepository = (*InMemoryEventRepository)(nil)
 
 type InMemoryEventRepository struct {
 	cache sync.Map
+	// locks holds a *sync.Mutex per room, serializing the writes to its events
+	locks sync.Map
+	// ttl is the age past which events are dropped, zero keeps them
+	ttl             time.Duration
+	cleanupInterval time.Duration
 }
 
-func NewInMemoryEventRepository() *InMemoryEventRepository {
-	repo := InMemoryEventRepository{
-		cache: sync.Map{},
+// NewInMemoryEventRepository returns a repository dropping events older than ttl every cleanupInterval, a zero ttl
+// leaves them to retention.
+func NewInMemoryEventRepository(ttl time.Duration, cleanupInterval time.Duration) *InMemoryEventRepository {
+	return &InMemoryEventRepository{
+		cache:           sync.Map{},
+		ttl:             ttl,
+		cleanupInterval: cleanupInterval,
 	}
+}
 
-	go func() {
-		ticker := time.NewTicker(cleanupSchedule)
-		for {
-			select {
-			case <-ticker.C:
-				repo.cleanUp(defaultTTL)
-			}
-		}
-	}()
+// Run drops expired events every cleanupInterval until ctx is done.
+func (i *InMemoryEventRepository) Run(ctx context.Context) {
+	if i.ttl == 0 {
+		return
+	}
 
-	return &repo
+	ticker := time.NewTicker(i.cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			i.cleanUp(i.ttl)
+		}
+	}
 }
 
 func (i *InMemoryEventRepository) Save(ctx context.Context, roomID string, ev *domain.Event) error {
-	ev.CreatedAt = timeNowFunc().UTC()
+	if ev.ID == 0 {
+		id, err := sf.NextID()
+		if err != nil {
+			return fmt.Errorf("generate id: %w", err)
+		}
+		ev.ID = id
+	}
+	if ev.CreatedAt.IsZero() {
+		ev.CreatedAt = timeNowFunc().UTC()
+	}
+
+	defer i.lock(roomID)()
 
 	data, ok := i.cache.Load(roomID)
 	if !ok || data == nil {
@@ -60,36 +87,139 @@ func (i *InMemoryEventRepository) Save(ctx context.Context, roomID string, ev *d
 	return nil
 }
 
-func (i *InMemoryEventRepository) List(ctx context.Context, roomID string, page, size int) ([]domain.Event, bool, error) {
+func (i *InMemoryEventRepository) Get(ctx context.Context, roomID string, id int64) (domain.Event, error) {
 	data, ok := i.cache.Load(roomID)
 	if !ok || data == nil {
-		return nil, false, nil
+		return domain.Event{}, domain.ErrEventNotFound
 	}
 
 	events, ok := data.([]domain.Event)
+	if !ok {
+		return domain.Event{}, errors.New("invalid data")
+	}
+
+	for _, ev := range events {
+		if ev.ID == id {
+			return ev, nil
+		}
+	}
+	return domain.Event{}, domain.ErrEventNotFound
+}
+
+func (i *InMemoryEventRepository) List(ctx context.Context, roomID string, filter ports.EventFilter, cursor ports.EventCursor) ([]domain.Event, bool, error) {
+	data, ok := i.cache.Load(roomID)
+	if !ok || data == nil {
+		return nil, false, nil
+	}
+
+	all, ok := data.([]domain.Event)
 	if !ok {
 		return nil, false, errors.New("invalid data")
 	}
 
-	offset := (page - 1) * size
-	hi := offset + size
-	if hi > len(events) {
-		hi = len(events)
+	// Events are kept oldest first, keep the ones on the cursor's side
+	var events []domain.Event
+	for _, ev := range all {
+		if cursor.Before > 0 && ev.ID >= cursor.Before {
+			continue
+		}
+		if cursor.After > 0 && ev.ID <= cursor.After {
+			continue
+		}
+		if filter.Match(ev) {
+			events = append(events, ev)
+		}
+	}
+
+	hasMore := len(events) > cursor.Limit
+	if hasMore {
+		if cursor.After > 0 {
+			// Newer events closest to the cursor come first
+			events = events[:cursor.Limit]
+		} else {
+			events = events[len(events)-cursor.Limit:]
+		}
+	}
+
+	rs := slices.Clone(events)
+	slices.Reverse(rs)
+	return rs, hasMore, nil
+}
+
+func (i *InMemoryEventRepository) Prune(ctx context.Context, roomID string, policy domain.RetentionPolicy, batchSize int) (ports.PruneResult, error) {
+	defer i.lock(roomID)()
+
+	data, ok := i.cache.Load(roomID)
+	if !ok || data == nil {
+		return ports.PruneResult{}, nil
+	}
+
+	events, ok := data.([]domain.Event)
+	if !ok {
+		return ports.PruneResult{}, errors.New("invalid data")
+	}
+
+	// Events are kept oldest first, walk from the newest to find the first one out of bounds
+	keep := len(events)
+	var count, size int64
+	for idx := len(events) - 1; idx >= 0; idx-- {
+		ev := events[idx]
+		count++
+		size += ev.ContentLength
+		if (policy.MaxEvents > 0 && count > policy.MaxEvents) ||
+			(policy.MaxBytes > 0 && size > policy.MaxBytes) ||
+			(policy.MaxAge() > 0 && ev.CreatedAt.Before(timeNowFunc().Add(-policy.MaxAge()))) {
+			break
+		}
+		keep = idx
+	}
+	if keep == 0 {
+		return ports.PruneResult{}, nil
+	}
+
+	var result ports.PruneResult
+	for _, ev := range events[:keep] {
+		result.Events++
+		result.Bytes += ev.ContentLength
+	}
+	i.cache.Store(roomID, slices.Clone(events[keep:]))
+	return result, nil
+}
+
+func (i *InMemoryEventRepository) ListAfter(ctx context.Context, roomID string, afterID int64, limit int) ([]domain.Event, error) {
+	data, ok := i.cache.Load(roomID)
+	if !ok || data == nil {
+		return nil, nil
+	}
+
+	events, ok := data.([]domain.Event)
+	if !ok {
+		return nil, errors.New("invalid data")
 	}
 
 	var rs []domain.Event
-	for idx := hi - 1; idx >= offset; idx-- {
-		rs = append(rs, events[idx])
+	for _, ev := range events {
+		if ev.ID <= afterID {
+			continue
+		}
+		if len(rs) == limit {
+			break
+		}
+		rs = append(rs, ev)
 	}
 
-	return rs, offset+size < len(events), nil
+	return rs, nil
 }
 
 func (i *InMemoryEventRepository) cleanUp(ttl time.Duration) {
 	log.Printf("[event_repository] cleaning up expired events")
 	now := timeNowFunc().UTC()
 
-	i.cache.Range(func(key, value interface{}) bool {
+	i.cache.Range(func(key, _ interface{}) bool {
+		defer i.lock(key.(string))()
+
+		// Load again under the lock, the value ranged over may be stale
+		value, _ := i.cache.Load(key)
 		var retained []domain.Event
 		for _, ev := range value.([]domain.Event) {
 			if now.After(ev.CreatedAt.Add(ttl)) {
@@ -103,3 +233,10 @@ func (i *InMemoryEventRepository) cleanUp(ttl time.Duration) {
 		return true
 	})
 }
+
+// lock locks the room's writes and returns the unlock function, readers load the events without locking.
+func (i *InMemoryEventRepository) lock(roomID string) func() {
+	mu, _ := i.locks.LoadOrStore(roomID, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	return mu.(*sync.Mutex).Unlock
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/erwin-lovecraft/pistol/internal/core/domain"
+	"github.com/erwin-lovecraft/pistol/internal/core/ports"
+)
+
+var _ ports.ReplayRepository = (*InMemoryReplayRepository)(nil)
+
+type InMemoryReplayRepository struct {
+	// cache holds the attempts of each event, oldest first
+	cache map[int64][]domain.ReplayAttempt
+	mu    sync.RWMutex
+}
+
+func NewInMemoryReplayRepository() *InMemoryReplayRepository {
+	return &InMemoryReplayRepository{
+		cache: make(map[int64][]domain.ReplayAttempt),
+	}
+}
+
+func (i *InMemoryReplayRepository) Save(ctx context.Context, attempt *domain.ReplayAttempt) error {
+	if attempt.ID == 0 {
+		id, err := sf.NextID()
+		if err != nil {
+			return fmt.Errorf("generate id: %w", err)
+		}
+		attempt.ID = id
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	attempt.CreatedAt = timeNowFunc().UTC()
+	i.cache[attempt.EventID] = append(i.cache[attempt.EventID], *attempt)
+	return nil
+}
+
+func (i *InMemoryReplayRepository) ListByEvent(ctx context.Context, eventID int64, limit int) ([]domain.ReplayAttempt, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	attempts := slices.Clone(i.cache[eventID])
+	slices.Reverse(attempts)
+	if len(attempts) > limit {
+		attempts = attempts[:limit]
+	}
+	return attempts, nil
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/erwin-lovecraft/pistol/internal/core/domain"
+	"github.com/erwin-lovecraft/pistol/internal/core/ports"
+)
+
+var _ ports.RoomRepository = (*InMemoryRoomRepository)(nil)
+
+type InMemoryRoomRepository struct {
+	cache map[string]domain.Room
+	mu    sync.RWMutex
+	// tokens keeps the tokens the rooms are created with
+	tokens *InMemoryTokenRepository
+}
+
+func NewInMemoryRoomRepository(tokens *InMemoryTokenRepository) *InMemoryRoomRepository {
+	return &InMemoryRoomRepository{
+		cache:  make(map[string]domain.Room),
+		tokens: tokens,
+	}
+}
+
+func (i *InMemoryRoomRepository) SaveRoom(ctx context.Context, room *domain.Room) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	now := timeNowFunc().UTC()
+	if existing, ok := i.cache[room.ID]; ok {
+		room.CreatedAt = existing.CreatedAt
+	} else {
+		room.CreatedAt = now
+	}
+	room.UpdatedAt = now
+
+	i.cache[room.ID] = *room
+	return nil
+}
+
+func (i *InMemoryRoomRepository) CreateRoom(ctx context.Context, room *domain.Room, tokens []domain.RoomToken) error {
+	// IDs are generated first, nothing can fail once the room is stored
+	for idx := range tokens {
+		if tokens[idx].ID == 0 {
+			id, err := sf.NextID()
+			if err != nil {
+				return fmt.Errorf("generate id: %w", err)
+			}
+			tokens[idx].ID = id
+		}
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.tokens.mu.Lock()
+	defer i.tokens.mu.Unlock()
+
+	now := timeNowFunc().UTC()
+	room.CreatedAt = now
+	room.UpdatedAt = now
+	i.cache[room.ID] = *room
+
+	for idx := range tokens {
+		tokens[idx].RoomID = room.ID
+		tokens[idx].CreatedAt = now
+		i.tokens.cache[tokens[idx].ID] = tokens[idx]
+	}
+	return nil
+}
+
+func (i *InMemoryRoomRepository) GetByID(ctx context.Context, id string) (domain.Room, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	room, ok := i.cache[id]
+	if !ok {
+		return domain.Room{}, domain.ErrRoomNotFound
+	}
+	return room, nil
+}
+
+func (i *InMemoryRoomRepository) List(ctx context.Context, filter ports.RoomFilter) ([]domain.Room, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	var rooms []domain.Room
+	for _, room := range i.cache {
+		rooms = append(rooms, room)
+	}
+	sort.Slice(rooms, func(a, b int) bool {
+		return rooms[a].CreatedAt.After(rooms[b].CreatedAt)
+	})
+	return rooms, nil
+}
+
+func (i *InMemoryRoomRepository) UpdateName(ctx context.Context, id string, name string) (domain.Room, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	room, ok := i.cache[id]
+	if !ok {
+		return domain.Room{}, domain.ErrRoomNotFound
+	}
+	room.Name = name
+	room.UpdatedAt = timeNowFunc().UTC()
+	i.cache[id] = room
+	return room, nil
+}
+
+func (i *InMemoryRoomRepository) UpdateResponse(ctx context.Context, id string, rule *domain.ResponseRule) (domain.Room, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	room, ok := i.cache[id]
+	if !ok {
+		return domain.Room{}, domain.ErrRoomNotFound
+	}
+	room.Response = rule
+	room.UpdatedAt = timeNowFunc().UTC()
+	i.cache[id] = room
+	return room, nil
+}
+
+func (i *InMemoryRoomRepository) UpdateForwards(ctx context.Context, id string, rules []domain.ForwardRule) (domain.Room, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	room, ok := i.cache[id]
+	if !ok {
+		return domain.Room{}, domain.ErrRoomNotFound
+	}
+	room.Forwards = rules
+	room.UpdatedAt = timeNowFunc().UTC()
+	i.cache[id] = room
+	return room, nil
+}
+
+func (i *InMemoryRoomRepository) UpdateSignature(ctx context.Context, id string, profile *domain.SignatureProfile) (domain.Room, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	room, ok := i.cache[id]
+	if !ok {
+		return domain.Room{}, domain.ErrRoomNotFound
+	}
+	room.Signature = profile
+	room.UpdatedAt = timeNowFunc().UTC()
+	i.cache[id] = room
+	return room, nil
+}
+
+func (i *InMemoryRoomRepository) UpdateRetention(ctx context.Context, id string, policy *domain.RetentionPolicy) (domain.Room, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	room, ok := i.cache[id]
+	if !ok {
+		return domain.Room{}, domain.ErrRoomNotFound
+	}
+	room.Retention = policy
+	room.UpdatedAt = timeNowFunc().UTC()
+	i.cache[id] = room
+	return room, nil
+}
+
+func (i *InMemoryRoomRepository) UpdateRedaction(ctx context.Context, id string, rules []domain.RedactionRule) (domain.Room, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	room, ok := i.cache[id]
+	if !ok {
+		return domain.Room{}, domain.ErrRoomNotFound
+	}
+	room.Redaction = rules
+	room.UpdatedAt = timeNowFunc().UTC()
+	i.cache[id] = room
+	return room, nil
+}
+
+func (i *InMemoryRoomRepository) DeleteByID(ctx context.Context, id string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if _, ok := i.cache[id]; !ok {
+		return domain.ErrRoomNotFound
+	}
+	delete(i.cache, id)
+	return nil
+}
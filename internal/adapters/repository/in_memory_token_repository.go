@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/erwin-lovecraft/pistol/internal/core/domain"
+	"github.com/erwin-lovecraft/pistol/internal/core/ports"
+)
+
+var _ ports.TokenRepository = (*InMemoryTokenRepository)(nil)
+
+type InMemoryTokenRepository struct {
+	cache map[int64]domain.RoomToken
+	mu    sync.RWMutex
+}
+
+func NewInMemoryTokenRepository() *InMemoryTokenRepository {
+	return &InMemoryTokenRepository{
+		cache: make(map[int64]domain.RoomToken),
+	}
+}
+
+func (i *InMemoryTokenRepository) Save(ctx context.Context, token *domain.RoomToken) error {
+	if token.ID == 0 {
+		id, err := sf.NextID()
+		if err != nil {
+			return fmt.Errorf("generate id: %w", err)
+		}
+		token.ID = id
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	token.CreatedAt = timeNowFunc().UTC()
+	i.cache[token.ID] = *token
+	return nil
+}
+
+func (i *InMemoryTokenRepository) Get(ctx context.Context, roomID string, id int64) (domain.RoomToken, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	token, ok := i.cache[id]
+	if !ok || token.RoomID != roomID {
+		return domain.RoomToken{}, domain.ErrTokenNotFound
+	}
+	return token, nil
+}
+
+func (i *InMemoryTokenRepository) List(ctx context.Context, roomID string) ([]domain.RoomToken, error) {
+	return i.list(roomID, func(domain.RoomToken) bool { return true }), nil
+}
+
+func (i *InMemoryTokenRepository) ListActive(ctx context.Context, roomID string, scope string) ([]domain.RoomToken, error) {
+	now := timeNowFunc()
+	return i.list(roomID, func(token domain.RoomToken) bool {
+		return token.Scope == scope &&
+			token.RevokedAt == nil &&
+			(token.ExpiresAt == nil || token.ExpiresAt.After(now))
+	}), nil
+}
+
+func (i *InMemoryTokenRepository) Revoke(ctx context.Context, roomID string, id int64) (domain.RoomToken, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	token, ok := i.cache[id]
+	// Revoking twice is reported as not found, as in Postgres
+	if !ok || token.RoomID != roomID || token.RevokedAt != nil {
+		return domain.RoomToken{}, domain.ErrTokenNotFound
+	}
+	now := timeNowFunc().UTC()
+	token.RevokedAt = &now
+	i.cache[id] = token
+	return token, nil
+}
+
+func (i *InMemoryTokenRepository) Rotate(ctx context.Context, roomID string, id int64, next *domain.RoomToken) error {
+	if next.ID == 0 {
+		nextID, err := sf.NextID()
+		if err != nil {
+			return fmt.Errorf("generate id: %w", err)
+		}
+		next.ID = nextID
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	token, ok := i.cache[id]
+	if !ok || token.RoomID != roomID || token.RevokedAt != nil {
+		return domain.ErrTokenNotFound
+	}
+	now := timeNowFunc().UTC()
+	token.RevokedAt = &now
+	i.cache[id] = token
+
+	next.RoomID = token.RoomID
+	next.Name = token.Name
+	next.Scope = token.Scope
+	next.ExpiresAt = token.ExpiresAt
+	next.CreatedAt = now
+	i.cache[next.ID] = *next
+	return nil
+}
+
+func (i *InMemoryTokenRepository) list(roomID string, match func(domain.RoomToken) bool) []domain.RoomToken {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	var tokens []domain.RoomToken
+	for _, token := range i.cache {
+		if token.RoomID == roomID && match(token) {
+			tokens = append(tokens, token)
+		}
+	}
+	sort.Slice(tokens, func(a, b int) bool {
+		return tokens[a].ID > tokens[b].ID
+	})
+	return tokens
+}
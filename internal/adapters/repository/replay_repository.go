@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/erwin-lovecraft/pistol/internal/adapters/ormmodel"
+	"github.com/erwin-lovecraft/pistol/internal/core/domain"
+	"github.com/erwin-lovecraft/pistol/internal/core/ports"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var _ ports.ReplayRepository = (*replayRepository)(nil)
+
+type replayRepository struct {
+	queries *ormmodel.Queries
+}
+
+func NewReplayRepository(dbPool *pgxpool.Pool) ports.ReplayRepository {
+	return replayRepository{
+		queries: ormmodel.New(dbPool),
+	}
+}
+
+func (repo replayRepository) Save(ctx context.Context, attempt *domain.ReplayAttempt) error {
+	if attempt.ID == 0 {
+		id, err := sf.NextID()
+		if err != nil {
+			return fmt.Errorf("generate id: %w", err)
+		}
+		attempt.ID = id
+	}
+
+	var (
+		err                 error
+		requestHeaderBytes  []byte
+		responseHeaderBytes []byte
+	)
+	if attempt.RequestHeader != nil {
+		if requestHeaderBytes, err = json.Marshal(attempt.RequestHeader); err != nil {
+			return fmt.Errorf("marshal request header: %w", err)
+		}
+	}
+	if attempt.ResponseHeader != nil {
+		if responseHeaderBytes, err = json.Marshal(attempt.ResponseHeader); err != nil {
+			return fmt.Errorf("marshal response header: %w", err)
+		}
+	}
+
+	createdAt, err := repo.queries.SaveReplayAttempt(ctx, ormmodel.SaveReplayAttemptParams{
+		ID:                 attempt.ID,
+		EventID:            attempt.EventID,
+		TargetUrl:          attempt.TargetURL,
+		RequestHeader:      requestHeaderBytes,
+		StatusCode:         int32(attempt.StatusCode),
+		ResponseHeader:     responseHeaderBytes,
+		ResponseBody:       attempt.ResponseBody,
+		ResponseBodyBase64: attempt.ResponseBodyBase64,
+		LatencyMs:          attempt.LatencyMS,
+		Error:              attempt.Error,
+		Source:             attempt.Source,
+		Attempt:            int32(attempt.Attempt),
+	})
+	if err != nil {
+		return fmt.Errorf("save replay attempt: %w", err)
+	}
+	attempt.CreatedAt = createdAt.Time
+	return nil
+}
+
+func (repo replayRepository) ListByEvent(ctx context.Context, eventID int64, limit int) ([]domain.ReplayAttempt, error) {
+	models, err := repo.queries.ListReplayAttempts(ctx, ormmodel.ListReplayAttemptsParams{
+		EventID: eventID,
+		Limit:   int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list replay attempts: %w", err)
+	}
+
+	attempts := make([]domain.ReplayAttempt, len(models))
+	for idx, model := range models {
+		var requestHeader, responseHeader http.Header
+		if len(model.RequestHeader) > 0 {
+			if err := json.Unmarshal(model.RequestHeader, &requestHeader); err != nil {
+				return nil, fmt.Errorf("unmarshal request header: %w", err)
+			}
+		}
+		if len(model.ResponseHeader) > 0 {
+			if err := json.Unmarshal(model.ResponseHeader, &responseHeader); err != nil {
+				return nil, fmt.Errorf("unmarshal response header: %w", err)
+			}
+		}
+
+		attempts[idx] = domain.ReplayAttempt{
+			ID:                 model.ID,
+			EventID:            model.EventID,
+			TargetURL:          model.TargetUrl,
+			RequestHeader:      requestHeader,
+			StatusCode:         int(model.StatusCode),
+			ResponseHeader:     responseHeader,
+			ResponseBody:       model.ResponseBody,
+			ResponseBodyBase64: model.ResponseBodyBase64,
+			LatencyMS:          model.LatencyMs,
+			Error:              model.Error,
+			Source:             model.Source,
+			Attempt:            int(model.Attempt),
+			CreatedAt:          model.CreatedAt.Time,
+		}
+	}
+	return attempts, nil
+}
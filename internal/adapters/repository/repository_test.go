@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/erwin-lovecraft/pistol/internal/core/domain"
+	"github.com/erwin-lovecraft/pistol/internal/core/ports"
+	"github.com/erwin-lovecraft/pistol/migrations"
+	"github.com/google/uuid"
+	"github.com/pressly/goose/v3"
+	"github.com/sony/sonyflake/v2"
+	_ "modernc.org/sqlite"
+)
+
+func TestMain(m *testing.M) {
+	// The machine ID is derived from a private IP by default, which test hosts may not have
+	var err error
+	sf, err = sonyflake.New(sonyflake.Settings{MachineID: func() (int, error) { return 1, nil }})
+	if err != nil {
+		log.Fatalf("id generator: %v", err)
+	}
+	os.Exit(m.Run())
+}
+
+// backend holds the repositories of a storage.
+type backend struct {
+	rooms  ports.RoomRepository
+	events ports.EventRepository
+	tokens ports.TokenRepository
+}
+
+// eachBackend runs the test against the memory storage and against a SQLite database of its own.
+func eachBackend(t *testing.T, test func(t *testing.T, b backend)) {
+	t.Run("memory", func(t *testing.T) {
+		tokens := NewInMemoryTokenRepository()
+		test(t, backend{
+			rooms:  NewInMemoryRoomRepository(tokens),
+			events: NewInMemoryEventRepository(0, time.Minute),
+			tokens: tokens,
+		})
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		db := openSQLite(t)
+		test(t, backend{
+			rooms:  NewSQLiteRoomRepository(db),
+			events: NewSQLiteEventRepository(db),
+			tokens: NewSQLiteTokenRepository(db),
+		})
+	})
+}
+
+// openSQLite opens a migrated SQLite database in a temporary directory, set up as the sqlite storage sets it up.
+func openSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "pistol.db")+
+		"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_time_format=sqlite")
+	if err != nil {
+		t.Fatalf("open sqlite database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	goose.SetBaseFS(migrations.SQLiteFS)
+	goose.SetLogger(goose.NopLogger())
+	if err := goose.SetDialect("sqlite3"); err != nil {
+		t.Fatalf("goose set dialect: %v", err)
+	}
+	if err := goose.Up(db, "sqlite"); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	return db
+}
+
+// newRoom saves a room and returns its ID.
+func newRoom(t *testing.T, b backend) string {
+	t.Helper()
+
+	room := domain.Room{ID: uuid.NewString(), Name: "room"}
+	if err := b.rooms.SaveRoom(context.Background(), &room); err != nil {
+		t.Fatalf("save room: %v", err)
+	}
+	return room.ID
+}
+
+// saveEvents saves the events in order, setting their IDs. Events with a CreatedAt are saved at that time.
+func saveEvents(t *testing.T, b backend, roomID string, events []domain.Event) {
+	t.Helper()
+
+	now := timeNowFunc
+	defer func() { timeNowFunc = now }()
+	for idx := range events {
+		timeNowFunc = now
+		if createdAt := events[idx].CreatedAt; !createdAt.IsZero() {
+			timeNowFunc = func() time.Time { return createdAt }
+		}
+		if err := b.events.Save(context.Background(), roomID, &events[idx]); err != nil {
+			t.Fatalf("save event %d: %v", idx, err)
+		}
+	}
+}
+
+// eventIDs returns the IDs of the events.
+func eventIDs(events []domain.Event) []int64 {
+	ids := make([]int64, len(events))
+	for idx, ev := range events {
+		ids[idx] = ev.ID
+	}
+	return ids
+}
+
+// pickIDs returns the IDs of the events at the indexes.
+func pickIDs(events []domain.Event, indexes ...int) []int64 {
+	ids := make([]int64, len(indexes))
+	for idx, i := range indexes {
+		ids[idx] = events[i].ID
+	}
+	return ids
+}
+
+func equalIDs(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for idx := range a {
+		if a[idx] != b[idx] {
+			return false
+		}
+	}
+	return true
+}
@@ -2,48 +2,320 @@ package repository
 
 import (
 	"context"
-	"sync"
+	"encoding/json"
+	"errors"
+	"fmt"
 
+	"github.com/erwin-lovecraft/pistol/internal/adapters/ormmodel"
 	"github.com/erwin-lovecraft/pistol/internal/core/domain"
 	"github.com/erwin-lovecraft/pistol/internal/core/ports"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-var _ ports.RoomRepository = (*InMemoryRoomRepository)(nil)
+var _ ports.RoomRepository = (*roomRepository)(nil)
 
-type InMemoryRoomRepository struct {
-	cache map[string]domain.Room
-	mu    sync.RWMutex
+type roomRepository struct {
+	dbPool  *pgxpool.Pool
+	queries *ormmodel.Queries
 }
 
-func NewInMemoryRoomRepository() *InMemoryRoomRepository {
-	return &InMemoryRoomRepository{
-		cache: make(map[string]domain.Room),
+func NewRoomRepository(dbPool *pgxpool.Pool) ports.RoomRepository {
+	return roomRepository{
+		dbPool:  dbPool,
+		queries: ormmodel.New(dbPool),
 	}
 }
 
-func (i *InMemoryRoomRepository) SaveRoom(ctx context.Context, room domain.Room) error {
-	i.mu.Lock()
-	defer i.mu.Unlock()
+func (repo roomRepository) SaveRoom(ctx context.Context, room *domain.Room) error {
+	return saveRoom(ctx, repo.queries, room)
+}
+
+func (repo roomRepository) CreateRoom(ctx context.Context, room *domain.Room, tokens []domain.RoomToken) error {
+	return inTx(ctx, repo.dbPool, func(queries *ormmodel.Queries) error {
+		if err := saveRoom(ctx, queries, room); err != nil {
+			return err
+		}
+		for idx := range tokens {
+			tokens[idx].RoomID = room.ID
+			if err := saveRoomToken(ctx, queries, &tokens[idx]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// saveRoom saves the room with the queries given, which may be bound to a transaction.
+func saveRoom(ctx context.Context, queries *ormmodel.Queries, room *domain.Room) error {
+	var pgRoomID pgtype.UUID
+	if err := pgRoomID.Scan(room.ID); err != nil {
+		return fmt.Errorf("scan room id: %w", err)
+	}
 
-	i.cache[room.ID] = room
+	model, err := queries.SaveRoom(ctx, ormmodel.SaveRoomParams{
+		ID:     pgRoomID,
+		Name:   room.Name,
+		Avatar: room.Avatar,
+	})
+	if err != nil {
+		return fmt.Errorf("save room: %w", err)
+	}
+	saved, err := toDomainRoom(model)
+	if err != nil {
+		return err
+	}
+	*room = saved
 	return nil
 }
 
-func (i *InMemoryRoomRepository) List(ctx context.Context, filter ports.RoomFilter) ([]domain.Room, error) {
-	i.mu.RLock()
-	defer i.mu.RUnlock()
+func (repo roomRepository) GetByID(ctx context.Context, id string) (domain.Room, error) {
+	var pgRoomID pgtype.UUID
+	if err := pgRoomID.Scan(id); err != nil {
+		// A malformed ID can't match any room
+		return domain.Room{}, domain.ErrRoomNotFound
+	}
+
+	model, err := repo.queries.GetRoom(ctx, pgRoomID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Room{}, domain.ErrRoomNotFound
+		}
+		return domain.Room{}, fmt.Errorf("get room: %w", err)
+	}
+	return toDomainRoom(model)
+}
+
+func (repo roomRepository) List(ctx context.Context, filter ports.RoomFilter) ([]domain.Room, error) {
+	models, err := repo.queries.ListRooms(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list rooms: %w", err)
+	}
 
-	var rooms []domain.Room
-	for _, room := range i.cache {
-		rooms = append(rooms, room)
+	rooms := make([]domain.Room, len(models))
+	for idx, model := range models {
+		room, err := toDomainRoom(model)
+		if err != nil {
+			return nil, err
+		}
+		rooms[idx] = room
 	}
 	return rooms, nil
 }
 
-func (i *InMemoryRoomRepository) DeleteByID(ctx context.Context, id string) error {
-	i.mu.Lock()
-	defer i.mu.Unlock()
+func (repo roomRepository) UpdateName(ctx context.Context, id string, name string) (domain.Room, error) {
+	var pgRoomID pgtype.UUID
+	if err := pgRoomID.Scan(id); err != nil {
+		return domain.Room{}, domain.ErrRoomNotFound
+	}
+
+	model, err := repo.queries.UpdateRoomName(ctx, ormmodel.UpdateRoomNameParams{
+		ID:   pgRoomID,
+		Name: name,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Room{}, domain.ErrRoomNotFound
+		}
+		return domain.Room{}, fmt.Errorf("update room name: %w", err)
+	}
+	return toDomainRoom(model)
+}
+
+func (repo roomRepository) UpdateResponse(ctx context.Context, id string, rule *domain.ResponseRule) (domain.Room, error) {
+	var pgRoomID pgtype.UUID
+	if err := pgRoomID.Scan(id); err != nil {
+		return domain.Room{}, domain.ErrRoomNotFound
+	}
+
+	var ruleBytes []byte
+	if rule != nil {
+		var err error
+		if ruleBytes, err = json.Marshal(rule); err != nil {
+			return domain.Room{}, fmt.Errorf("marshal response rule: %w", err)
+		}
+	}
+
+	model, err := repo.queries.UpdateRoomResponse(ctx, ormmodel.UpdateRoomResponseParams{
+		ID:       pgRoomID,
+		Response: ruleBytes,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Room{}, domain.ErrRoomNotFound
+		}
+		return domain.Room{}, fmt.Errorf("update room response: %w", err)
+	}
+	return toDomainRoom(model)
+}
+
+func (repo roomRepository) UpdateForwards(ctx context.Context, id string, rules []domain.ForwardRule) (domain.Room, error) {
+	var pgRoomID pgtype.UUID
+	if err := pgRoomID.Scan(id); err != nil {
+		return domain.Room{}, domain.ErrRoomNotFound
+	}
+
+	var rulesBytes []byte
+	if len(rules) > 0 {
+		var err error
+		if rulesBytes, err = json.Marshal(rules); err != nil {
+			return domain.Room{}, fmt.Errorf("marshal forward rules: %w", err)
+		}
+	}
+
+	model, err := repo.queries.UpdateRoomForwards(ctx, ormmodel.UpdateRoomForwardsParams{
+		ID:       pgRoomID,
+		Forwards: rulesBytes,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Room{}, domain.ErrRoomNotFound
+		}
+		return domain.Room{}, fmt.Errorf("update room forwards: %w", err)
+	}
+	return toDomainRoom(model)
+}
+
+func (repo roomRepository) UpdateSignature(ctx context.Context, id string, profile *domain.SignatureProfile) (domain.Room, error) {
+	var pgRoomID pgtype.UUID
+	if err := pgRoomID.Scan(id); err != nil {
+		return domain.Room{}, domain.ErrRoomNotFound
+	}
+
+	var profileBytes []byte
+	if profile != nil {
+		var err error
+		if profileBytes, err = json.Marshal(profile); err != nil {
+			return domain.Room{}, fmt.Errorf("marshal signature profile: %w", err)
+		}
+	}
+
+	model, err := repo.queries.UpdateRoomSignature(ctx, ormmodel.UpdateRoomSignatureParams{
+		ID:        pgRoomID,
+		Signature: profileBytes,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Room{}, domain.ErrRoomNotFound
+		}
+		return domain.Room{}, fmt.Errorf("update room signature: %w", err)
+	}
+	return toDomainRoom(model)
+}
+
+func (repo roomRepository) UpdateRetention(ctx context.Context, id string, policy *domain.RetentionPolicy) (domain.Room, error) {
+	var pgRoomID pgtype.UUID
+	if err := pgRoomID.Scan(id); err != nil {
+		return domain.Room{}, domain.ErrRoomNotFound
+	}
+
+	var policyBytes []byte
+	if policy != nil {
+		var err error
+		if policyBytes, err = json.Marshal(policy); err != nil {
+			return domain.Room{}, fmt.Errorf("marshal retention policy: %w", err)
+		}
+	}
+
+	model, err := repo.queries.UpdateRoomRetention(ctx, ormmodel.UpdateRoomRetentionParams{
+		ID:        pgRoomID,
+		Retention: policyBytes,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Room{}, domain.ErrRoomNotFound
+		}
+		return domain.Room{}, fmt.Errorf("update room retention: %w", err)
+	}
+	return toDomainRoom(model)
+}
+
+func (repo roomRepository) UpdateRedaction(ctx context.Context, id string, rules []domain.RedactionRule) (domain.Room, error) {
+	var pgRoomID pgtype.UUID
+	if err := pgRoomID.Scan(id); err != nil {
+		return domain.Room{}, domain.ErrRoomNotFound
+	}
+
+	var rulesBytes []byte
+	if len(rules) > 0 {
+		var err error
+		if rulesBytes, err = json.Marshal(rules); err != nil {
+			return domain.Room{}, fmt.Errorf("marshal redaction rules: %w", err)
+		}
+	}
+
+	model, err := repo.queries.UpdateRoomRedaction(ctx, ormmodel.UpdateRoomRedactionParams{
+		ID:        pgRoomID,
+		Redaction: rulesBytes,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Room{}, domain.ErrRoomNotFound
+		}
+		return domain.Room{}, fmt.Errorf("update room redaction: %w", err)
+	}
+	return toDomainRoom(model)
+}
 
-	delete(i.cache, id)
+func (repo roomRepository) DeleteByID(ctx context.Context, id string) error {
+	var pgRoomID pgtype.UUID
+	if err := pgRoomID.Scan(id); err != nil {
+		return domain.ErrRoomNotFound
+	}
+
+	affected, err := repo.queries.DeleteRoom(ctx, pgRoomID)
+	if err != nil {
+		return fmt.Errorf("delete room: %w", err)
+	}
+	if affected == 0 {
+		return domain.ErrRoomNotFound
+	}
 	return nil
 }
+
+func toDomainRoom(model ormmodel.Room) (domain.Room, error) {
+	var rule *domain.ResponseRule
+	if len(model.Response) > 0 {
+		if err := json.Unmarshal(model.Response, &rule); err != nil {
+			return domain.Room{}, fmt.Errorf("unmarshal response rule: %w", err)
+		}
+	}
+	var forwards []domain.ForwardRule
+	if len(model.Forwards) > 0 {
+		if err := json.Unmarshal(model.Forwards, &forwards); err != nil {
+			return domain.Room{}, fmt.Errorf("unmarshal forward rules: %w", err)
+		}
+	}
+	var signature *domain.SignatureProfile
+	if len(model.Signature) > 0 {
+		if err := json.Unmarshal(model.Signature, &signature); err != nil {
+			return domain.Room{}, fmt.Errorf("unmarshal signature profile: %w", err)
+		}
+	}
+	var retention *domain.RetentionPolicy
+	if len(model.Retention) > 0 {
+		if err := json.Unmarshal(model.Retention, &retention); err != nil {
+			return domain.Room{}, fmt.Errorf("unmarshal retention policy: %w", err)
+		}
+	}
+	var redaction []domain.RedactionRule
+	if len(model.Redaction) > 0 {
+		if err := json.Unmarshal(model.Redaction, &redaction); err != nil {
+			return domain.Room{}, fmt.Errorf("unmarshal redaction rules: %w", err)
+		}
+	}
+
+	return domain.Room{
+		ID:        model.ID.String(),
+		Name:      model.Name,
+		Avatar:    model.Avatar,
+		CreatedAt: model.CreatedAt.Time,
+		UpdatedAt: model.UpdatedAt.Time,
+		Response:  rule,
+		Forwards:  forwards,
+		Signature: signature,
+		Retention: retention,
+		Redaction: redaction,
+	}, nil
+}
@@ -0,0 +1,337 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"slices"
+
+	"github.com/erwin-lovecraft/pistol/internal/adapters/sqlitemodel"
+	"github.com/erwin-lovecraft/pistol/internal/core/domain"
+	"github.com/erwin-lovecraft/pistol/internal/core/ports"
+)
+
+const (
+	// sqliteListBatch is how many events are read at once when some criteria are matched in Go, or sizes summed
+	sqliteListBatch = 200
+)
+
+var _ ports.EventRepository = (*sqliteEventRepository)(nil)
+
+type sqliteEventRepository struct {
+	queries *sqlitemodel.Queries
+}
+
+func NewSQLiteEventRepository(db *sql.DB) ports.EventRepository {
+	return sqliteEventRepository{
+		queries: sqlitemodel.New(db),
+	}
+}
+
+func (repo sqliteEventRepository) Save(ctx context.Context, roomID string, ev *domain.Event) error {
+	if ev.ID == 0 {
+		id, err := sf.NextID()
+		if err != nil {
+			return fmt.Errorf("generate id: %w", err)
+		}
+		ev.ID = id
+	}
+
+	params := sqlitemodel.SaveEventParams{
+		ID:            ev.ID,
+		RoomID:        roomID,
+		Method:        ev.Method,
+		Body:          ev.Body,
+		BodyBase64:    ev.BodyBase64,
+		ContentType:   ev.ContentType,
+		ContentLength: ev.ContentLength,
+		Path:          ev.Path,
+		RemoteAddr:    ev.RemoteAddr,
+		Proto:         ev.Proto,
+		Host:          ev.Host,
+		DurationUs:    ev.DurationUS,
+		// Imported events keep the time they were captured at
+		CreatedAt: ev.CreatedAt.UTC(),
+	}
+	if ev.CreatedAt.IsZero() {
+		params.CreatedAt = timeNowFunc().UTC()
+	}
+	if ev.Signature != nil {
+		params.SignatureVerdict = ev.Signature.Verdict
+		params.SignatureReason = ev.Signature.Reason
+	}
+
+	var err error
+	if params.Header, err = marshalNullString(ev.Header, ev.Header != nil); err != nil {
+		return fmt.Errorf("marshal header: %w", err)
+	}
+	if params.QueryParams, err = marshalNullString(ev.QueryParams, ev.QueryParams != nil); err != nil {
+		return fmt.Errorf("marshal query param: %w", err)
+	}
+	if params.Response, err = marshalNullString(ev.Response, ev.Response != nil); err != nil {
+		return fmt.Errorf("marshal response: %w", err)
+	}
+	if params.Tls, err = marshalNullString(ev.TLS, ev.TLS != nil); err != nil {
+		return fmt.Errorf("marshal tls: %w", err)
+	}
+	if params.Redacted, err = marshalNullString(ev.Redacted, len(ev.Redacted) > 0); err != nil {
+		return fmt.Errorf("marshal redacted fields: %w", err)
+	}
+
+	createdAt, err := repo.queries.SaveEvent(ctx, params)
+	if err != nil {
+		return fmt.Errorf("save event: %w", err)
+	}
+	ev.CreatedAt = createdAt
+	return nil
+}
+
+func (repo sqliteEventRepository) Get(ctx context.Context, roomID string, id int64) (domain.Event, error) {
+	model, err := repo.queries.GetEvent(ctx, sqlitemodel.GetEventParams{
+		RoomID: roomID,
+		ID:     id,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Event{}, domain.ErrEventNotFound
+		}
+		return domain.Event{}, fmt.Errorf("get event: %w", err)
+	}
+	return sqliteToDomainEvent(model)
+}
+
+func (repo sqliteEventRepository) List(ctx context.Context, roomID string, filter ports.EventFilter, cursor ports.EventCursor) ([]domain.Event, bool, error) {
+	// Fetch one more event than asked to tell whether there's another page
+	want := cursor.Limit + 1
+	batch := want
+	if len(filter.Headers) > 0 || len(filter.QueryParams) > 0 || filter.BodyPath != nil {
+		// These are matched in Go, rows are read in batches until enough of them match
+		batch = max(want, sqliteListBatch)
+	}
+
+	var events []domain.Event
+	before, after := cursor.Before, cursor.After
+	for len(events) < want {
+		models, err := repo.list(ctx, roomID, filter, before, after, batch)
+		if err != nil {
+			return nil, false, fmt.Errorf("list events: %w", err)
+		}
+
+		for _, model := range models {
+			if cursor.After > 0 {
+				after = model.ID
+			} else {
+				before = model.ID
+			}
+
+			ev, err := sqliteToDomainEvent(model)
+			if err != nil {
+				log.Printf("convert event: %v", err)
+				continue
+			}
+			if filter.Match(ev) {
+				events = append(events, ev)
+				if len(events) == want {
+					break
+				}
+			}
+		}
+		if len(models) < batch {
+			break
+		}
+	}
+
+	hasMore := len(events) > cursor.Limit
+	if hasMore {
+		events = events[:cursor.Limit]
+	}
+	if cursor.After > 0 {
+		// Newer events are fetched oldest first, to stay next to the cursor
+		slices.Reverse(events)
+	}
+	return events, hasMore, nil
+}
+
+// list reads a batch of events on the cursor's side, narrowed by the criteria SQLite can match.
+func (repo sqliteEventRepository) list(ctx context.Context, roomID string, filter ports.EventFilter, before, after int64, limit int) ([]sqlitemodel.Event, error) {
+	verdict := sql.NullString{String: filter.SignatureVerdict, Valid: filter.SignatureVerdict != ""}
+	method := sql.NullString{String: filter.Method, Valid: filter.Method != ""}
+	from := sql.NullTime{Time: filter.From.UTC(), Valid: !filter.From.IsZero()}
+	to := sql.NullTime{Time: filter.To.UTC(), Valid: !filter.To.IsZero()}
+	pathSuffix := sql.NullString{String: filter.PathSuffix, Valid: filter.PathSuffix != ""}
+	// A nil slice would be bound as an empty blob, which every body contains
+	var bodyContains interface{}
+	if filter.BodyContains != nil {
+		bodyContains = filter.BodyContains
+	}
+
+	if after > 0 {
+		return repo.queries.ListEventsNewer(ctx, sqlitemodel.ListEventsNewerParams{
+			RoomID:           roomID,
+			After:            after,
+			SignatureVerdict: verdict,
+			Method:           method,
+			CreatedFrom:      from,
+			CreatedTo:        to,
+			BodyContains:     bodyContains,
+			PathSuffix:       pathSuffix,
+			Limit:            int64(limit),
+		})
+	}
+	return repo.queries.ListEvents(ctx, sqlitemodel.ListEventsParams{
+		RoomID:           roomID,
+		Before:           sql.NullInt64{Int64: before, Valid: before > 0},
+		SignatureVerdict: verdict,
+		Method:           method,
+		CreatedFrom:      from,
+		CreatedTo:        to,
+		BodyContains:     bodyContains,
+		PathSuffix:       pathSuffix,
+		Limit:            int64(limit),
+	})
+}
+
+func (repo sqliteEventRepository) ListAfter(ctx context.Context, roomID string, afterID int64, limit int) ([]domain.Event, error) {
+	models, err := repo.queries.ListEventsAfter(ctx, sqlitemodel.ListEventsAfterParams{
+		RoomID: roomID,
+		ID:     afterID,
+		Limit:  int64(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list events after: %w", err)
+	}
+
+	events := make([]domain.Event, 0, len(models))
+	for _, model := range models {
+		ev, err := sqliteToDomainEvent(model)
+		if err != nil {
+			return nil, fmt.Errorf("convert event: %w", err)
+		}
+		events = append(events, ev)
+	}
+
+	return events, nil
+}
+
+// getEventBytesCutoff finds the newest event past the room's size limit, summing sizes newest first a batch at a
+// time as sqlc can't read SQLite's windowed subqueries.
+func (repo sqliteEventRepository) getEventBytesCutoff(ctx context.Context, roomID string, maxBytes int64) (int64, error) {
+	var total int64
+	beforeID := int64(math.MaxInt64)
+	for {
+		rows, err := repo.queries.ListEventSizes(ctx, sqlitemodel.ListEventSizesParams{
+			RoomID:   roomID,
+			BeforeID: beforeID,
+			Limit:    sqliteListBatch,
+		})
+		if err != nil {
+			return 0, err
+		}
+		for _, row := range rows {
+			total += row.ContentLength
+			if total > maxBytes {
+				return row.ID, nil
+			}
+			beforeID = row.ID
+		}
+		if len(rows) < sqliteListBatch {
+			return 0, sql.ErrNoRows
+		}
+	}
+}
+
+func (repo sqliteEventRepository) Prune(ctx context.Context, roomID string, policy domain.RetentionPolicy, batchSize int) (ports.PruneResult, error) {
+	// Count and size limits resolve to the newest event to delete, everything up to it goes
+	var maxID sql.NullInt64
+	if policy.MaxEvents > 0 {
+		id, err := repo.queries.GetEventCountCutoff(ctx, sqlitemodel.GetEventCountCutoffParams{
+			RoomID:    roomID,
+			MaxEvents: policy.MaxEvents,
+		})
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return ports.PruneResult{}, fmt.Errorf("get event count cutoff: %w", err)
+		}
+		if err == nil {
+			maxID = sql.NullInt64{Int64: id, Valid: true}
+		}
+	}
+	if policy.MaxBytes > 0 {
+		id, err := repo.getEventBytesCutoff(ctx, roomID, policy.MaxBytes)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return ports.PruneResult{}, fmt.Errorf("get event bytes cutoff: %w", err)
+		}
+		if err == nil && id > maxID.Int64 {
+			maxID = sql.NullInt64{Int64: id, Valid: true}
+		}
+	}
+
+	var createdBefore sql.NullTime
+	if policy.MaxAge() > 0 {
+		createdBefore = sql.NullTime{Time: timeNowFunc().Add(-policy.MaxAge()).UTC(), Valid: true}
+	}
+	if !maxID.Valid && !createdBefore.Valid {
+		return ports.PruneResult{}, nil
+	}
+
+	var result ports.PruneResult
+	for {
+		sizes, err := repo.queries.PruneEvents(ctx, sqlitemodel.PruneEventsParams{
+			RoomID:        roomID,
+			MaxID:         maxID,
+			CreatedBefore: createdBefore,
+			Limit:         int64(batchSize),
+		})
+		if err != nil {
+			return result, fmt.Errorf("prune events: %w", err)
+		}
+		for _, size := range sizes {
+			result.Events++
+			result.Bytes += size
+		}
+		if len(sizes) < batchSize {
+			return result, nil
+		}
+	}
+}
+
+func sqliteToDomainEvent(model sqlitemodel.Event) (domain.Event, error) {
+	ev := domain.Event{
+		ID:            model.ID,
+		Method:        model.Method,
+		Body:          model.Body,
+		BodyBase64:    model.BodyBase64,
+		ContentType:   model.ContentType,
+		ContentLength: model.ContentLength,
+		Path:          model.Path,
+		RemoteAddr:    model.RemoteAddr,
+		Proto:         model.Proto,
+		Host:          model.Host,
+		DurationUS:    model.DurationUs,
+		CreatedAt:     model.CreatedAt,
+	}
+	if err := unmarshalNullString(model.Header, &ev.Header); err != nil {
+		return domain.Event{}, fmt.Errorf("unmarshal header: %w", err)
+	}
+	if err := unmarshalNullString(model.QueryParams, &ev.QueryParams); err != nil {
+		return domain.Event{}, fmt.Errorf("unmarshal query params: %w", err)
+	}
+	if err := unmarshalNullString(model.Response, &ev.Response); err != nil {
+		return domain.Event{}, fmt.Errorf("unmarshal response: %w", err)
+	}
+	if err := unmarshalNullString(model.Tls, &ev.TLS); err != nil {
+		return domain.Event{}, fmt.Errorf("unmarshal tls: %w", err)
+	}
+	if err := unmarshalNullString(model.Redacted, &ev.Redacted); err != nil {
+		return domain.Event{}, fmt.Errorf("unmarshal redacted fields: %w", err)
+	}
+	if model.SignatureVerdict != "" {
+		ev.Signature = &domain.SignatureCheck{
+			Verdict: model.SignatureVerdict,
+			Reason:  model.SignatureReason,
+		}
+	}
+	return ev, nil
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/erwin-lovecraft/pistol/internal/adapters/sqlitemodel"
+	"github.com/erwin-lovecraft/pistol/internal/core/domain"
+	"github.com/erwin-lovecraft/pistol/internal/core/ports"
+)
+
+var _ ports.ReplayRepository = (*sqliteReplayRepository)(nil)
+
+type sqliteReplayRepository struct {
+	queries *sqlitemodel.Queries
+}
+
+func NewSQLiteReplayRepository(db *sql.DB) ports.ReplayRepository {
+	return sqliteReplayRepository{
+		queries: sqlitemodel.New(db),
+	}
+}
+
+func (repo sqliteReplayRepository) Save(ctx context.Context, attempt *domain.ReplayAttempt) error {
+	if attempt.ID == 0 {
+		id, err := sf.NextID()
+		if err != nil {
+			return fmt.Errorf("generate id: %w", err)
+		}
+		attempt.ID = id
+	}
+
+	requestHeader, err := marshalNullString(attempt.RequestHeader, attempt.RequestHeader != nil)
+	if err != nil {
+		return fmt.Errorf("marshal request header: %w", err)
+	}
+	responseHeader, err := marshalNullString(attempt.ResponseHeader, attempt.ResponseHeader != nil)
+	if err != nil {
+		return fmt.Errorf("marshal response header: %w", err)
+	}
+
+	createdAt := timeNowFunc().UTC()
+	if err := repo.queries.SaveReplayAttempt(ctx, sqlitemodel.SaveReplayAttemptParams{
+		ID:                 attempt.ID,
+		EventID:            attempt.EventID,
+		TargetUrl:          attempt.TargetURL,
+		RequestHeader:      requestHeader,
+		StatusCode:         int64(attempt.StatusCode),
+		ResponseHeader:     responseHeader,
+		ResponseBody:       attempt.ResponseBody,
+		ResponseBodyBase64: attempt.ResponseBodyBase64,
+		LatencyMs:          attempt.LatencyMS,
+		Error:              attempt.Error,
+		Source:             attempt.Source,
+		Attempt:            int64(attempt.Attempt),
+		CreatedAt:          createdAt,
+	}); err != nil {
+		return fmt.Errorf("save replay attempt: %w", err)
+	}
+	attempt.CreatedAt = createdAt
+	return nil
+}
+
+func (repo sqliteReplayRepository) ListByEvent(ctx context.Context, eventID int64, limit int) ([]domain.ReplayAttempt, error) {
+	models, err := repo.queries.ListReplayAttempts(ctx, sqlitemodel.ListReplayAttemptsParams{
+		EventID: eventID,
+		Limit:   int64(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list replay attempts: %w", err)
+	}
+
+	attempts := make([]domain.ReplayAttempt, len(models))
+	for idx, model := range models {
+		attempt := domain.ReplayAttempt{
+			ID:                 model.ID,
+			EventID:            model.EventID,
+			TargetURL:          model.TargetUrl,
+			StatusCode:         int(model.StatusCode),
+			ResponseBody:       model.ResponseBody,
+			ResponseBodyBase64: model.ResponseBodyBase64,
+			LatencyMS:          model.LatencyMs,
+			Error:              model.Error,
+			Source:             model.Source,
+			Attempt:            int(model.Attempt),
+			CreatedAt:          model.CreatedAt,
+		}
+		if err := unmarshalNullString(model.RequestHeader, &attempt.RequestHeader); err != nil {
+			return nil, fmt.Errorf("unmarshal request header: %w", err)
+		}
+		if err := unmarshalNullString(model.ResponseHeader, &attempt.ResponseHeader); err != nil {
+			return nil, fmt.Errorf("unmarshal response header: %w", err)
+		}
+		attempts[idx] = attempt
+	}
+	return attempts, nil
+}
@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/erwin-lovecraft/pistol/internal/adapters/sqlitemodel"
+	"github.com/erwin-lovecraft/pistol/internal/core/domain"
+	"github.com/erwin-lovecraft/pistol/internal/core/ports"
+)
+
+var _ ports.RoomRepository = (*sqliteRoomRepository)(nil)
+
+type sqliteRoomRepository struct {
+	db      *sql.DB
+	queries *sqlitemodel.Queries
+}
+
+func NewSQLiteRoomRepository(db *sql.DB) ports.RoomRepository {
+	return sqliteRoomRepository{
+		db:      db,
+		queries: sqlitemodel.New(db),
+	}
+}
+
+func (repo sqliteRoomRepository) SaveRoom(ctx context.Context, room *domain.Room) error {
+	return sqliteSaveRoom(ctx, repo.queries, room)
+}
+
+func (repo sqliteRoomRepository) CreateRoom(ctx context.Context, room *domain.Room, tokens []domain.RoomToken) error {
+	return inSQLiteTx(ctx, repo.db, func(queries *sqlitemodel.Queries) error {
+		if err := sqliteSaveRoom(ctx, queries, room); err != nil {
+			return err
+		}
+		for idx := range tokens {
+			tokens[idx].RoomID = room.ID
+			if err := sqliteSaveRoomToken(ctx, queries, &tokens[idx]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// sqliteSaveRoom saves the room with the queries given, which may be bound to a transaction.
+func sqliteSaveRoom(ctx context.Context, queries *sqlitemodel.Queries, room *domain.Room) error {
+	model, err := queries.SaveRoom(ctx, sqlitemodel.SaveRoomParams{
+		ID:     room.ID,
+		Name:   room.Name,
+		Avatar: room.Avatar,
+		Now:    timeNowFunc().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("save room: %w", err)
+	}
+	saved, err := sqliteToDomainRoom(model)
+	if err != nil {
+		return err
+	}
+	*room = saved
+	return nil
+}
+
+func (repo sqliteRoomRepository) GetByID(ctx context.Context, id string) (domain.Room, error) {
+	model, err := repo.queries.GetRoom(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Room{}, domain.ErrRoomNotFound
+		}
+		return domain.Room{}, fmt.Errorf("get room: %w", err)
+	}
+	return sqliteToDomainRoom(model)
+}
+
+func (repo sqliteRoomRepository) List(ctx context.Context, filter ports.RoomFilter) ([]domain.Room, error) {
+	models, err := repo.queries.ListRooms(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list rooms: %w", err)
+	}
+
+	rooms := make([]domain.Room, len(models))
+	for idx, model := range models {
+		room, err := sqliteToDomainRoom(model)
+		if err != nil {
+			return nil, err
+		}
+		rooms[idx] = room
+	}
+	return rooms, nil
+}
+
+func (repo sqliteRoomRepository) UpdateName(ctx context.Context, id string, name string) (domain.Room, error) {
+	model, err := repo.queries.UpdateRoomName(ctx, sqlitemodel.UpdateRoomNameParams{
+		Name:      name,
+		UpdatedAt: timeNowFunc().UTC(),
+		ID:        id,
+	})
+	return repo.updated(model, err, "name")
+}
+
+func (repo sqliteRoomRepository) UpdateResponse(ctx context.Context, id string, rule *domain.ResponseRule) (domain.Room, error) {
+	ruleText, err := marshalNullString(rule, rule != nil)
+	if err != nil {
+		return domain.Room{}, fmt.Errorf("marshal response rule: %w", err)
+	}
+
+	model, err := repo.queries.UpdateRoomResponse(ctx, sqlitemodel.UpdateRoomResponseParams{
+		Response:  ruleText,
+		UpdatedAt: timeNowFunc().UTC(),
+		ID:        id,
+	})
+	return repo.updated(model, err, "response")
+}
+
+func (repo sqliteRoomRepository) UpdateForwards(ctx context.Context, id string, rules []domain.ForwardRule) (domain.Room, error) {
+	rulesText, err := marshalNullString(rules, len(rules) > 0)
+	if err != nil {
+		return domain.Room{}, fmt.Errorf("marshal forward rules: %w", err)
+	}
+
+	model, err := repo.queries.UpdateRoomForwards(ctx, sqlitemodel.UpdateRoomForwardsParams{
+		Forwards:  rulesText,
+		UpdatedAt: timeNowFunc().UTC(),
+		ID:        id,
+	})
+	return repo.updated(model, err, "forwards")
+}
+
+func (repo sqliteRoomRepository) UpdateSignature(ctx context.Context, id string, profile *domain.SignatureProfile) (domain.Room, error) {
+	profileText, err := marshalNullString(profile, profile != nil)
+	if err != nil {
+		return domain.Room{}, fmt.Errorf("marshal signature profile: %w", err)
+	}
+
+	model, err := repo.queries.UpdateRoomSignature(ctx, sqlitemodel.UpdateRoomSignatureParams{
+		Signature: profileText,
+		UpdatedAt: timeNowFunc().UTC(),
+		ID:        id,
+	})
+	return repo.updated(model, err, "signature")
+}
+
+func (repo sqliteRoomRepository) UpdateRetention(ctx context.Context, id string, policy *domain.RetentionPolicy) (domain.Room, error) {
+	policyText, err := marshalNullString(policy, policy != nil)
+	if err != nil {
+		return domain.Room{}, fmt.Errorf("marshal retention policy: %w", err)
+	}
+
+	model, err := repo.queries.UpdateRoomRetention(ctx, sqlitemodel.UpdateRoomRetentionParams{
+		Retention: policyText,
+		UpdatedAt: timeNowFunc().UTC(),
+		ID:        id,
+	})
+	return repo.updated(model, err, "retention")
+}
+
+func (repo sqliteRoomRepository) UpdateRedaction(ctx context.Context, id string, rules []domain.RedactionRule) (domain.Room, error) {
+	rulesText, err := marshalNullString(rules, len(rules) > 0)
+	if err != nil {
+		return domain.Room{}, fmt.Errorf("marshal redaction rules: %w", err)
+	}
+
+	model, err := repo.queries.UpdateRoomRedaction(ctx, sqlitemodel.UpdateRoomRedactionParams{
+		Redaction: rulesText,
+		UpdatedAt: timeNowFunc().UTC(),
+		ID:        id,
+	})
+	return repo.updated(model, err, "redaction")
+}
+
+func (repo sqliteRoomRepository) DeleteByID(ctx context.Context, id string) error {
+	affected, err := repo.queries.DeleteRoom(ctx, id)
+	if err != nil {
+		return fmt.Errorf("delete room: %w", err)
+	}
+	if affected == 0 {
+		return domain.ErrRoomNotFound
+	}
+	return nil
+}
+
+// updated converts the room returned by an update of its field.
+func (repo sqliteRoomRepository) updated(model sqlitemodel.Room, err error, field string) (domain.Room, error) {
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Room{}, domain.ErrRoomNotFound
+		}
+		return domain.Room{}, fmt.Errorf("update room %s: %w", field, err)
+	}
+	return sqliteToDomainRoom(model)
+}
+
+func sqliteToDomainRoom(model sqlitemodel.Room) (domain.Room, error) {
+	room := domain.Room{
+		ID:        model.ID,
+		Name:      model.Name,
+		Avatar:    model.Avatar,
+		CreatedAt: model.CreatedAt,
+		UpdatedAt: model.UpdatedAt,
+	}
+	if err := unmarshalNullString(model.Response, &room.Response); err != nil {
+		return domain.Room{}, fmt.Errorf("unmarshal response rule: %w", err)
+	}
+	if err := unmarshalNullString(model.Forwards, &room.Forwards); err != nil {
+		return domain.Room{}, fmt.Errorf("unmarshal forward rules: %w", err)
+	}
+	if err := unmarshalNullString(model.Signature, &room.Signature); err != nil {
+		return domain.Room{}, fmt.Errorf("unmarshal signature profile: %w", err)
+	}
+	if err := unmarshalNullString(model.Retention, &room.Retention); err != nil {
+		return domain.Room{}, fmt.Errorf("unmarshal retention policy: %w", err)
+	}
+	if err := unmarshalNullString(model.Redaction, &room.Redaction); err != nil {
+		return domain.Room{}, fmt.Errorf("unmarshal redaction rules: %w", err)
+	}
+	return room, nil
+}
+
+// marshalNullString encodes v as a JSON column, NULL unless set.
+func marshalNullString(v any, set bool) (sql.NullString, error) {
+	if !set {
+		return sql.NullString{}, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(b), Valid: true}, nil
+}
+
+// unmarshalNullString decodes a JSON column into v, leaving it untouched when NULL.
+func unmarshalNullString(s sql.NullString, v any) error {
+	if !s.Valid || s.String == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(s.String), v)
+}
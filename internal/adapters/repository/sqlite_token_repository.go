@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/erwin-lovecraft/pistol/internal/adapters/sqlitemodel"
+	"github.com/erwin-lovecraft/pistol/internal/core/domain"
+	"github.com/erwin-lovecraft/pistol/internal/core/ports"
+)
+
+var _ ports.TokenRepository = (*sqliteTokenRepository)(nil)
+
+type sqliteTokenRepository struct {
+	db      *sql.DB
+	queries *sqlitemodel.Queries
+}
+
+func NewSQLiteTokenRepository(db *sql.DB) ports.TokenRepository {
+	return sqliteTokenRepository{
+		db:      db,
+		queries: sqlitemodel.New(db),
+	}
+}
+
+func (repo sqliteTokenRepository) Save(ctx context.Context, token *domain.RoomToken) error {
+	return sqliteSaveRoomToken(ctx, repo.queries, token)
+}
+
+// sqliteSaveRoomToken saves the token with the queries given, which may be bound to a transaction.
+func sqliteSaveRoomToken(ctx context.Context, queries *sqlitemodel.Queries, token *domain.RoomToken) error {
+	if token.ID == 0 {
+		id, err := sf.NextID()
+		if err != nil {
+			return fmt.Errorf("generate id: %w", err)
+		}
+		token.ID = id
+	}
+
+	var expiresAt sql.NullTime
+	if token.ExpiresAt != nil {
+		expiresAt = sql.NullTime{Time: token.ExpiresAt.UTC(), Valid: true}
+	}
+
+	model, err := queries.SaveRoomToken(ctx, sqlitemodel.SaveRoomTokenParams{
+		ID:        token.ID,
+		RoomID:    token.RoomID,
+		Name:      token.Name,
+		Scope:     token.Scope,
+		Prefix:    token.Prefix,
+		TokenHash: token.Hash,
+		ExpiresAt: expiresAt,
+		CreatedAt: timeNowFunc().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("save room token: %w", err)
+	}
+	*token = sqliteToDomainRoomToken(model)
+	return nil
+}
+
+func (repo sqliteTokenRepository) Get(ctx context.Context, roomID string, id int64) (domain.RoomToken, error) {
+	model, err := repo.queries.GetRoomToken(ctx, sqlitemodel.GetRoomTokenParams{
+		RoomID: roomID,
+		ID:     id,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.RoomToken{}, domain.ErrTokenNotFound
+		}
+		return domain.RoomToken{}, fmt.Errorf("get room token: %w", err)
+	}
+	return sqliteToDomainRoomToken(model), nil
+}
+
+func (repo sqliteTokenRepository) List(ctx context.Context, roomID string) ([]domain.RoomToken, error) {
+	models, err := repo.queries.ListRoomTokens(ctx, roomID)
+	if err != nil {
+		return nil, fmt.Errorf("list room tokens: %w", err)
+	}
+	return sqliteToDomainRoomTokens(models), nil
+}
+
+func (repo sqliteTokenRepository) ListActive(ctx context.Context, roomID string, scope string) ([]domain.RoomToken, error) {
+	models, err := repo.queries.ListActiveRoomTokens(ctx, sqlitemodel.ListActiveRoomTokensParams{
+		RoomID: roomID,
+		Scope:  scope,
+		Now:    sql.NullTime{Time: timeNowFunc().UTC(), Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list active room tokens: %w", err)
+	}
+	return sqliteToDomainRoomTokens(models), nil
+}
+
+func (repo sqliteTokenRepository) Revoke(ctx context.Context, roomID string, id int64) (domain.RoomToken, error) {
+	model, err := repo.queries.RevokeRoomToken(ctx, sqlitemodel.RevokeRoomTokenParams{
+		Now:    sql.NullTime{Time: timeNowFunc().UTC(), Valid: true},
+		RoomID: roomID,
+		ID:     id,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.RoomToken{}, domain.ErrTokenNotFound
+		}
+		return domain.RoomToken{}, fmt.Errorf("revoke room token: %w", err)
+	}
+	return sqliteToDomainRoomToken(model), nil
+}
+
+func (repo sqliteTokenRepository) Rotate(ctx context.Context, roomID string, id int64, next *domain.RoomToken) error {
+	return inSQLiteTx(ctx, repo.db, func(queries *sqlitemodel.Queries) error {
+		revoked, err := queries.RevokeRoomToken(ctx, sqlitemodel.RevokeRoomTokenParams{
+			Now:    sql.NullTime{Time: timeNowFunc().UTC(), Valid: true},
+			RoomID: roomID,
+			ID:     id,
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return domain.ErrTokenNotFound
+			}
+			return fmt.Errorf("revoke room token: %w", err)
+		}
+
+		token := sqliteToDomainRoomToken(revoked)
+		next.RoomID = token.RoomID
+		next.Name = token.Name
+		next.Scope = token.Scope
+		next.ExpiresAt = token.ExpiresAt
+		return sqliteSaveRoomToken(ctx, queries, next)
+	})
+}
+
+func sqliteToDomainRoomTokens(models []sqlitemodel.RoomToken) []domain.RoomToken {
+	tokens := make([]domain.RoomToken, len(models))
+	for idx, model := range models {
+		tokens[idx] = sqliteToDomainRoomToken(model)
+	}
+	return tokens
+}
+
+func sqliteToDomainRoomToken(model sqlitemodel.RoomToken) domain.RoomToken {
+	token := domain.RoomToken{
+		ID:        model.ID,
+		RoomID:    model.RoomID,
+		Name:      model.Name,
+		Scope:     model.Scope,
+		Prefix:    model.Prefix,
+		Hash:      model.TokenHash,
+		CreatedAt: model.CreatedAt,
+	}
+	if model.ExpiresAt.Valid {
+		token.ExpiresAt = &model.ExpiresAt.Time
+	}
+	if model.RevokedAt.Valid {
+		token.RevokedAt = &model.RevokedAt.Time
+	}
+	return token
+}
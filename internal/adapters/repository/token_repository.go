@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/erwin-lovecraft/pistol/internal/adapters/ormmodel"
+	"github.com/erwin-lovecraft/pistol/internal/core/domain"
+	"github.com/erwin-lovecraft/pistol/internal/core/ports"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var _ ports.TokenRepository = (*tokenRepository)(nil)
+
+type tokenRepository struct {
+	dbPool  *pgxpool.Pool
+	queries *ormmodel.Queries
+}
+
+func NewTokenRepository(dbPool *pgxpool.Pool) ports.TokenRepository {
+	return tokenRepository{
+		dbPool:  dbPool,
+		queries: ormmodel.New(dbPool),
+	}
+}
+
+func (repo tokenRepository) Save(ctx context.Context, token *domain.RoomToken) error {
+	return saveRoomToken(ctx, repo.queries, token)
+}
+
+// saveRoomToken saves the token with the queries given, which may be bound to a transaction.
+func saveRoomToken(ctx context.Context, queries *ormmodel.Queries, token *domain.RoomToken) error {
+	if token.ID == 0 {
+		id, err := sf.NextID()
+		if err != nil {
+			return fmt.Errorf("generate id: %w", err)
+		}
+		token.ID = id
+	}
+
+	var pgRoomID pgtype.UUID
+	if err := pgRoomID.Scan(token.RoomID); err != nil {
+		return fmt.Errorf("scan room id: %w", err)
+	}
+
+	var expiresAt pgtype.Timestamptz
+	if token.ExpiresAt != nil {
+		expiresAt = pgtype.Timestamptz{Time: *token.ExpiresAt, Valid: true}
+	}
+
+	model, err := queries.SaveRoomToken(ctx, ormmodel.SaveRoomTokenParams{
+		ID:        token.ID,
+		RoomID:    pgRoomID,
+		Name:      token.Name,
+		Scope:     token.Scope,
+		Prefix:    token.Prefix,
+		TokenHash: token.Hash,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return fmt.Errorf("save room token: %w", err)
+	}
+	*token = toDomainRoomToken(model)
+	return nil
+}
+
+func (repo tokenRepository) Get(ctx context.Context, roomID string, id int64) (domain.RoomToken, error) {
+	var pgRoomID pgtype.UUID
+	if err := pgRoomID.Scan(roomID); err != nil {
+		return domain.RoomToken{}, domain.ErrTokenNotFound
+	}
+
+	model, err := repo.queries.GetRoomToken(ctx, ormmodel.GetRoomTokenParams{
+		RoomID: pgRoomID,
+		ID:     id,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.RoomToken{}, domain.ErrTokenNotFound
+		}
+		return domain.RoomToken{}, fmt.Errorf("get room token: %w", err)
+	}
+	return toDomainRoomToken(model), nil
+}
+
+func (repo tokenRepository) List(ctx context.Context, roomID string) ([]domain.RoomToken, error) {
+	var pgRoomID pgtype.UUID
+	if err := pgRoomID.Scan(roomID); err != nil {
+		return nil, nil
+	}
+
+	models, err := repo.queries.ListRoomTokens(ctx, pgRoomID)
+	if err != nil {
+		return nil, fmt.Errorf("list room tokens: %w", err)
+	}
+	return toDomainRoomTokens(models), nil
+}
+
+func (repo tokenRepository) ListActive(ctx context.Context, roomID string, scope string) ([]domain.RoomToken, error) {
+	var pgRoomID pgtype.UUID
+	if err := pgRoomID.Scan(roomID); err != nil {
+		return nil, nil
+	}
+
+	models, err := repo.queries.ListActiveRoomTokens(ctx, ormmodel.ListActiveRoomTokensParams{
+		RoomID: pgRoomID,
+		Scope:  scope,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list active room tokens: %w", err)
+	}
+	return toDomainRoomTokens(models), nil
+}
+
+func (repo tokenRepository) Revoke(ctx context.Context, roomID string, id int64) (domain.RoomToken, error) {
+	var pgRoomID pgtype.UUID
+	if err := pgRoomID.Scan(roomID); err != nil {
+		return domain.RoomToken{}, domain.ErrTokenNotFound
+	}
+
+	model, err := repo.queries.RevokeRoomToken(ctx, ormmodel.RevokeRoomTokenParams{
+		RoomID: pgRoomID,
+		ID:     id,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.RoomToken{}, domain.ErrTokenNotFound
+		}
+		return domain.RoomToken{}, fmt.Errorf("revoke room token: %w", err)
+	}
+	return toDomainRoomToken(model), nil
+}
+
+func (repo tokenRepository) Rotate(ctx context.Context, roomID string, id int64, next *domain.RoomToken) error {
+	var pgRoomID pgtype.UUID
+	if err := pgRoomID.Scan(roomID); err != nil {
+		return domain.ErrTokenNotFound
+	}
+
+	return inTx(ctx, repo.dbPool, func(queries *ormmodel.Queries) error {
+		revoked, err := queries.RevokeRoomToken(ctx, ormmodel.RevokeRoomTokenParams{
+			RoomID: pgRoomID,
+			ID:     id,
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return domain.ErrTokenNotFound
+			}
+			return fmt.Errorf("revoke room token: %w", err)
+		}
+
+		token := toDomainRoomToken(revoked)
+		next.RoomID = token.RoomID
+		next.Name = token.Name
+		next.Scope = token.Scope
+		next.ExpiresAt = token.ExpiresAt
+		return saveRoomToken(ctx, queries, next)
+	})
+}
+
+func toDomainRoomTokens(models []ormmodel.RoomToken) []domain.RoomToken {
+	tokens := make([]domain.RoomToken, len(models))
+	for idx, model := range models {
+		tokens[idx] = toDomainRoomToken(model)
+	}
+	return tokens
+}
+
+func toDomainRoomToken(model ormmodel.RoomToken) domain.RoomToken {
+	token := domain.RoomToken{
+		ID:        model.ID,
+		RoomID:    model.RoomID.String(),
+		Name:      model.Name,
+		Scope:     model.Scope,
+		Prefix:    model.Prefix,
+		Hash:      model.TokenHash,
+		CreatedAt: model.CreatedAt.Time,
+	}
+	if model.ExpiresAt.Valid {
+		token.ExpiresAt = &model.ExpiresAt.Time
+	}
+	if model.RevokedAt.Valid {
+		token.RevokedAt = &model.RevokedAt.Time
+	}
+	return token
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/erwin-lovecraft/pistol/internal/core/domain"
+	"github.com/google/uuid"
+)
+
+func TestRoomRepositoryCreateRoom(t *testing.T) {
+	eachBackend(t, func(t *testing.T, b backend) {
+		ctx := context.Background()
+		room := domain.Room{ID: uuid.NewString(), Name: "room"}
+		tokens := []domain.RoomToken{
+			{Name: "default", Scope: domain.TokenScopeIngest, Prefix: "pst_ingest", Hash: []byte("ingest")},
+			{Name: "default", Scope: domain.TokenScopeRead, Prefix: "pst_read", Hash: []byte("read")},
+		}
+		if err := b.rooms.CreateRoom(ctx, &room, tokens); err != nil {
+			t.Fatalf("create room: %v", err)
+		}
+
+		if _, err := b.rooms.GetByID(ctx, room.ID); err != nil {
+			t.Fatalf("get room: %v", err)
+		}
+		for _, token := range tokens {
+			if token.ID == 0 || token.RoomID != room.ID {
+				t.Errorf("token %+v wasn't given an ID and the room's", token)
+			}
+			active, err := b.tokens.ListActive(ctx, room.ID, token.Scope)
+			if err != nil {
+				t.Fatalf("list active tokens: %v", err)
+			}
+			if len(active) != 1 || active[0].ID != token.ID {
+				t.Errorf("active %s tokens = %+v, want the created one", token.Scope, active)
+			}
+		}
+	})
+}
+
+func TestSQLiteRoomRepositoryCreateRoomRollsBack(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+	rooms := NewSQLiteRoomRepository(db)
+
+	// Both tokens have the same ID, the second insert fails
+	room := domain.Room{ID: uuid.NewString(), Name: "room"}
+	tokens := []domain.RoomToken{
+		{ID: 1, Name: "default", Scope: domain.TokenScopeIngest, Prefix: "pst_ingest", Hash: []byte("ingest")},
+		{ID: 1, Name: "default", Scope: domain.TokenScopeRead, Prefix: "pst_read", Hash: []byte("read")},
+	}
+	if err := rooms.CreateRoom(ctx, &room, tokens); err == nil {
+		t.Fatal("create room succeeded, want an error")
+	}
+
+	if _, err := rooms.GetByID(ctx, room.ID); !errors.Is(err, domain.ErrRoomNotFound) {
+		t.Errorf("get room = %v, want %v", err, domain.ErrRoomNotFound)
+	}
+}
+
+func TestTokenRepositoryRotate(t *testing.T) {
+	eachBackend(t, func(t *testing.T, b backend) {
+		ctx := context.Background()
+		roomID := newRoom(t, b)
+		expiresAt := time.Now().UTC().Add(24 * time.Hour).Truncate(time.Second)
+		old := domain.RoomToken{RoomID: roomID, Name: "ci", Scope: domain.TokenScopeIngest, Prefix: "pst_old", Hash: []byte("old"), ExpiresAt: &expiresAt}
+		if err := b.tokens.Save(ctx, &old); err != nil {
+			t.Fatalf("save token: %v", err)
+		}
+
+		next := domain.RoomToken{Prefix: "pst_next", Hash: []byte("next")}
+		if err := b.tokens.Rotate(ctx, roomID, old.ID, &next); err != nil {
+			t.Fatalf("rotate: %v", err)
+		}
+		if next.ID == 0 || next.RoomID != roomID || next.Name != old.Name || next.Scope != old.Scope ||
+			next.ExpiresAt == nil || !next.ExpiresAt.Equal(expiresAt) {
+			t.Errorf("rotated to %+v, want the name, scope and expiry of %+v", next, old)
+		}
+
+		active, err := b.tokens.ListActive(ctx, roomID, domain.TokenScopeIngest)
+		if err != nil {
+			t.Fatalf("list active tokens: %v", err)
+		}
+		if len(active) != 1 || active[0].ID != next.ID {
+			t.Errorf("active tokens = %+v, want the new one only", active)
+		}
+
+		// The old token is revoked already, nothing is saved
+		again := domain.RoomToken{Prefix: "pst_again", Hash: []byte("again")}
+		if err := b.tokens.Rotate(ctx, roomID, old.ID, &again); !errors.Is(err, domain.ErrTokenNotFound) {
+			t.Errorf("rotate a revoked token = %v, want %v", err, domain.ErrTokenNotFound)
+		}
+		all, err := b.tokens.List(ctx, roomID)
+		if err != nil {
+			t.Fatalf("list tokens: %v", err)
+		}
+		if len(all) != 2 {
+			t.Errorf("listed %d tokens, want 2", len(all))
+		}
+	})
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/erwin-lovecraft/pistol/internal/adapters/ormmodel"
+	"github.com/erwin-lovecraft/pistol/internal/adapters/sqlitemodel"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// inTx runs fn with queries bound to a Postgres transaction, committed when fn succeeds and rolled back otherwise.
+func inTx(ctx context.Context, dbPool *pgxpool.Pool, fn func(queries *ormmodel.Queries) error) error {
+	tx, err := dbPool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(ormmodel.New(tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// inSQLiteTx runs fn with queries bound to a SQLite transaction, committed when fn succeeds and rolled back otherwise.
+func inSQLiteTx(ctx context.Context, db *sql.DB, fn func(queries *sqlitemodel.Queries) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(sqlitemodel.New(tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
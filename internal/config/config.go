@@ -1,24 +1,263 @@
 package config
 
 import (
-	"os"
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/erwin-lovecraft/pistol/internal/core/domain"
+	"github.com/erwin-lovecraft/pistol/pkg/ssehub"
+)
+
+const (
+	StorageMemory   = "memory"
+	StorageSQLite   = "sqlite"
+	StoragePostgres = "postgres"
+)
+
+const (
+	BackplaneNone     = ""
+	BackplanePostgres = "postgres"
+)
+
+const (
+	maskedSecret = "****"
+)
+
+var (
+	// dsnPassword finds the password of key=value Postgres connection strings
+	dsnPassword = regexp.MustCompile(`(password=)('[^']*'|\S+)`)
 )
 
+// Config is the configuration of serverd. Settings are read from, by increasing precedence, the defaults, a YAML or
+// TOML file, the environment and the command-line flags.
 type Config struct {
-	Port  string
-	PGURL string
+	Server    ServerConfig    `yaml:"server" toml:"server"`
+	Storage   StorageConfig   `yaml:"storage" toml:"storage"`
+	Database  DatabaseConfig  `yaml:"database" toml:"database"`
+	Auth      AuthConfig      `yaml:"auth" toml:"auth"`
+	RateLimit RateLimitConfig `yaml:"rate_limit" toml:"rate_limit"`
+	Hub       HubConfig       `yaml:"hub" toml:"hub"`
+	Retention RetentionConfig `yaml:"retention" toml:"retention"`
+	Redaction RedactionConfig `yaml:"redaction" toml:"redaction"`
+	Metrics   MetricsConfig   `yaml:"metrics" toml:"metrics"`
 }
 
-func ReadFromENV() Config {
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
+type ServerConfig struct {
+	Port string `yaml:"port" toml:"port"`
+	// TemplateDir holds the HTML templates of the web UI
+	TemplateDir string        `yaml:"template_dir" toml:"template_dir"`
+	ReadTimeout time.Duration `yaml:"read_timeout" toml:"read_timeout"`
+	IdleTimeout time.Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+	// ShutdownTimeout is how long in-flight requests are waited for on SIGTERM before they're cut
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+	// TrustedProxies may set X-Forwarded-For on pushes, the client address is taken from it
+	TrustedProxies []netip.Prefix `yaml:"trusted_proxies" toml:"trusted_proxies"`
+}
+
+type StorageConfig struct {
+	// Backend keeps rooms and events in memory, in a SQLite file or in Postgres
+	Backend    string `yaml:"backend" toml:"backend"`
+	SQLitePath string `yaml:"sqlite_path" toml:"sqlite_path"`
+	// MemoryTTL drops the memory storage's events older than it, zero leaves them to retention
+	MemoryTTL time.Duration `yaml:"memory_ttl" toml:"memory_ttl"`
+	// MemoryCleanupInterval is how often the memory storage drops its expired events
+	MemoryCleanupInterval time.Duration `yaml:"memory_cleanup_interval" toml:"memory_cleanup_interval"`
+}
+
+type DatabaseConfig struct {
+	URL string `yaml:"url" toml:"url"`
+}
+
+type AuthConfig struct {
+	// SecretKey is the admin key, admin endpoints are closed when it's empty
+	SecretKey string `yaml:"secret_key" toml:"secret_key"`
+}
+
+type RateLimitConfig struct {
+	// Requests is how many requests a client may send to an endpoint per Window, zero disables rate limiting
+	Requests int           `yaml:"requests" toml:"requests"`
+	Window   time.Duration `yaml:"window" toml:"window"`
+}
+
+type HubConfig struct {
+	// Backplane shares SSE messages between instances, empty keeps them in-process
+	Backplane         string        `yaml:"backplane" toml:"backplane"`
+	DeliveryPolicy    string        `yaml:"delivery_policy" toml:"delivery_policy"`
+	BlockTimeout      time.Duration `yaml:"block_timeout" toml:"block_timeout"`
+	HeartbeatInterval time.Duration `yaml:"heartbeat_interval" toml:"heartbeat_interval"`
+	SendBuffer        int           `yaml:"send_buffer" toml:"send_buffer"`
+	// ShutdownRetry is how long SSE clients wait before reconnecting when the server shuts down
+	ShutdownRetry time.Duration `yaml:"shutdown_retry" toml:"shutdown_retry"`
+}
+
+type RetentionConfig struct {
+	// MaxAge, MaxEvents and MaxBytes bound the events kept by every room unless the room overrides them
+	MaxAge    time.Duration `yaml:"max_age" toml:"max_age"`
+	MaxEvents int64         `yaml:"max_events" toml:"max_events"`
+	MaxBytes  int64         `yaml:"max_bytes" toml:"max_bytes"`
+	// PruneInterval is how often events past retention are deleted
+	PruneInterval time.Duration `yaml:"prune_interval" toml:"prune_interval"`
+}
+
+type RedactionConfig struct {
+	// Rules apply to every room, before the room's own rules
+	Rules []domain.RedactionRule `yaml:"rules" toml:"rules"`
+}
 
-	pgURL := os.Getenv("PG_URL")
+type MetricsConfig struct {
+	// Path serves the Prometheus metrics to admins, empty turns them off
+	Path string `yaml:"path" toml:"path"`
+}
 
+// Default returns the configuration used for what isn't set elsewhere.
+func Default() Config {
 	return Config{
-		Port:  port,
-		PGURL: pgURL,
+		Server: ServerConfig{
+			Port:            "8080",
+			TemplateDir:     "internal/web",
+			ReadTimeout:     5 * time.Second,
+			IdleTimeout:     2 * time.Minute,
+			ShutdownTimeout: 30 * time.Second,
+		},
+		Storage: StorageConfig{
+			Backend:               StoragePostgres,
+			SQLitePath:            "pistol.db",
+			MemoryTTL:             time.Hour,
+			MemoryCleanupInterval: 15 * time.Minute,
+		},
+		RateLimit: RateLimitConfig{
+			Requests: 100,
+			Window:   time.Second,
+		},
+		Hub: HubConfig{
+			DeliveryPolicy:    string(ssehub.DisconnectSlow),
+			BlockTimeout:      2 * time.Second,
+			HeartbeatInterval: 25 * time.Second,
+			SendBuffer:        64,
+			ShutdownRetry:     time.Second,
+		},
+		Retention: RetentionConfig{
+			PruneInterval: 5 * time.Minute,
+		},
+		Redaction: RedactionConfig{
+			Rules: domain.DefaultRedactionRules(),
+		},
+		Metrics: MetricsConfig{
+			Path: "/metrics",
+		},
+	}
+}
+
+// Validate checks every setting, the error lists all the invalid ones.
+func (c Config) Validate() error {
+	var errs []error
+	invalid := func(key string, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+	}
+
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+		invalid("server.port", "must be a number between 1 and 65535, got %q", c.Server.Port)
+	}
+	if c.Server.TemplateDir == "" {
+		invalid("server.template_dir", "is required")
+	}
+	if c.Server.ReadTimeout < 0 {
+		invalid("server.read_timeout", "can't be negative")
+	}
+	if c.Server.IdleTimeout < 0 {
+		invalid("server.idle_timeout", "can't be negative")
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		invalid("server.shutdown_timeout", "must be positive")
+	}
+
+	switch c.Storage.Backend {
+	case StorageMemory, StoragePostgres:
+	case StorageSQLite:
+		if c.Storage.SQLitePath == "" {
+			invalid("storage.sqlite_path", "is required with the %s backend", StorageSQLite)
+		}
+	default:
+		invalid("storage.backend", "must be %q, %q or %q, got %q", StorageMemory, StorageSQLite, StoragePostgres, c.Storage.Backend)
+	}
+	if c.Storage.MemoryTTL < 0 {
+		invalid("storage.memory_ttl", "can't be negative")
+	}
+	if c.Storage.MemoryCleanupInterval <= 0 {
+		invalid("storage.memory_cleanup_interval", "must be positive")
+	}
+	if c.UsesPostgres() && c.Database.URL == "" {
+		invalid("database.url", "is required with the postgres storage or hub backplane")
+	}
+
+	if c.RateLimit.Requests < 0 {
+		invalid("rate_limit.requests", "can't be negative")
+	}
+	if c.RateLimit.Requests > 0 && c.RateLimit.Window <= 0 {
+		invalid("rate_limit.window", "must be positive")
+	}
+
+	switch c.Hub.Backplane {
+	case BackplaneNone, BackplanePostgres:
+	default:
+		invalid("hub.backplane", "must be empty or %q, got %q", BackplanePostgres, c.Hub.Backplane)
+	}
+	if _, err := ssehub.ParseDeliveryPolicy(c.Hub.DeliveryPolicy); err != nil {
+		invalid("hub.delivery_policy", "%v", err)
+	}
+	if c.Hub.BlockTimeout <= 0 {
+		invalid("hub.block_timeout", "must be positive")
+	}
+	if c.Hub.HeartbeatInterval <= 0 {
+		invalid("hub.heartbeat_interval", "must be positive")
+	}
+	if c.Hub.SendBuffer <= 0 {
+		invalid("hub.send_buffer", "must be positive")
+	}
+	if c.Hub.ShutdownRetry < 0 {
+		invalid("hub.shutdown_retry", "can't be negative")
+	}
+
+	if c.Retention.MaxAge < 0 {
+		invalid("retention.max_age", "can't be negative")
+	}
+	if c.Retention.MaxEvents < 0 {
+		invalid("retention.max_events", "can't be negative")
+	}
+	if c.Retention.MaxBytes < 0 {
+		invalid("retention.max_bytes", "can't be negative")
+	}
+	if c.Retention.PruneInterval <= 0 {
+		invalid("retention.prune_interval", "must be positive")
+	}
+
+	if c.Metrics.Path != "" && !strings.HasPrefix(c.Metrics.Path, "/") {
+		invalid("metrics.path", "must start with /, got %q", c.Metrics.Path)
+	}
+
+	return errors.Join(errs...)
+}
+
+// UsesPostgres reports whether the storage or the hub backplane needs the Postgres database.
+func (c Config) UsesPostgres() bool {
+	return c.Storage.Backend == StoragePostgres || c.Hub.Backplane == BackplanePostgres
+}
+
+// Masked returns the configuration with its secrets hidden, to be shown.
+func (c Config) Masked() Config {
+	if c.Auth.SecretKey != "" {
+		c.Auth.SecretKey = maskedSecret
+	}
+	if u, err := url.Parse(c.Database.URL); err == nil && u.Scheme != "" {
+		c.Database.URL = u.Redacted()
+	} else {
+		c.Database.URL = dsnPassword.ReplaceAllString(c.Database.URL, "${1}"+maskedSecret)
 	}
+	return c
 }
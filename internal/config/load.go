@@ -0,0 +1,238 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/erwin-lovecraft/pistol/internal/core/domain"
+	"gopkg.in/yaml.v3"
+)
+
+// Load reads the configuration from the file given by -config or CONFIG_FILE, the environment and the flags in args.
+// It returns flag.ErrHelp when args ask for help, the usage being already printed.
+func Load(name string, args []string) (Config, error) {
+	cfg := Default()
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	configFile := os.Getenv("CONFIG_FILE")
+	fs.StringVar(&configFile, "config", configFile, "YAML or TOML config `file` (CONFIG_FILE)")
+	envs := cfg.bind(fs)
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+	if fs.NArg() > 0 {
+		return Config{}, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	// The flags had to be parsed to find the config file, start over so they come after the file and the environment
+	flags := make(map[string]string)
+	fs.Visit(func(f *flag.Flag) {
+		flags[f.Name] = f.Value.String()
+	})
+	cfg = Default()
+
+	if configFile != "" {
+		if err := readFile(configFile, &cfg); err != nil {
+			return Config{}, fmt.Errorf("read config file %s: %w", configFile, err)
+		}
+	}
+	for flagName, env := range envs {
+		if v := os.Getenv(env); v != "" {
+			if err := fs.Set(flagName, v); err != nil {
+				return Config{}, fmt.Errorf("invalid %s: %w", env, err)
+			}
+		}
+	}
+	for flagName, v := range flags {
+		if err := fs.Set(flagName, v); err != nil {
+			return Config{}, fmt.Errorf("invalid -%s: %w", flagName, err)
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid config:\n%w", err)
+	}
+	return cfg, nil
+}
+
+// bind defines a flag for every setting, it returns the environment variable of each flag.
+func (c *Config) bind(fs *flag.FlagSet) map[string]string {
+	envs := make(map[string]string)
+	define := func(name, env, usage string, define func(name, usage string)) {
+		envs[name] = env
+		define(name, usage+" ("+env+")")
+	}
+	str := func(p *string) func(string, string) {
+		return func(name, usage string) { fs.StringVar(p, name, *p, usage) }
+	}
+
+	define("port", "PORT", "HTTP `port`", str(&c.Server.Port))
+	define("template-dir", "TEMPLATE_DIR", "`dir`ectory of the web UI templates", str(&c.Server.TemplateDir))
+	define("read-timeout", "READ_TIMEOUT", "how long reading a request may take", func(name, usage string) {
+		fs.DurationVar(&c.Server.ReadTimeout, name, c.Server.ReadTimeout, usage)
+	})
+	define("idle-timeout", "IDLE_TIMEOUT", "how long idle keep-alive connections are kept", func(name, usage string) {
+		fs.DurationVar(&c.Server.IdleTimeout, name, c.Server.IdleTimeout, usage)
+	})
+	define("shutdown-timeout", "SHUTDOWN_TIMEOUT", "how long in-flight requests are waited for on shutdown", func(name, usage string) {
+		fs.DurationVar(&c.Server.ShutdownTimeout, name, c.Server.ShutdownTimeout, usage)
+	})
+	define("trusted-proxies", "TRUSTED_PROXIES", "comma separated IPs or CIDRs allowed to set X-Forwarded-For", func(name, usage string) {
+		fs.Var(prefixesValue{&c.Server.TrustedProxies}, name, usage)
+	})
+	define("storage", "STORAGE", "storage `backend` keeping rooms and events, memory, sqlite or postgres", str(&c.Storage.Backend))
+	define("sqlite-path", "SQLITE_PATH", "SQLite database `file` of the sqlite storage", str(&c.Storage.SQLitePath))
+	define("memory-ttl", "MEMORY_TTL", "age past which the memory storage drops events, 0 leaves them to retention", func(name, usage string) {
+		fs.DurationVar(&c.Storage.MemoryTTL, name, c.Storage.MemoryTTL, usage)
+	})
+	define("memory-cleanup-interval", "MEMORY_CLEANUP_INTERVAL", "how often the memory storage drops expired events", func(name, usage string) {
+		fs.DurationVar(&c.Storage.MemoryCleanupInterval, name, c.Storage.MemoryCleanupInterval, usage)
+	})
+	define("pg-url", "PG_URL", "Postgres connection `url`", str(&c.Database.URL))
+	define("secret-key", "SECRET_KEY", "admin secret `key`, admin endpoints are closed when empty", str(&c.Auth.SecretKey))
+	define("rate-limit-requests", "RATE_LIMIT_REQUESTS", "requests a client may send to an endpoint per window, 0 disables", func(name, usage string) {
+		fs.IntVar(&c.RateLimit.Requests, name, c.RateLimit.Requests, usage)
+	})
+	define("rate-limit-window", "RATE_LIMIT_WINDOW", "rate limit window", func(name, usage string) {
+		fs.DurationVar(&c.RateLimit.Window, name, c.RateLimit.Window, usage)
+	})
+	define("hub-backplane", "HUB_BACKPLANE", "`backplane` sharing SSE messages between instances, empty or postgres", str(&c.Hub.Backplane))
+	define("hub-delivery-policy", "HUB_DELIVERY_POLICY", "what happens to slow SSE clients, drop-oldest, disconnect-slow or block", str(&c.Hub.DeliveryPolicy))
+	define("hub-block-timeout", "HUB_BLOCK_TIMEOUT", "how long the block delivery policy waits for a slow client", func(name, usage string) {
+		fs.DurationVar(&c.Hub.BlockTimeout, name, c.Hub.BlockTimeout, usage)
+	})
+	define("hub-heartbeat-interval", "HUB_HEARTBEAT_INTERVAL", "how often SSE clients get a heartbeat", func(name, usage string) {
+		fs.DurationVar(&c.Hub.HeartbeatInterval, name, c.Hub.HeartbeatInterval, usage)
+	})
+	define("hub-send-buffer", "HUB_SEND_BUFFER", "messages an SSE client may fall behind", func(name, usage string) {
+		fs.IntVar(&c.Hub.SendBuffer, name, c.Hub.SendBuffer, usage)
+	})
+	define("hub-shutdown-retry", "HUB_SHUTDOWN_RETRY", "how long SSE clients wait before reconnecting on shutdown", func(name, usage string) {
+		fs.DurationVar(&c.Hub.ShutdownRetry, name, c.Hub.ShutdownRetry, usage)
+	})
+	define("retention-max-age", "RETENTION_MAX_AGE", "age past which events are pruned, 0 keeps them", func(name, usage string) {
+		fs.DurationVar(&c.Retention.MaxAge, name, c.Retention.MaxAge, usage)
+	})
+	define("retention-max-events", "RETENTION_MAX_EVENTS", "events kept per room, 0 is unbounded", func(name, usage string) {
+		fs.Int64Var(&c.Retention.MaxEvents, name, c.Retention.MaxEvents, usage)
+	})
+	define("retention-max-bytes", "RETENTION_MAX_BYTES", "event body bytes kept per room, 0 is unbounded", func(name, usage string) {
+		fs.Int64Var(&c.Retention.MaxBytes, name, c.Retention.MaxBytes, usage)
+	})
+	define("prune-interval", "PRUNE_INTERVAL", "how often events past retention are deleted", func(name, usage string) {
+		fs.DurationVar(&c.Retention.PruneInterval, name, c.Retention.PruneInterval, usage)
+	})
+	define("redaction-rules", "REDACTION_RULES", "global redaction rules as a JSON array, replacing the default ones", func(name, usage string) {
+		fs.Var(redactionRulesValue{&c.Redaction.Rules}, name, usage)
+	})
+	define("metrics-path", "METRICS_PATH", "`path` serving the Prometheus metrics to admins, empty turns them off", str(&c.Metrics.Path))
+	return envs
+}
+
+// readFile decodes a YAML or TOML file into cfg, picked by its extension. Unknown keys are errors, so typos don't go
+// unnoticed.
+func readFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+	case ".toml":
+		md, err := toml.Decode(string(data), cfg)
+		if err != nil {
+			return err
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("unknown key %s", undecoded[0])
+		}
+	default:
+		return fmt.Errorf("unsupported extension %q, expected .yaml, .yml or .toml", ext)
+	}
+	return nil
+}
+
+type prefixesValue struct {
+	prefixes *[]netip.Prefix
+}
+
+func (v prefixesValue) String() string {
+	if v.prefixes == nil {
+		return ""
+	}
+	s := make([]string, len(*v.prefixes))
+	for i, p := range *v.prefixes {
+		s[i] = p.String()
+	}
+	return strings.Join(s, ",")
+}
+
+func (v prefixesValue) Set(s string) error {
+	prefixes, err := parsePrefixes(s)
+	if err != nil {
+		return err
+	}
+	*v.prefixes = prefixes
+	return nil
+}
+
+// parsePrefixes parses a comma separated list of CIDRs, a bare IP being a prefix of its own.
+func parsePrefixes(s string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if !strings.Contains(v, "/") {
+			addr, err := netip.ParseAddr(v)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(v)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+type redactionRulesValue struct {
+	rules *[]domain.RedactionRule
+}
+
+func (v redactionRulesValue) String() string {
+	if v.rules == nil || *v.rules == nil {
+		return ""
+	}
+	b, _ := json.Marshal(*v.rules)
+	return string(b)
+}
+
+func (v redactionRulesValue) Set(s string) error {
+	var rules []domain.RedactionRule
+	if err := json.Unmarshal([]byte(s), &rules); err != nil {
+		return err
+	}
+	*v.rules = rules
+	return nil
+}
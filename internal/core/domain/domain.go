@@ -1,22 +1,107 @@
 package domain
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 )
 
+var (
+	ErrRoomNotFound  = errors.New("room not found")
+	ErrInvalidImport = errors.New("invalid import")
+)
+
+const (
+	MaxImportEvents = 10_000
+)
+
 type Room struct {
-	ID     string `json:"id"`
-	Name   string `json:"name"`
-	Avatar string `json:"avatar"`
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Avatar    string    `json:"avatar"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Response is how pushes into the room are answered, DefaultResponseRule when nil
+	Response *ResponseRule `json:"response,omitempty"`
+	// Forwards are the downstream URLs every pushed event is proxied to
+	Forwards []ForwardRule `json:"forwards,omitempty"`
+	// Signature verifies the authenticity of pushed events when set
+	Signature *SignatureProfile `json:"signature,omitempty"`
+	// Retention overrides the global retention policy for the room's events
+	Retention *RetentionPolicy `json:"retention,omitempty"`
+	// Redaction adds to the global redaction rules for the room's events
+	Redaction []RedactionRule `json:"redaction,omitempty"`
 }
 
 type Event struct {
-	ID          int64               `json:"id"`
-	Method      string              `json:"method"`
-	Header      http.Header         `json:"header"`
-	QueryParams map[string][]string `json:"query_params"`
-	Body        json.RawMessage     `json:"body,omitempty"`
-	CreatedAt   time.Time           `json:"created_at"`
+	ID            int64               `json:"id"`
+	Method        string              `json:"method"`
+	Header        http.Header         `json:"header"`
+	QueryParams   map[string][]string `json:"query_params"`
+	Body          []byte              `json:"-"`
+	BodyBase64    bool                `json:"body_base64"`
+	ContentType   string              `json:"content_type"`
+	ContentLength int64               `json:"content_length"`
+	Response      *EventResponse      `json:"response,omitempty"`
+	Signature     *SignatureCheck     `json:"signature,omitempty"`
+	// Redacted lists the fields redaction rules changed before the event was stored
+	Redacted []RedactedField `json:"redacted,omitempty"`
+	// Path is what follows /push in the URL, e.g. /github/hooks
+	Path string `json:"path,omitempty"`
+	// RemoteAddr is the IP of the client, past the trusted proxies
+	RemoteAddr string   `json:"remote_addr,omitempty"`
+	Proto      string   `json:"proto,omitempty"`
+	Host       string   `json:"host,omitempty"`
+	TLS        *TLSInfo `json:"tls,omitempty"`
+	// DurationUS is how long the request took to be received, in microseconds
+	DurationUS int64     `json:"duration_us,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// TLSInfo describes the TLS connection an event was received over.
+type TLSInfo struct {
+	Version            string `json:"version"`
+	CipherSuite        string `json:"cipher_suite"`
+	ServerName         string `json:"server_name,omitempty"`
+	NegotiatedProtocol string `json:"negotiated_protocol,omitempty"`
+}
+
+// eventJSON is the wire representation of Event: the raw body is rendered as text,
+// or as base64 when the payload is binary.
+type eventJSON struct {
+	event
+	Body string `json:"body"`
+}
+
+type event Event
+
+func (e Event) MarshalJSON() ([]byte, error) {
+	v := eventJSON{event: event(e)}
+	if e.BodyBase64 {
+		v.Body = base64.StdEncoding.EncodeToString(e.Body)
+	} else {
+		v.Body = string(e.Body)
+	}
+	return json.Marshal(v)
+}
+
+func (e *Event) UnmarshalJSON(data []byte) error {
+	var v eventJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	*e = Event(v.event)
+	if v.BodyBase64 {
+		body, err := base64.StdEncoding.DecodeString(v.Body)
+		if err != nil {
+			return err
+		}
+		e.Body = body
+	} else {
+		e.Body = []byte(v.Body)
+	}
+	return nil
 }
@@ -0,0 +1,24 @@
+package domain
+
+import (
+	"errors"
+)
+
+var (
+	ErrInvalidForwardRule = errors.New("invalid forward rule")
+)
+
+const (
+	MaxForwardRules        = 10
+	MaxForwardAttempts     = 10
+	DefaultForwardAttempts = 5
+)
+
+// ForwardRule proxies every event pushed into a room to a downstream URL.
+type ForwardRule struct {
+	TargetURL string `json:"target_url"`
+	// Headers override the captured ones, an empty value removes the header
+	Headers map[string]string `json:"headers,omitempty"`
+	// MaxAttempts bounds the retries of a failed delivery, DefaultForwardAttempts when zero
+	MaxAttempts int `json:"max_attempts,omitempty"`
+}
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"errors"
+)
+
+var (
+	ErrInvalidRedactionRule = errors.New("invalid redaction rule")
+)
+
+const (
+	MaxRedactionRules = 50
+)
+
+// Redaction rules match a field of the pushed request.
+const (
+	// RedactHeader matches header names with a case-insensitive glob, e.g. "X-*-Token"
+	RedactHeader = "header"
+	// RedactQuery matches query param names with a case-insensitive glob
+	RedactQuery = "query"
+	// RedactBodyPath matches the values a path selects in JSON bodies, e.g. "$.card.number"
+	RedactBodyPath = "body_path"
+	// RedactValue matches a regular expression in header values, query values and the body
+	RedactValue = "value"
+)
+
+// Redaction rules either drop, mask or hash what they match.
+const (
+	RedactRemove = "remove"
+	RedactMask   = "mask"
+	// RedactHash replaces the value with its SHA-256, so equal secrets can still be told apart
+	RedactHash = "hash"
+)
+
+// RedactionRule keeps secrets of pushed requests out of the stored events.
+type RedactionRule struct {
+	Target  string `json:"target"`
+	Pattern string `json:"pattern"`
+	// Action is RedactMask when empty
+	Action string `json:"action,omitempty"`
+}
+
+// RedactedField tells which field of an event was redacted, and how.
+type RedactedField struct {
+	// Location is header, query or body, or response_header or response_body for the stored response
+	Location string `json:"location"`
+	// Name is the header or query param name, for the body the path or pattern that matched
+	Name   string `json:"name"`
+	Action string `json:"action"`
+}
+
+// DefaultRedactionRules remove the credentials commonly sent along webhooks, including the ones of pistol itself.
+func DefaultRedactionRules() []RedactionRule {
+	return []RedactionRule{
+		{Target: RedactHeader, Pattern: "Authorization", Action: RedactRemove},
+		{Target: RedactHeader, Pattern: "X-Auth-Token", Action: RedactRemove},
+		{Target: RedactHeader, Pattern: "X-Api-Key", Action: RedactRemove},
+		{Target: RedactHeader, Pattern: "X-Api-Secret", Action: RedactRemove},
+		{Target: RedactHeader, Pattern: "X-Pistol-Token", Action: RedactRemove},
+		{Target: RedactQuery, Pattern: "x-api-key", Action: RedactRemove},
+		{Target: RedactQuery, Pattern: "x-api-secret", Action: RedactRemove},
+		{Target: RedactQuery, Pattern: "token", Action: RedactRemove},
+	}
+}
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+var (
+	ErrEventNotFound       = errors.New("event not found")
+	ErrInvalidReplayTarget = errors.New("invalid replay target")
+)
+
+const (
+	// ReplaySourceManual marks attempts requested through the replay API
+	ReplaySourceManual = "replay"
+	// ReplaySourceForward marks attempts made by a room's forward rules
+	ReplaySourceForward = "forward"
+)
+
+// ReplayRequest asks for a captured event to be sent again to TargetURL.
+type ReplayRequest struct {
+	TargetURL string `json:"target_url"`
+	// Headers override the captured ones, an empty value removes the header
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// ReplayAttempt records what happened when a captured event was sent to a target.
+type ReplayAttempt struct {
+	ID                 int64       `json:"id"`
+	EventID            int64       `json:"event_id"`
+	TargetURL          string      `json:"target_url"`
+	RequestHeader      http.Header `json:"request_header"`
+	StatusCode         int         `json:"status_code"`
+	ResponseHeader     http.Header `json:"response_header"`
+	ResponseBody       string      `json:"response_body"`
+	ResponseBodyBase64 bool        `json:"response_body_base64"`
+	LatencyMS          int64       `json:"latency_ms"`
+	Error              string      `json:"error,omitempty"`
+	Source             string      `json:"source"`
+	Attempt            int         `json:"attempt"`
+	CreatedAt          time.Time   `json:"created_at"`
+}
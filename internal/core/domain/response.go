@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"errors"
+	"net/http"
+)
+
+var (
+	ErrInvalidResponseRule = errors.New("invalid response rule")
+)
+
+const (
+	MaxResponseDelayMS = 30_000
+)
+
+// ResponseRule describes how a room answers the webhooks pushed into it.
+type ResponseRule struct {
+	StatusCode int               `json:"status_code"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	// Body is a text/template rendered against the incoming request
+	Body    string `json:"body"`
+	DelayMS int64  `json:"delay_ms,omitempty"`
+}
+
+// DefaultResponseRule is used by rooms without a configured rule.
+var DefaultResponseRule = ResponseRule{
+	StatusCode: http.StatusOK,
+	Headers:    map[string]string{"Content-Type": "application/json"},
+	Body:       "{\"message\":\"ok\"}\n",
+}
+
+// EventResponse is the response that was sent back for a captured event.
+type EventResponse struct {
+	StatusCode int               `json:"status_code"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Body       string            `json:"body"`
+	DelayMS    int64             `json:"delay_ms,omitempty"`
+}
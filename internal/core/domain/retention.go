@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidRetentionPolicy = errors.New("invalid retention policy")
+)
+
+// RetentionPolicy bounds the events kept by a room, the oldest ones are pruned first. A zero limit is unbounded.
+type RetentionPolicy struct {
+	MaxAgeSeconds int64 `json:"max_age_seconds,omitempty"`
+	MaxEvents     int64 `json:"max_events,omitempty"`
+	// MaxBytes bounds the total size of the room's event bodies
+	MaxBytes int64 `json:"max_bytes,omitempty"`
+}
+
+// IsZero reports whether the policy keeps every event.
+func (p RetentionPolicy) IsZero() bool {
+	return p.MaxAgeSeconds == 0 && p.MaxEvents == 0 && p.MaxBytes == 0
+}
+
+// MaxAge is the age past which events are pruned, zero when unbounded.
+func (p RetentionPolicy) MaxAge() time.Duration {
+	return time.Duration(p.MaxAgeSeconds) * time.Second
+}
+
+// Override returns the policy with the limits set by the room's policy replacing its own.
+func (p RetentionPolicy) Override(room *RetentionPolicy) RetentionPolicy {
+	if room == nil {
+		return p
+	}
+	if room.MaxAgeSeconds != 0 {
+		p.MaxAgeSeconds = room.MaxAgeSeconds
+	}
+	if room.MaxEvents != 0 {
+		p.MaxEvents = room.MaxEvents
+	}
+	if room.MaxBytes != 0 {
+		p.MaxBytes = room.MaxBytes
+	}
+	return p
+}
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"errors"
+)
+
+var (
+	ErrInvalidSignatureProfile = errors.New("invalid signature profile")
+)
+
+const (
+	SignatureTypeGitHub = "github"
+	SignatureTypeStripe = "stripe"
+	SignatureTypeSlack  = "slack"
+	SignatureTypeHMAC   = "hmac"
+)
+
+const (
+	SignatureVerified = "verified"
+	SignatureFailed   = "failed"
+	SignatureMissing  = "missing"
+)
+
+const (
+	redactedSecret = "********"
+)
+
+// SignatureProfile tells how the webhooks pushed into a room are signed.
+type SignatureProfile struct {
+	Type   string `json:"type"`
+	Secret string `json:"secret"`
+	// Header carries the signature, only used by the hmac type
+	Header string `json:"header,omitempty"`
+	// Algorithm is sha1, sha256 or sha512, only used by the hmac type
+	Algorithm string `json:"algorithm,omitempty"`
+	// Encoding of the signature, hex or base64, only used by the hmac type
+	Encoding string `json:"encoding,omitempty"`
+	// Prefix preceding the signature in the header, e.g. "sha256=", only used by the hmac type
+	Prefix string `json:"prefix,omitempty"`
+	// ToleranceSeconds bounds the age of signed timestamps for stripe and slack, 300 when zero
+	ToleranceSeconds int64 `json:"tolerance_seconds,omitempty"`
+}
+
+// SignatureCheck is the outcome of verifying an event against its room's signature profile.
+type SignatureCheck struct {
+	Verdict string `json:"verdict"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// Redacted returns a copy of the room that's safe to show, without secrets.
+func (r Room) Redacted() Room {
+	if r.Signature != nil {
+		profile := *r.Signature
+		if profile.Secret != "" {
+			profile.Secret = redactedSecret
+		}
+		r.Signature = &profile
+	}
+	// Forward headers usually carry the target's credentials, only their names are shown
+	if len(r.Forwards) > 0 {
+		forwards := make([]ForwardRule, len(r.Forwards))
+		for idx, rule := range r.Forwards {
+			if len(rule.Headers) > 0 {
+				headers := make(map[string]string, len(rule.Headers))
+				for k, v := range rule.Headers {
+					// An empty value removes the header, there's nothing to hide
+					if v != "" {
+						v = redactedSecret
+					}
+					headers[k] = v
+				}
+				rule.Headers = headers
+			}
+			forwards[idx] = rule
+		}
+		r.Forwards = forwards
+	}
+	return r
+}
@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrTokenNotFound = errors.New("token not found")
+	ErrInvalidToken  = errors.New("invalid token")
+	ErrUnauthorized  = errors.New("unauthorized")
+)
+
+// Room tokens are sent in a header, or in a query param where headers can't be set.
+const (
+	RoomTokenHeader = "X-Pistol-Token"
+	RoomTokenParam  = "token"
+)
+
+// Pistol's own credentials, pushes carry them but they're never stored nor sent on.
+const (
+	SecretKeyHeader = "X-API-Secret"
+	SecretKeyParam  = "x-api-secret"
+)
+
+const (
+	// TokenScopeIngest allows pushing events into a room
+	TokenScopeIngest = "ingest"
+	// TokenScopeRead allows reading a room's events, live or from history
+	TokenScopeRead = "read"
+)
+
+// RoomToken grants access to a single room. Only a hash of the token is kept.
+type RoomToken struct {
+	ID     int64  `json:"id"`
+	RoomID string `json:"room_id"`
+	Name   string `json:"name"`
+	Scope  string `json:"scope"`
+	// Prefix is the beginning of the token, to tell tokens apart
+	Prefix    string     `json:"prefix"`
+	Hash      []byte     `json:"-"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// IssuedToken is a freshly created token, the only time its value is known.
+type IssuedToken struct {
+	RoomToken
+	Token string `json:"token"`
+}
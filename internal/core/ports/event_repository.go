@@ -1,13 +1,118 @@
 package ports
 
 import (
+	"bytes"
 	"context"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/erwin-lovecraft/pistol/internal/core/domain"
+	"github.com/erwin-lovecraft/pistol/pkg/jsonpath"
 )
 
 type EventRepository interface {
 	Save(ctx context.Context, roomID string, ev *domain.Event) error
 
-	List(ctx context.Context, roomID string, page, size int) ([]domain.Event, bool, error)
+	Get(ctx context.Context, roomID string, id int64) (domain.Event, error)
+
+	// List returns a page of the room's events, newest first, and whether more events lie beyond it in the
+	// direction of the cursor.
+	List(ctx context.Context, roomID string, filter EventFilter, cursor EventCursor) ([]domain.Event, bool, error)
+
+	// Prune deletes the room's events falling outside the retention policy, oldest first, in batches of batchSize
+	// so the table is never locked for long.
+	Prune(ctx context.Context, roomID string, policy domain.RetentionPolicy, batchSize int) (PruneResult, error)
+
+	// ListAfter returns up to limit events of the room with an ID greater than afterID, oldest first.
+	ListAfter(ctx context.Context, roomID string, afterID int64, limit int) ([]domain.Event, error)
+}
+
+// EventFilter narrows listed events, every criterion set must hold.
+type EventFilter struct {
+	// SignatureVerdict keeps events with this verdict only when set
+	SignatureVerdict string
+	// Method keeps events sent with this HTTP method only when set
+	Method string
+	// From and To keep events created in [From, To) when set
+	From, To time.Time
+	// Headers keeps events carrying each header, with all the listed values if any. Keys are canonical.
+	Headers map[string][]string
+	// QueryParams keeps events carrying each query param, with all the listed values if any
+	QueryParams map[string][]string
+	// BodyContains keeps events whose raw body contains these bytes when set
+	BodyContains []byte
+	// BodyPath keeps events with a JSON body satisfying the expression when set
+	BodyPath *jsonpath.Expr
+	// PathSuffix keeps events whose path, what follows /push, ends with it when set
+	PathSuffix string
+}
+
+// IsZero reports whether the filter has no criterion, every event matching it.
+func (filter EventFilter) IsZero() bool {
+	return filter.SignatureVerdict == "" && filter.Method == "" && filter.From.IsZero() && filter.To.IsZero() &&
+		len(filter.Headers) == 0 && len(filter.QueryParams) == 0 && filter.BodyContains == nil && filter.BodyPath == nil &&
+		filter.PathSuffix == ""
+}
+
+// Match reports whether the event satisfies every criterion of the filter, for storages and streams that can't
+// filter otherwise.
+func (filter EventFilter) Match(ev domain.Event) bool {
+	if filter.SignatureVerdict != "" && (ev.Signature == nil || ev.Signature.Verdict != filter.SignatureVerdict) {
+		return false
+	}
+	if filter.Method != "" && ev.Method != filter.Method {
+		return false
+	}
+	if !filter.From.IsZero() && ev.CreatedAt.Before(filter.From) {
+		return false
+	}
+	if !filter.To.IsZero() && !ev.CreatedAt.Before(filter.To) {
+		return false
+	}
+	if !containsValues(ev.Header, filter.Headers) || !containsValues(ev.QueryParams, filter.QueryParams) {
+		return false
+	}
+	if filter.BodyContains != nil && !bytes.Contains(ev.Body, filter.BodyContains) {
+		return false
+	}
+	if filter.BodyPath != nil && !filter.BodyPath.MatchJSON(ev.Body) {
+		return false
+	}
+	if !strings.HasSuffix(ev.Path, filter.PathSuffix) {
+		return false
+	}
+	return true
+}
+
+// containsValues reports whether values has every key of wanted, with at least the wanted values.
+func containsValues(values map[string][]string, wanted map[string][]string) bool {
+	for k, want := range wanted {
+		got, ok := values[k]
+		if !ok {
+			return false
+		}
+		for _, v := range want {
+			if !slices.Contains(got, v) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// EventCursor pages through a room's events by ID, which grows with time. Without Before or After the newest
+// events are listed.
+type EventCursor struct {
+	// Before keeps events older than this ID when set
+	Before int64
+	// After keeps events newer than this ID when set
+	After int64
+	Limit int
+}
+
+// PruneResult counts what a prune deleted.
+type PruneResult struct {
+	Events int64
+	Bytes  int64
 }
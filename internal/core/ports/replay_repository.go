@@ -0,0 +1,13 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/erwin-lovecraft/pistol/internal/core/domain"
+)
+
+type ReplayRepository interface {
+	Save(ctx context.Context, attempt *domain.ReplayAttempt) error
+
+	ListByEvent(ctx context.Context, eventID int64, limit int) ([]domain.ReplayAttempt, error)
+}
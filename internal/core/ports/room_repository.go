@@ -7,10 +7,32 @@ import (
 )
 
 type RoomRepository interface {
-	SaveRoom(ctx context.Context, room domain.Room) error
+	SaveRoom(ctx context.Context, room *domain.Room) error
+
+	// CreateRoom saves a new room and its first tokens in one transaction.
+	CreateRoom(ctx context.Context, room *domain.Room, tokens []domain.RoomToken) error
+
+	GetByID(ctx context.Context, id string) (domain.Room, error)
 
 	List(ctx context.Context, filter RoomFilter) ([]domain.Room, error)
 
+	UpdateName(ctx context.Context, id string, name string) (domain.Room, error)
+
+	// UpdateResponse sets the room's response rule, nil restores the default response.
+	UpdateResponse(ctx context.Context, id string, rule *domain.ResponseRule) (domain.Room, error)
+
+	// UpdateForwards replaces the room's forward rules.
+	UpdateForwards(ctx context.Context, id string, rules []domain.ForwardRule) (domain.Room, error)
+
+	// UpdateSignature sets the room's signature profile, nil disables verification.
+	UpdateSignature(ctx context.Context, id string, profile *domain.SignatureProfile) (domain.Room, error)
+
+	// UpdateRetention sets the room's retention policy, nil falls back to the global one.
+	UpdateRetention(ctx context.Context, id string, policy *domain.RetentionPolicy) (domain.Room, error)
+
+	// UpdateRedaction replaces the room's redaction rules.
+	UpdateRedaction(ctx context.Context, id string, rules []domain.RedactionRule) (domain.Room, error)
+
 	DeleteByID(ctx context.Context, id string) error
 }
 
@@ -0,0 +1,23 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/erwin-lovecraft/pistol/internal/core/domain"
+)
+
+type TokenRepository interface {
+	Save(ctx context.Context, token *domain.RoomToken) error
+
+	Get(ctx context.Context, roomID string, id int64) (domain.RoomToken, error)
+
+	List(ctx context.Context, roomID string) ([]domain.RoomToken, error)
+
+	// ListActive returns the room's tokens of the scope that are neither revoked nor expired.
+	ListActive(ctx context.Context, roomID string, scope string) ([]domain.RoomToken, error)
+
+	Revoke(ctx context.Context, roomID string, id int64) (domain.RoomToken, error)
+
+	// Rotate revokes the token and saves next in its place, with its name, scope and expiry, in one transaction.
+	Rotate(ctx context.Context, roomID string, id int64, next *domain.RoomToken) error
+}
@@ -1,6 +0,0 @@
-package services
-
-var (
-	secretHeaders     = []string{"Authorization", "X-Auth-Token", "X-API-KEY", "X-API-SECRET"}
-	secretQueryParams = []string{"x-api-key", "x-api-secret"}
-)
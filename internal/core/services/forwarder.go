@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/erwin-lovecraft/pistol/internal/core/domain"
+	"github.com/erwin-lovecraft/pistol/internal/core/ports"
+	"github.com/erwin-lovecraft/pistol/pkg/ssehub"
+)
+
+const (
+	defaultForwardWorkers   = 8
+	defaultForwardQueueSize = 1024
+	forwardBaseBackoff      = time.Second
+	forwardMaxBackoff       = time.Minute
+	// EventTypeForward is the SSE event type of forward attempts
+	EventTypeForward = "forward"
+
+	// defaultForwardDrainTimeout bounds the deliveries made on shutdown
+	defaultForwardDrainTimeout = 30 * time.Second
+)
+
+type forwardJob struct {
+	room    domain.Room
+	event   domain.Event
+	rule    domain.ForwardRule
+	target  *url.URL
+	attempt int
+}
+
+// Forwarder proxies pushed events to the rooms' forward rules in the background,
+// using a bounded pool of workers and retrying failures with exponential backoff.
+type Forwarder struct {
+	hub              *ssehub.Hub
+	client           *http.Client
+	replayRepository ports.ReplayRepository
+	redactor         *Redactor
+	workers          int
+	drainTimeout     time.Duration
+	jobs             chan forwardJob
+
+	mu sync.Mutex
+	// retries are the jobs waiting for their backoff, the timer that removes a job from it enqueues it
+	retries  map[*time.Timer]forwardJob
+	draining bool
+}
+
+// NewForwarder returns a Forwarder recording the attempts in replayRepository, the headers sent redacted by redactor.
+// On shutdown it keeps delivering what's pending for up to drainTimeout.
+func NewForwarder(hub *ssehub.Hub, client *http.Client, replayRepository ports.ReplayRepository, redactor *Redactor, workers int, queueSize int, drainTimeout time.Duration) *Forwarder {
+	if workers <= 0 {
+		workers = defaultForwardWorkers
+	}
+	if queueSize <= 0 {
+		queueSize = defaultForwardQueueSize
+	}
+	if drainTimeout <= 0 {
+		drainTimeout = defaultForwardDrainTimeout
+	}
+
+	return &Forwarder{
+		hub:              hub,
+		client:           client,
+		replayRepository: replayRepository,
+		redactor:         redactor,
+		workers:          workers,
+		drainTimeout:     drainTimeout,
+		jobs:             make(chan forwardJob, queueSize),
+		retries:          make(map[*time.Timer]forwardJob),
+	}
+}
+
+// Run processes forward jobs until ctx is done. It then drains them: the queued jobs and the retries waiting for their
+// backoff are delivered once more, without further retries, for up to the drain timeout.
+func (f *Forwarder) Run(ctx context.Context) {
+	// Deliveries outlive ctx, they're only cut once the drain times out
+	sendCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancel()
+	stop := context.AfterFunc(ctx, func() {
+		time.AfterFunc(f.drainTimeout, cancel)
+	})
+	defer stop()
+
+	f.runWorkers(func() {
+		for ctx.Err() == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case job := <-f.jobs:
+				f.process(sendCtx, job)
+			}
+		}
+	})
+
+	f.mu.Lock()
+	f.draining = true
+	for timer, job := range f.retries {
+		timer.Stop()
+		f.enqueue(job)
+	}
+	clear(f.retries)
+	f.mu.Unlock()
+
+	f.runWorkers(func() {
+		for sendCtx.Err() == nil {
+			select {
+			case job := <-f.jobs:
+				f.process(sendCtx, job)
+			default:
+				return
+			}
+		}
+	})
+	if n := len(f.jobs); n > 0 {
+		log.Printf("[forwarder] drain timed out, dropping %d queued events", n)
+	}
+}
+
+func (f *Forwarder) runWorkers(work func()) {
+	var wg sync.WaitGroup
+	for range f.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			work()
+		}()
+	}
+	wg.Wait()
+}
+
+// Forward schedules the event to be sent to every forward rule of the room.
+func (f *Forwarder) Forward(room domain.Room, event domain.Event) {
+	for _, rule := range room.Forwards {
+		target, err := parseReplayTarget(rule.TargetURL)
+		if err != nil {
+			log.Printf("[forwarder] skip invalid target of room %s: %v", room.ID, err)
+			continue
+		}
+
+		f.enqueue(forwardJob{
+			room:    room,
+			event:   event,
+			rule:    rule,
+			target:  target,
+			attempt: 1,
+		})
+	}
+}
+
+func (f *Forwarder) enqueue(job forwardJob) {
+	select {
+	case f.jobs <- job:
+	default:
+		log.Printf("[forwarder] queue is full, dropping event %d to %s", job.event.ID, job.target)
+	}
+}
+
+func (f *Forwarder) process(ctx context.Context, job forwardJob) {
+	attempt := sendEvent(ctx, f.client, job.event, job.target, job.rule.Headers)
+	// Rule headers carry the target's credentials, they're stored and streamed redacted
+	f.redactor.RedactAttempt(job.room, &attempt)
+	attempt.Source = domain.ReplaySourceForward
+	attempt.Attempt = job.attempt
+
+	if err := f.replayRepository.Save(ctx, &attempt); err != nil {
+		log.Printf("[forwarder] failed to save attempt of event %d: %v", job.event.ID, err)
+	}
+	f.publish(job.room.ID, attempt)
+
+	maxAttempts := job.rule.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = domain.DefaultForwardAttempts
+	}
+	if !shouldRetry(attempt) || job.attempt >= maxAttempts {
+		return
+	}
+
+	// Retry later without holding a worker
+	backoff := forwardBaseBackoff << (job.attempt - 1)
+	if backoff > forwardMaxBackoff {
+		backoff = forwardMaxBackoff
+	}
+	job.attempt++
+	f.retry(job, backoff)
+}
+
+// retry enqueues the job again after backoff, unless the forwarder is draining.
+func (f *Forwarder) retry(job forwardJob, backoff time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.draining {
+		log.Printf("[forwarder] shutting down, not retrying event %d to %s", job.event.ID, job.target)
+		return
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(backoff, func() {
+		f.mu.Lock()
+		job, ok := f.retries[timer]
+		delete(f.retries, timer)
+		f.mu.Unlock()
+		if ok {
+			f.enqueue(job)
+		}
+	})
+	f.retries[timer] = job
+}
+
+func (f *Forwarder) publish(roomID string, attempt domain.ReplayAttempt) {
+	payload, err := json.Marshal(attempt)
+	if err != nil {
+		log.Printf("[forwarder] failed to marshal attempt: %v", err)
+		return
+	}
+
+	if err := f.hub.SendToRoom(roomID, ssehub.Message{
+		Event: EventTypeForward,
+		Data:  string(payload),
+	}); err != nil && err != ssehub.ErrRoomNotFound {
+		log.Printf("[forwarder] failed to send attempt to room %s: %v", roomID, err)
+	}
+}
+
+// shouldRetry tells whether a failed delivery is worth trying again.
+func shouldRetry(attempt domain.ReplayAttempt) bool {
+	if attempt.StatusCode == 0 {
+		return true // network error
+	}
+	return attempt.StatusCode == http.StatusTooManyRequests || attempt.StatusCode >= 500
+}
+
+func validateForwardRules(rules []domain.ForwardRule) error {
+	if len(rules) > domain.MaxForwardRules {
+		return fmt.Errorf("%w: at most %d rules", domain.ErrInvalidForwardRule, domain.MaxForwardRules)
+	}
+	for _, rule := range rules {
+		if _, err := parseReplayTarget(rule.TargetURL); err != nil {
+			return fmt.Errorf("%w: %v", domain.ErrInvalidForwardRule, err)
+		}
+		if rule.MaxAttempts < 0 || rule.MaxAttempts > domain.MaxForwardAttempts {
+			return fmt.Errorf("%w: max attempts must be between 0 and %d", domain.ErrInvalidForwardRule, domain.MaxForwardAttempts)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/erwin-lovecraft/pistol/internal/core/domain"
+	"github.com/erwin-lovecraft/pistol/pkg/ssehub"
+)
+
+// recordedAttempts is a ports.ReplayRepository keeping the attempts saved.
+type recordedAttempts struct {
+	mu       sync.Mutex
+	attempts []domain.ReplayAttempt
+}
+
+func (r *recordedAttempts) Save(_ context.Context, attempt *domain.ReplayAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts = append(r.attempts, *attempt)
+	return nil
+}
+
+func (r *recordedAttempts) ListByEvent(context.Context, int64, int) ([]domain.ReplayAttempt, error) {
+	return nil, nil
+}
+
+func (r *recordedAttempts) statuses() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	statuses := make([]int, len(r.attempts))
+	for idx, attempt := range r.attempts {
+		statuses[idx] = attempt.StatusCode
+	}
+	return statuses
+}
+
+func TestForwarderDrainsOnShutdown(t *testing.T) {
+	tests := []struct {
+		name string
+		// status answered to the deliveries, in order
+		status []int
+		events int
+		want   []int
+	}{
+		{
+			name:   "queued jobs",
+			status: []int{http.StatusOK, http.StatusOK, http.StatusOK},
+			events: 3,
+			want:   []int{http.StatusOK, http.StatusOK, http.StatusOK},
+		},
+		{
+			// The retry is sent at once rather than after its backoff, a failure isn't retried again
+			name:   "retries waiting for their backoff",
+			status: []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK},
+			events: 1,
+			want:   []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var served int
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				status := tt.status[served]
+				served++
+				mu.Unlock()
+				// Slow enough for the jobs to still be queued on shutdown
+				time.Sleep(20 * time.Millisecond)
+				w.WriteHeader(status)
+			}))
+			defer srv.Close()
+
+			replays := &recordedAttempts{}
+			forwarder := NewForwarder(ssehub.NewHub(), NewHTTPClient(), replays, nil, 1, 0, time.Minute)
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				forwarder.Run(ctx)
+			}()
+
+			room := domain.Room{ID: "room", Forwards: []domain.ForwardRule{{TargetURL: srv.URL}}}
+			for idx := range tt.events {
+				forwarder.Forward(room, domain.Event{ID: int64(idx + 1), Method: http.MethodPost})
+			}
+			// Wait for the first delivery to be recorded, the others are still queued or waiting to be retried
+			for len(replays.statuses()) == 0 {
+				time.Sleep(time.Millisecond)
+			}
+			cancel()
+
+			select {
+			case <-done:
+			case <-time.After(forwardBaseBackoff / 2):
+				t.Fatal("Run didn't return before the retry's backoff")
+			}
+			if got := replays.statuses(); !equalInts(got, tt.want) {
+				t.Errorf("attempts answered %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestForwarderDrainTimesOut(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	replays := &recordedAttempts{}
+	forwarder := NewForwarder(ssehub.NewHub(), NewHTTPClient(), replays, nil, 1, 0, 50*time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	room := domain.Room{ID: "room", Forwards: []domain.ForwardRule{{TargetURL: srv.URL}}}
+	forwarder.Forward(room, domain.Event{ID: 1, Method: http.MethodPost})
+	forwarder.Forward(room, domain.Event{ID: 2, Method: http.MethodPost})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		forwarder.Run(ctx)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run didn't return once the drain timed out")
+	}
+	// The delivery in flight is cut and recorded as failed, the queued one is dropped
+	if got := replays.statuses(); !equalInts(got, []int{0}) {
+		t.Errorf("attempts answered %v, want [0]", got)
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for idx := range a {
+		if a[idx] != b[idx] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"encoding/json"
+
+	"github.com/erwin-lovecraft/pistol/internal/core/domain"
+	"github.com/erwin-lovecraft/pistol/internal/core/ports"
+	"github.com/erwin-lovecraft/pistol/pkg/ssehub"
+)
+
+// StreamFilter returns the hub filter letting through the captured events matching filter, along with every other
+// kind of message such as forward attempts. It's nil when the filter has no criterion.
+func StreamFilter(filter ports.EventFilter) ssehub.Filter {
+	if filter.IsZero() {
+		return nil
+	}
+
+	return func(e ssehub.Message) bool {
+		if e.Event != EventTypeMessage {
+			return true
+		}
+		event, ok := e.Value.(domain.Event)
+		if !ok {
+			// Messages coming through the backplane only have their data
+			if err := json.Unmarshal([]byte(e.Data), &event); err != nil {
+				return false
+			}
+		}
+		return filter.Match(event)
+	}
+}
+
+func (s *service) HubRooms() []ssehub.RoomStats {
+	return s.hub.RoomStats()
+}
+
+func (s *service) HubClients(roomID string) []ssehub.ClientStats {
+	return s.hub.ClientStats(roomID)
+}
+
+func (s *service) DisconnectClient(roomID string, clientID string) error {
+	return s.hub.Disconnect(roomID, clientID)
+}
+
+func (s *service) DisconnectRoom(roomID string) int {
+	return s.hub.DisconnectRoom(roomID)
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/erwin-lovecraft/pistol/internal/core/domain"
+	"github.com/erwin-lovecraft/pistol/pkg/ssehub"
+)
+
+func (s *service) ImportEvents(ctx context.Context, roomID string, events []domain.Event, broadcast bool) (int, error) {
+	if len(events) > domain.MaxImportEvents {
+		return 0, fmt.Errorf("%w: more than %d events", domain.ErrInvalidImport, domain.MaxImportEvents)
+	}
+	// Check everything first, so a bad event doesn't leave half an import behind
+	for idx, event := range events {
+		if err := validateImportedEvent(event); err != nil {
+			return 0, fmt.Errorf("%w: event %d: %v", domain.ErrInvalidImport, idx+1, err)
+		}
+	}
+
+	room, err := s.roomRepository.GetByID(ctx, roomID)
+	if err != nil {
+		return 0, err
+	}
+
+	// Exports list the newest events first, save the oldest first so the fresh IDs follow the capture order
+	events = slices.Clone(events)
+	slices.SortStableFunc(events, func(a, b domain.Event) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	for idx, event := range events {
+		event.ID = 0
+		event.Method = strings.ToUpper(event.Method)
+		event.ContentLength = int64(len(event.Body))
+		if event.Header == nil {
+			event.Header = http.Header{}
+		}
+		if event.QueryParams == nil {
+			event.QueryParams = map[string][]string{}
+		}
+		s.sanitizeEvent(room, &event)
+
+		if err := s.eventRepository.Save(ctx, roomID, &event); err != nil {
+			return idx, fmt.Errorf("failed to save event: %w", err)
+		}
+
+		if !broadcast {
+			continue
+		}
+		msg, err := toMessage(event)
+		if err != nil {
+			return idx + 1, err
+		}
+		if err := s.hub.SendToRoom(roomID, msg); err != nil && !errors.Is(err, ssehub.ErrRoomNotFound) {
+			log.Printf("failed to send event %d to room %s: %v", event.ID, roomID, err)
+		}
+	}
+
+	return len(events), nil
+}
+
+func validateImportedEvent(event domain.Event) error {
+	if event.Method == "" {
+		return errors.New("method is required")
+	}
+	for _, c := range event.Method {
+		if !strings.ContainsRune("!#$%&'*+-.^_`|~", c) && !('0' <= c && c <= '9') && !('a' <= c && c <= 'z') && !('A' <= c && c <= 'Z') {
+			return fmt.Errorf("invalid method %q", event.Method)
+		}
+	}
+	if event.Response != nil && (event.Response.StatusCode < 100 || event.Response.StatusCode > 999) {
+		return fmt.Errorf("invalid response status %d", event.Response.StatusCode)
+	}
+	return nil
+}
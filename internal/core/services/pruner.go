@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/erwin-lovecraft/pistol/internal/core/domain"
+	"github.com/erwin-lovecraft/pistol/internal/core/ports"
+)
+
+const (
+	defaultPruneInterval  = 5 * time.Minute
+	defaultPruneBatchSize = 500
+)
+
+// PruneStats counts what the pruner deleted since it started.
+type PruneStats struct {
+	Runs          int64     `json:"runs"`
+	PrunedEvents  int64     `json:"pruned_events"`
+	PrunedBytes   int64     `json:"pruned_bytes"`
+	Failures      int64     `json:"failures"`
+	LastRunAt     time.Time `json:"last_run_at"`
+	LastRunMillis int64     `json:"last_run_ms"`
+}
+
+// Pruner periodically deletes the events falling outside the retention policies,
+// each room's policy overriding the global one.
+type Pruner struct {
+	roomRepository  ports.RoomRepository
+	eventRepository ports.EventRepository
+	policy          domain.RetentionPolicy
+	interval        time.Duration
+	batchSize       int
+
+	runs          atomic.Int64
+	prunedEvents  atomic.Int64
+	prunedBytes   atomic.Int64
+	failures      atomic.Int64
+	lastRunAt     atomic.Int64
+	lastRunMillis atomic.Int64
+}
+
+func NewPruner(roomRepository ports.RoomRepository, eventRepository ports.EventRepository, policy domain.RetentionPolicy, interval time.Duration, batchSize int) *Pruner {
+	if interval <= 0 {
+		interval = defaultPruneInterval
+	}
+	if batchSize <= 0 {
+		batchSize = defaultPruneBatchSize
+	}
+
+	return &Pruner{
+		roomRepository:  roomRepository,
+		eventRepository: eventRepository,
+		policy:          policy,
+		interval:        interval,
+		batchSize:       batchSize,
+	}
+}
+
+// Policy is the global retention policy.
+func (p *Pruner) Policy() domain.RetentionPolicy {
+	return p.policy
+}
+
+// Run prunes every interval until ctx is done.
+func (p *Pruner) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.Prune(ctx); err != nil {
+				log.Printf("failed to prune events: %v", err)
+			}
+		}
+	}
+}
+
+// Prune applies the retention policies to every room once.
+func (p *Pruner) Prune(ctx context.Context) error {
+	start := time.Now()
+	defer func() {
+		p.runs.Add(1)
+		p.lastRunAt.Store(start.UnixNano())
+		p.lastRunMillis.Store(time.Since(start).Milliseconds())
+	}()
+
+	rooms, err := p.roomRepository.List(ctx, ports.RoomFilter{})
+	if err != nil {
+		p.failures.Add(1)
+		return fmt.Errorf("failed to list rooms: %w", err)
+	}
+
+	var total ports.PruneResult
+	for _, room := range rooms {
+		policy := p.policy.Override(room.Retention)
+		if policy.IsZero() {
+			continue
+		}
+
+		result, err := p.eventRepository.Prune(ctx, room.ID, policy, p.batchSize)
+		// Batches deleted before a failure are gone all the same
+		total.Events += result.Events
+		total.Bytes += result.Bytes
+		if err != nil {
+			p.failures.Add(1)
+			log.Printf("failed to prune events of room %s: %v", room.ID, err)
+			continue
+		}
+	}
+
+	p.prunedEvents.Add(total.Events)
+	p.prunedBytes.Add(total.Bytes)
+	if total.Events > 0 {
+		log.Printf("pruned %d events (%d bytes) across %d rooms", total.Events, total.Bytes, len(rooms))
+	}
+	return nil
+}
+
+func (p *Pruner) Stats() PruneStats {
+	stats := PruneStats{
+		Runs:          p.runs.Load(),
+		PrunedEvents:  p.prunedEvents.Load(),
+		PrunedBytes:   p.prunedBytes.Load(),
+		Failures:      p.failures.Load(),
+		LastRunMillis: p.lastRunMillis.Load(),
+	}
+	if at := p.lastRunAt.Load(); at != 0 {
+		stats.LastRunAt = time.Unix(0, at).UTC()
+	}
+	return stats
+}
+
+func validateRetentionPolicy(policy domain.RetentionPolicy) error {
+	if policy.MaxAgeSeconds < 0 || policy.MaxEvents < 0 || policy.MaxBytes < 0 {
+		return fmt.Errorf("%w: limits can't be negative", domain.ErrInvalidRetentionPolicy)
+	}
+	if policy.IsZero() {
+		return fmt.Errorf("%w: no limit set", domain.ErrInvalidRetentionPolicy)
+	}
+	return nil
+}
@@ -0,0 +1,348 @@
+package services
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/erwin-lovecraft/pistol/internal/core/domain"
+	"github.com/erwin-lovecraft/pistol/pkg/jsonpath"
+)
+
+const (
+	redactionMask = "****"
+)
+
+// Redactor applies the global redaction rules, followed by the room's ones, to events before they're stored.
+type Redactor struct {
+	rules  []domain.RedactionRule
+	global []redactionRule
+}
+
+// redactionRule is a domain.RedactionRule with its pattern compiled.
+type redactionRule struct {
+	domain.RedactionRule
+	glob string
+	path *jsonpath.Expr
+	re   *regexp.Regexp
+}
+
+// NewRedactor returns a Redactor applying rules to every room, domain.DefaultRedactionRules when nil.
+func NewRedactor(rules []domain.RedactionRule) (*Redactor, error) {
+	if rules == nil {
+		rules = domain.DefaultRedactionRules()
+	}
+	compiled, err := compileRedactionRules(rules)
+	if err != nil {
+		return nil, err
+	}
+	return &Redactor{rules: rules, global: compiled}, nil
+}
+
+// Rules returns the global redaction rules.
+func (r *Redactor) Rules() []domain.RedactionRule {
+	if r == nil {
+		return []domain.RedactionRule{}
+	}
+	return r.rules
+}
+
+// Redact removes pistol's own credentials from the event and changes its fields matched by the rules, it records
+// them in event.Redacted.
+func (r *Redactor) Redact(room domain.Room, event *domain.Event) {
+	redacted := recordRedacted(event)
+	stripCredentials(event.Header, event.QueryParams, redacted)
+	if body, ok := redactMessage(r.roomRules(room), "", event.Header, event.QueryParams, event.Body, redacted); ok {
+		// ContentLength and the Content-Length header stay what was pushed, as captured
+		event.Body = body
+	}
+	sortRedacted(event.Redacted)
+}
+
+// RedactResponse changes the fields of the response stored with the event matched by the rules, it records them in
+// event.Redacted. Responses are rendered from the redacted request, this catches what the room's template adds.
+func (r *Redactor) RedactResponse(room domain.Room, event *domain.Event) {
+	if event.Response == nil {
+		return
+	}
+
+	resp := *event.Response
+	header := make(http.Header, len(resp.Headers))
+	for k, v := range resp.Headers {
+		header[k] = []string{v}
+	}
+	if body, ok := redactMessage(r.roomRules(room), "response_", header, nil, []byte(resp.Body), recordRedacted(event)); ok {
+		resp.Body = string(body)
+	}
+	resp.Headers = make(map[string]string, len(header))
+	for k, values := range header {
+		resp.Headers[k] = values[0]
+	}
+	event.Response = &resp
+	sortRedacted(event.Redacted)
+}
+
+// RedactAttempt redacts what was sent and answered when an event of the room was replayed or forwarded, as the
+// event's own fields are.
+func (r *Redactor) RedactAttempt(room domain.Room, attempt *domain.ReplayAttempt) {
+	rules := r.roomRules(room)
+	ignore := func(string, string, string) {}
+
+	attempt.RequestHeader = attempt.RequestHeader.Clone()
+	stripCredentials(attempt.RequestHeader, nil, ignore)
+	redactMessage(rules, "", attempt.RequestHeader, nil, nil, ignore)
+
+	attempt.ResponseHeader = attempt.ResponseHeader.Clone()
+	var body []byte
+	if !attempt.ResponseBodyBase64 {
+		body = []byte(attempt.ResponseBody)
+	}
+	if body, ok := redactMessage(rules, "", attempt.ResponseHeader, nil, body, ignore); ok {
+		attempt.ResponseBody = string(body)
+	}
+}
+
+// recordRedacted returns a function adding the fields it's given to event.Redacted, once each.
+func recordRedacted(event *domain.Event) func(location, name, action string) {
+	return func(location, name, action string) {
+		field := domain.RedactedField{Location: location, Name: name, Action: action}
+		if !slices.Contains(event.Redacted, field) {
+			event.Redacted = append(event.Redacted, field)
+		}
+	}
+}
+
+// sortRedacted sorts the redacted fields, maps are walked in random order and the same request must be reported the
+// same way.
+func sortRedacted(fields []domain.RedactedField) {
+	slices.SortFunc(fields, func(a, b domain.RedactedField) int {
+		return cmp.Or(strings.Compare(a.Location, b.Location), strings.Compare(a.Name, b.Name),
+			strings.Compare(a.Action, b.Action))
+	})
+}
+
+// roomRules returns the global rules followed by the room's ones.
+func (r *Redactor) roomRules(room domain.Room) []redactionRule {
+	var rules []redactionRule
+	if r != nil {
+		rules = slices.Clone(r.global)
+	}
+	if len(room.Redaction) > 0 {
+		roomRules, err := compileRedactionRules(room.Redaction)
+		if err != nil {
+			// Rules are checked when set, this only happens when the room was stored by another version
+			log.Printf("ignoring redaction rules of room %s: %v", room.ID, err)
+		}
+		rules = append(rules, roomRules...)
+	}
+	return rules
+}
+
+// stripCredentials removes the credentials a push was let in with, whatever the rules, they go no further.
+func stripCredentials(header http.Header, query map[string][]string, redacted func(location, name, action string)) {
+	for _, k := range []string{domain.RoomTokenHeader, domain.SecretKeyHeader} {
+		if _, ok := header[http.CanonicalHeaderKey(k)]; ok {
+			header.Del(k)
+			redacted("header", http.CanonicalHeaderKey(k), domain.RedactRemove)
+		}
+	}
+	for _, k := range []string{domain.RoomTokenParam, domain.SecretKeyParam} {
+		if _, ok := query[k]; ok {
+			delete(query, k)
+			redacted("query", k, domain.RedactRemove)
+		}
+	}
+}
+
+// redactMessage applies the rules to the header and query, changed in place, and to the body. The fields changed are
+// reported with their location prefixed. It returns the redacted body and whether it changed.
+func redactMessage(rules []redactionRule, prefix string, header http.Header, query map[string][]string, body []byte,
+	redacted func(location, name, action string)) ([]byte, bool) {
+	// Names first, the first rule matching a header or query param wins
+	for k, values := range header {
+		for _, rule := range rules {
+			if rule.Target != domain.RedactHeader || !rule.matchName(k) {
+				continue
+			}
+			if rule.Action == domain.RedactRemove {
+				delete(header, k)
+			} else {
+				header[k] = redactValues(values, rule.Action)
+			}
+			redacted(prefix+"header", k, rule.Action)
+			break
+		}
+	}
+	for k, values := range query {
+		for _, rule := range rules {
+			if rule.Target != domain.RedactQuery || !rule.matchName(k) {
+				continue
+			}
+			if rule.Action == domain.RedactRemove {
+				delete(query, k)
+			} else {
+				query[k] = redactValues(values, rule.Action)
+			}
+			redacted(prefix+"query", k, rule.Action)
+			break
+		}
+	}
+
+	var bodyChanged bool
+	for _, rule := range rules {
+		if rule.Target != domain.RedactBodyPath {
+			continue
+		}
+		rs, n, err := redactJSONBody(body, rule)
+		if err != nil {
+			// Not JSON, no path can match
+			break
+		}
+		if n > 0 {
+			body, bodyChanged = rs, true
+			redacted(prefix+"body", rule.Pattern, rule.Action)
+		}
+	}
+
+	for _, rule := range rules {
+		if rule.Target != domain.RedactValue {
+			continue
+		}
+		for k, values := range header {
+			if rs, ok := rule.replaceAll(values); ok {
+				header[k] = rs
+				redacted(prefix+"header", k, rule.Action)
+			}
+		}
+		for k, values := range query {
+			if rs, ok := rule.replaceAll(values); ok {
+				query[k] = rs
+				redacted(prefix+"query", k, rule.Action)
+			}
+		}
+		// Binary bodies have no text to look into
+		if utf8.Valid(body) && rule.re.Match(body) {
+			body = rule.re.ReplaceAllFunc(body, func(b []byte) []byte {
+				return []byte(redactString(string(b), rule.Action))
+			})
+			bodyChanged = true
+			redacted(prefix+"body", rule.Pattern, rule.Action)
+		}
+	}
+	return body, bodyChanged
+}
+
+func (rule redactionRule) matchName(name string) bool {
+	ok, _ := path.Match(rule.glob, strings.ToLower(name))
+	return ok
+}
+
+// replaceAll redacts the parts of values matching the rule's pattern, it reports whether any did.
+func (rule redactionRule) replaceAll(values []string) ([]string, bool) {
+	var found bool
+	rs := make([]string, len(values))
+	for i, v := range values {
+		rs[i] = rule.re.ReplaceAllStringFunc(v, func(s string) string {
+			found = true
+			return redactString(s, rule.Action)
+		})
+	}
+	return rs, found
+}
+
+// redactJSONBody applies a path rule to a JSON body where the values are, it returns the body and how many values
+// matched, the body unchanged when none did. It fails when the body isn't a single JSON document.
+func redactJSONBody(body []byte, rule redactionRule) ([]byte, int, error) {
+	return rule.path.UpdateJSON(body, func(raw []byte) ([]byte, bool) {
+		if rule.Action == domain.RedactRemove {
+			return nil, false
+		}
+		// Strings are redacted as their text, other values as their JSON
+		s := string(raw)
+		if raw[0] == '"' {
+			_ = json.Unmarshal(raw, &s)
+		}
+		rs, _ := json.Marshal(redactString(s, rule.Action))
+		return rs, true
+	})
+}
+
+func redactValues(values []string, action string) []string {
+	rs := make([]string, len(values))
+	for i, v := range values {
+		rs[i] = redactString(v, action)
+	}
+	return rs
+}
+
+func redactString(s string, action string) string {
+	switch action {
+	case domain.RedactRemove:
+		return ""
+	case domain.RedactHash:
+		sum := sha256.Sum256([]byte(s))
+		return "sha256:" + hex.EncodeToString(sum[:])
+	default:
+		return redactionMask
+	}
+}
+
+func compileRedactionRules(rules []domain.RedactionRule) ([]redactionRule, error) {
+	compiled := make([]redactionRule, 0, len(rules))
+	for idx, rule := range rules {
+		if rule.Action == "" {
+			rule.Action = domain.RedactMask
+		}
+		if !slices.Contains([]string{domain.RedactRemove, domain.RedactMask, domain.RedactHash}, rule.Action) {
+			return nil, fmt.Errorf("%w: rule %d: action must be remove, mask or hash", domain.ErrInvalidRedactionRule, idx+1)
+		}
+		if rule.Pattern == "" {
+			return nil, fmt.Errorf("%w: rule %d: pattern is required", domain.ErrInvalidRedactionRule, idx+1)
+		}
+
+		c := redactionRule{RedactionRule: rule}
+		switch rule.Target {
+		case domain.RedactHeader, domain.RedactQuery:
+			c.glob = strings.ToLower(rule.Pattern)
+			if _, err := path.Match(c.glob, ""); err != nil {
+				return nil, fmt.Errorf("%w: rule %d: invalid glob %q", domain.ErrInvalidRedactionRule, idx+1, rule.Pattern)
+			}
+		case domain.RedactBodyPath:
+			expr, err := jsonpath.Parse(rule.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("%w: rule %d: %v", domain.ErrInvalidRedactionRule, idx+1, err)
+			}
+			if !expr.IsPath() {
+				return nil, fmt.Errorf("%w: rule %d: body path can't have a comparison", domain.ErrInvalidRedactionRule, idx+1)
+			}
+			c.path = expr
+		case domain.RedactValue:
+			re, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("%w: rule %d: %v", domain.ErrInvalidRedactionRule, idx+1, err)
+			}
+			c.re = re
+		default:
+			return nil, fmt.Errorf("%w: rule %d: target must be header, query, body_path or value", domain.ErrInvalidRedactionRule, idx+1)
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled, nil
+}
+
+func validateRedactionRules(rules []domain.RedactionRule) error {
+	if len(rules) > domain.MaxRedactionRules {
+		return fmt.Errorf("%w: at most %d rules", domain.ErrInvalidRedactionRule, domain.MaxRedactionRules)
+	}
+	_, err := compileRedactionRules(rules)
+	return err
+}
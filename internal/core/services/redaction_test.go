@@ -0,0 +1,238 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/erwin-lovecraft/pistol/internal/core/domain"
+)
+
+func TestRedactorRedact(t *testing.T) {
+	sum := sha256.Sum256([]byte("4111111111111111"))
+	cardHash := "sha256:" + hex.EncodeToString(sum[:])
+
+	redactor, err := NewRedactor(nil)
+	if err != nil {
+		t.Fatalf("new redactor: %v", err)
+	}
+	room := domain.Room{
+		ID: "room",
+		Redaction: []domain.RedactionRule{
+			{Target: domain.RedactHeader, Pattern: "X-*-Token"},
+			{Target: domain.RedactQuery, Pattern: "sig", Action: domain.RedactHash},
+			{Target: domain.RedactBodyPath, Pattern: "$.card.number", Action: domain.RedactHash},
+			{Target: domain.RedactBodyPath, Pattern: "$.card.cvv", Action: domain.RedactRemove},
+			{Target: domain.RedactValue, Pattern: `sk_live_\w+`},
+		},
+	}
+	event := domain.Event{
+		Header: http.Header{
+			"Authorization":  {"Bearer secret"},
+			"X-Pistol-Token": {"pst_ingest"},
+			"X-Github-Token": {"ghp_1", "ghp_2"},
+			"X-Note":         {"key sk_live_abc here"},
+			"Content-Type":   {"application/json"},
+			"Content-Length": {"75"},
+		},
+		QueryParams: map[string][]string{
+			"token":        {"pst_ingest"},
+			"x-api-secret": {"k"},
+			"sig":          {"abc"},
+			"page":         {"2"},
+		},
+		Body:          []byte(`{"card": {"number": "4111111111111111", "cvv": 123}, "note": "sk_live_xyz"}`),
+		ContentLength: 75,
+	}
+	redactor.Redact(room, &event)
+
+	wantBody := `{"card": {"number": "` + cardHash + `"}, "note": "****"}`
+	wantHeader := http.Header{
+		"X-Github-Token": {"****", "****"},
+		"X-Note":         {"key **** here"},
+		"Content-Type":   {"application/json"},
+		"Content-Length": {"75"},
+	}
+	if !reflect.DeepEqual(event.Header, wantHeader) {
+		t.Errorf("header = %v, want %v", event.Header, wantHeader)
+	}
+	sigSum := sha256.Sum256([]byte("abc"))
+	wantQuery := map[string][]string{"sig": {"sha256:" + hex.EncodeToString(sigSum[:])}, "page": {"2"}}
+	if !reflect.DeepEqual(event.QueryParams, wantQuery) {
+		t.Errorf("query = %v, want %v", event.QueryParams, wantQuery)
+	}
+	if string(event.Body) != wantBody {
+		t.Errorf("body = %s, want %s", event.Body, wantBody)
+	}
+	if event.ContentLength != 75 {
+		t.Errorf("content length = %d, want the pushed 75", event.ContentLength)
+	}
+
+	// Sorted, so that the same request is always reported the same way
+	wantRedacted := []domain.RedactedField{
+		{Location: "body", Name: "$.card.cvv", Action: domain.RedactRemove},
+		{Location: "body", Name: "$.card.number", Action: domain.RedactHash},
+		{Location: "body", Name: `sk_live_\w+`, Action: domain.RedactMask},
+		{Location: "header", Name: "Authorization", Action: domain.RedactRemove},
+		{Location: "header", Name: "X-Github-Token", Action: domain.RedactMask},
+		{Location: "header", Name: "X-Note", Action: domain.RedactMask},
+		{Location: "header", Name: "X-Pistol-Token", Action: domain.RedactRemove},
+		{Location: "query", Name: "sig", Action: domain.RedactHash},
+		{Location: "query", Name: "token", Action: domain.RedactRemove},
+		{Location: "query", Name: "x-api-secret", Action: domain.RedactRemove},
+	}
+	if !reflect.DeepEqual(event.Redacted, wantRedacted) {
+		t.Errorf("redacted = %+v, want %+v", event.Redacted, wantRedacted)
+	}
+}
+
+func TestRedactorRedactKeepsOtherBodies(t *testing.T) {
+	redactor, err := NewRedactor([]domain.RedactionRule{{Target: domain.RedactBodyPath, Pattern: "$.secret"}})
+	if err != nil {
+		t.Fatalf("new redactor: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "no match", body: `{"public":  "a"}`},
+		{name: "not json", body: `secret=a`},
+		{name: "several documents", body: `{"secret": "a"} {"secret": "b"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := domain.Event{Header: http.Header{}, Body: []byte(tt.body), ContentLength: int64(len(tt.body))}
+			redactor.Redact(domain.Room{}, &event)
+			if string(event.Body) != tt.body || event.ContentLength != int64(len(tt.body)) || len(event.Redacted) > 0 {
+				t.Errorf("redacted to %s (%d bytes, %+v), want it unchanged", event.Body, event.ContentLength, event.Redacted)
+			}
+		})
+	}
+}
+
+func TestRedactorRedactResponse(t *testing.T) {
+	redactor, err := NewRedactor([]domain.RedactionRule{
+		{Target: domain.RedactHeader, Pattern: "Set-Cookie", Action: domain.RedactRemove},
+		{Target: domain.RedactBodyPath, Pattern: "$.token"},
+	})
+	if err != nil {
+		t.Fatalf("new redactor: %v", err)
+	}
+
+	// Fields redacted from the request are kept and the response's are added
+	redacted := []domain.RedactedField{{Location: "query", Name: "token", Action: domain.RedactRemove}}
+	event := domain.Event{
+		Redacted: redacted,
+		Response: &domain.EventResponse{
+			StatusCode: http.StatusOK,
+			Headers:    map[string]string{"Set-Cookie": "session=1", "X-Request-ID": "abc"},
+			Body:       `{"token": "t0k3n", "ok": true}`,
+		},
+	}
+	rendered := event.Response
+	redactor.RedactResponse(domain.Room{}, &event)
+
+	want := domain.EventResponse{
+		StatusCode: http.StatusOK,
+		Headers:    map[string]string{"X-Request-ID": "abc"},
+		Body:       `{"token": "****", "ok": true}`,
+	}
+	if !reflect.DeepEqual(*event.Response, want) {
+		t.Errorf("response = %+v, want %+v", *event.Response, want)
+	}
+	if rendered.Body != `{"token": "t0k3n", "ok": true}` {
+		t.Error("the rendered response, sent to the client, was changed")
+	}
+	wantRedacted := []domain.RedactedField{
+		{Location: "query", Name: "token", Action: domain.RedactRemove},
+		{Location: "response_body", Name: "$.token", Action: domain.RedactMask},
+		{Location: "response_header", Name: "Set-Cookie", Action: domain.RedactRemove},
+	}
+	if !reflect.DeepEqual(event.Redacted, wantRedacted) {
+		t.Errorf("redacted = %+v, want %+v", event.Redacted, wantRedacted)
+	}
+
+	noResponse := domain.Event{}
+	redactor.RedactResponse(domain.Room{}, &noResponse)
+	if noResponse.Response != nil || noResponse.Redacted != nil {
+		t.Errorf("redacted an event without response to %+v", noResponse)
+	}
+}
+
+func TestRedactorRedactAttempt(t *testing.T) {
+	redactor, err := NewRedactor(nil)
+	if err != nil {
+		t.Fatalf("new redactor: %v", err)
+	}
+	room := domain.Room{Redaction: []domain.RedactionRule{{Target: domain.RedactValue, Pattern: `sk_live_\w+`}}}
+
+	sent := http.Header{"Authorization": {"Bearer secret"}, "X-Pistol-Token": {"pst_ingest"}, "Accept": {"*/*"}}
+	attempt := domain.ReplayAttempt{
+		RequestHeader:  sent,
+		ResponseHeader: http.Header{"X-Api-Key": {"sk_live_abc"}, "X-Echo": {"sk_live_abc"}},
+		ResponseBody:   `{"key": "sk_live_abc"}`,
+	}
+	redactor.RedactAttempt(room, &attempt)
+
+	if want := (http.Header{"Accept": {"*/*"}}); !reflect.DeepEqual(attempt.RequestHeader, want) {
+		t.Errorf("request header = %v, want %v", attempt.RequestHeader, want)
+	}
+	if len(sent) != 3 {
+		t.Error("the header the request was sent with was changed")
+	}
+	if want := (http.Header{"X-Echo": {"****"}}); !reflect.DeepEqual(attempt.ResponseHeader, want) {
+		t.Errorf("response header = %v, want %v", attempt.ResponseHeader, want)
+	}
+	if want := `{"key": "****"}`; attempt.ResponseBody != want {
+		t.Errorf("response body = %s, want %s", attempt.ResponseBody, want)
+	}
+
+	// Binary bodies are stored as base64, there's no text to look into
+	binary := domain.ReplayAttempt{ResponseBody: "c2tfbGl2ZV9hYmM=", ResponseBodyBase64: true}
+	redactor.RedactAttempt(room, &binary)
+	if binary.ResponseBody != "c2tfbGl2ZV9hYmM=" {
+		t.Errorf("response body = %s, want it unchanged", binary.ResponseBody)
+	}
+}
+
+func TestValidateRedactionRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  domain.RedactionRule
+		valid bool
+	}{
+		{name: "header", rule: domain.RedactionRule{Target: domain.RedactHeader, Pattern: "X-*-Token"}, valid: true},
+		{name: "body path", rule: domain.RedactionRule{Target: domain.RedactBodyPath, Pattern: "$.items[*].card", Action: domain.RedactHash}, valid: true},
+		{name: "value", rule: domain.RedactionRule{Target: domain.RedactValue, Pattern: `\d{16}`, Action: domain.RedactRemove}, valid: true},
+		{name: "no pattern", rule: domain.RedactionRule{Target: domain.RedactHeader}},
+		{name: "unknown target", rule: domain.RedactionRule{Target: "cookie", Pattern: "session"}},
+		{name: "unknown action", rule: domain.RedactionRule{Target: domain.RedactHeader, Pattern: "X-Token", Action: "encrypt"}},
+		{name: "invalid glob", rule: domain.RedactionRule{Target: domain.RedactQuery, Pattern: "[a"}},
+		{name: "invalid path", rule: domain.RedactionRule{Target: domain.RedactBodyPath, Pattern: "card.number"}},
+		{name: "path with a comparison", rule: domain.RedactionRule{Target: domain.RedactBodyPath, Pattern: `$.card == "x"`}},
+		{name: "invalid regular expression", rule: domain.RedactionRule{Target: domain.RedactValue, Pattern: "(sk_"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRedactionRules([]domain.RedactionRule{tt.rule})
+			if tt.valid && err != nil {
+				t.Errorf("validate: %v", err)
+			}
+			if !tt.valid && !errors.Is(err, domain.ErrInvalidRedactionRule) {
+				t.Errorf("validate = %v, want %v", err, domain.ErrInvalidRedactionRule)
+			}
+		})
+	}
+
+	tooMany := make([]domain.RedactionRule, domain.MaxRedactionRules+1)
+	for idx := range tooMany {
+		tooMany[idx] = domain.RedactionRule{Target: domain.RedactHeader, Pattern: "X-Token"}
+	}
+	if err := validateRedactionRules(tooMany); !errors.Is(err, domain.ErrInvalidRedactionRule) {
+		t.Errorf("validate %d rules = %v, want %v", len(tooMany), err, domain.ErrInvalidRedactionRule)
+	}
+}
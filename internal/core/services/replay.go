@@ -0,0 +1,146 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+	"unicode/utf8"
+
+	"github.com/erwin-lovecraft/pistol/internal/core/domain"
+)
+
+const (
+	replayTimeout         = 30 * time.Second
+	maxReplayResponseBody = 1 << 20
+	replayAttemptsLimit   = 50
+)
+
+var (
+	// hopHeaders are meaningful for a single connection only and must not be replayed
+	hopHeaders = []string{
+		"Connection", "Keep-Alive", "Proxy-Connection", "Proxy-Authenticate", "Proxy-Authorization",
+		"Te", "Trailer", "Transfer-Encoding", "Upgrade", "Content-Length", "Host", "Accept-Encoding",
+	}
+)
+
+func (s *service) ReplayEvent(ctx context.Context, roomID string, eventID int64, req domain.ReplayRequest) (domain.ReplayAttempt, error) {
+	target, err := parseReplayTarget(req.TargetURL)
+	if err != nil {
+		return domain.ReplayAttempt{}, err
+	}
+
+	room, err := s.roomRepository.GetByID(ctx, roomID)
+	if err != nil {
+		return domain.ReplayAttempt{}, err
+	}
+	event, err := s.eventRepository.Get(ctx, roomID, eventID)
+	if err != nil {
+		return domain.ReplayAttempt{}, err
+	}
+
+	attempt := sendEvent(ctx, s.httpClient, event, target, req.Headers)
+	// Overrides often carry the target's credentials, they're redacted like the pushed headers
+	s.redactor.RedactAttempt(room, &attempt)
+	attempt.Source = domain.ReplaySourceManual
+	attempt.Attempt = 1
+	if err := s.replayRepository.Save(ctx, &attempt); err != nil {
+		return domain.ReplayAttempt{}, fmt.Errorf("failed to save replay attempt: %w", err)
+	}
+
+	return attempt, nil
+}
+
+func (s *service) ListReplayAttempts(ctx context.Context, roomID string, eventID int64) ([]domain.ReplayAttempt, error) {
+	// Make sure the event belongs to the room
+	if _, err := s.eventRepository.Get(ctx, roomID, eventID); err != nil {
+		return nil, err
+	}
+
+	return s.replayRepository.ListByEvent(ctx, eventID, replayAttemptsLimit)
+}
+
+func parseReplayTarget(raw string) (*url.URL, error) {
+	target, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidReplayTarget, err)
+	}
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return nil, fmt.Errorf("%w: scheme must be http or https", domain.ErrInvalidReplayTarget)
+	}
+	if target.Host == "" {
+		return nil, fmt.Errorf("%w: host is required", domain.ErrInvalidReplayTarget)
+	}
+	return target, nil
+}
+
+// sendEvent sends the captured event to target with its original method, headers, query params
+// and body, and records how the target answered. Failures are reported in the attempt.
+func sendEvent(ctx context.Context, client *http.Client, event domain.Event, target *url.URL, headerOverrides map[string]string) domain.ReplayAttempt {
+	attempt := domain.ReplayAttempt{
+		EventID:   event.ID,
+		TargetURL: target.String(),
+	}
+
+	u := *target
+	query := u.Query()
+	for k, vs := range event.QueryParams {
+		for _, v := range vs {
+			query.Add(k, v)
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	ctx, cancel := context.WithTimeout(ctx, replayTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, event.Method, u.String(), bytes.NewReader(event.Body))
+	if err != nil {
+		attempt.Error = fmt.Sprintf("build request: %v", err)
+		return attempt
+	}
+	req.Header = event.Header.Clone()
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+	for _, k := range hopHeaders {
+		req.Header.Del(k)
+	}
+	for k, v := range headerOverrides {
+		if v == "" {
+			req.Header.Del(k)
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+	attempt.RequestHeader = req.Header
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		attempt.LatencyMS = time.Since(start).Milliseconds()
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxReplayResponseBody))
+	attempt.LatencyMS = time.Since(start).Milliseconds()
+	attempt.StatusCode = resp.StatusCode
+	attempt.ResponseHeader = resp.Header
+	if err != nil {
+		attempt.Error = fmt.Sprintf("read response body: %v", err)
+	}
+	if utf8.Valid(body) {
+		attempt.ResponseBody = string(body)
+	} else {
+		attempt.ResponseBody = base64.StdEncoding.EncodeToString(body)
+		attempt.ResponseBodyBase64 = true
+	}
+
+	return attempt
+}
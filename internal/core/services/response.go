@@ -0,0 +1,123 @@
+package services
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/erwin-lovecraft/pistol/internal/core/domain"
+)
+
+// responseTemplateData is what a room's response body template is rendered against.
+type responseTemplateData struct {
+	RoomID string
+	Method string
+	Header http.Header
+	Query  url.Values
+	Body   string
+}
+
+// validateResponseRule checks the rule, it returns its parsed body template.
+func validateResponseRule(rule domain.ResponseRule) (*template.Template, error) {
+	if rule.StatusCode < 100 || rule.StatusCode > 599 {
+		return nil, fmt.Errorf("%w: status code %d out of range", domain.ErrInvalidResponseRule, rule.StatusCode)
+	}
+	if rule.DelayMS < 0 || rule.DelayMS > domain.MaxResponseDelayMS {
+		return nil, fmt.Errorf("%w: delay must be between 0 and %dms", domain.ErrInvalidResponseRule, domain.MaxResponseDelayMS)
+	}
+	for k := range rule.Headers {
+		if k == "" || strings.ContainsAny(k, " :\r\n") {
+			return nil, fmt.Errorf("%w: invalid header name %q", domain.ErrInvalidResponseRule, k)
+		}
+	}
+	tpl, err := template.New("response").Parse(rule.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: body template: %v", domain.ErrInvalidResponseRule, err)
+	}
+	return tpl, nil
+}
+
+// responseTemplates keeps the rooms' parsed body templates, so that pushes don't parse them again.
+type responseTemplates struct {
+	mu     sync.RWMutex
+	byRoom map[string]responseTemplate
+}
+
+type responseTemplate struct {
+	body string
+	tpl  *template.Template
+}
+
+func newResponseTemplates() *responseTemplates {
+	return &responseTemplates{byRoom: make(map[string]responseTemplate)}
+}
+
+func (c *responseTemplates) set(roomID string, body string, tpl *template.Template) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.byRoom[roomID] = responseTemplate{body: body, tpl: tpl}
+}
+
+// get returns the room's parsed body template, parsing it when it was set by another instance or before a restart.
+func (c *responseTemplates) get(roomID string, body string) (*template.Template, error) {
+	c.mu.RLock()
+	cached, ok := c.byRoom[roomID]
+	c.mu.RUnlock()
+	if ok && cached.body == body {
+		return cached.tpl, nil
+	}
+
+	tpl, err := template.New("response").Parse(body)
+	if err != nil {
+		return nil, err
+	}
+	c.set(roomID, body, tpl)
+	return tpl, nil
+}
+
+func (c *responseTemplates) forget(roomID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.byRoom, roomID)
+}
+
+// renderResponse builds the response a room sends back for the event.
+func (c *responseTemplates) renderResponse(room domain.Room, event domain.Event) domain.EventResponse {
+	rule := domain.DefaultResponseRule
+	if room.Response != nil {
+		rule = *room.Response
+	}
+
+	resp := domain.EventResponse{
+		StatusCode: rule.StatusCode,
+		Headers:    maps.Clone(rule.Headers),
+		DelayMS:    rule.DelayMS,
+	}
+
+	tpl, err := c.get(room.ID, rule.Body)
+	if err != nil {
+		resp.StatusCode = http.StatusInternalServerError
+		resp.Body = fmt.Sprintf("parse response template: %v", err)
+		return resp
+	}
+
+	var sb strings.Builder
+	if err := tpl.Execute(&sb, responseTemplateData{
+		RoomID: room.ID,
+		Method: event.Method,
+		Header: event.Header,
+		Query:  event.QueryParams,
+		Body:   string(event.Body),
+	}); err != nil {
+		resp.StatusCode = http.StatusInternalServerError
+		resp.Body = fmt.Sprintf("render response template: %v", err)
+		return resp
+	}
+	resp.Body = sb.String()
+
+	return resp
+}
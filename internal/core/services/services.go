@@ -3,9 +3,15 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"maps"
 	"net/http"
-	"slices"
+	"strconv"
+	"text/template"
+	"time"
+	"unicode/utf8"
 
 	"github.com/erwin-lovecraft/pistol/internal/core/domain"
 	"github.com/erwin-lovecraft/pistol/internal/core/ports"
@@ -17,29 +23,143 @@ var (
 	uuidFunc = uuid.New
 )
 
+const (
+	replayPageSize = 100
+	exportPageSize = 100
+	// EventTypeMessage is the SSE event type of captured events
+	EventTypeMessage = "message"
+)
+
 type Service interface {
-	ListenEvents(ctx context.Context, roomID string, w http.ResponseWriter) (*ssehub.Client, error)
+	// CreateRoom creates the room along with its first ingest and read tokens
+	CreateRoom(ctx context.Context, name, avatar string) (domain.Room, string, []domain.IssuedToken, error)
+
+	GetRoom(ctx context.Context, roomID string) (domain.Room, error)
+
+	ListRoom(ctx context.Context) ([]domain.Room, error)
+
+	RenameRoom(ctx context.Context, roomID string, name string) (domain.Room, error)
+
+	SetRoomResponse(ctx context.Context, roomID string, rule *domain.ResponseRule) (domain.Room, error)
+
+	SetRoomForwards(ctx context.Context, roomID string, rules []domain.ForwardRule) (domain.Room, error)
+
+	SetRoomSignature(ctx context.Context, roomID string, profile *domain.SignatureProfile) (domain.Room, error)
+
+	SetRoomRetention(ctx context.Context, roomID string, policy *domain.RetentionPolicy) (domain.Room, error)
+
+	// SetRoomRedaction replaces the redaction rules the room adds to the global ones
+	SetRoomRedaction(ctx context.Context, roomID string, rules []domain.RedactionRule) (domain.Room, error)
+
+	DeleteRoom(ctx context.Context, roomID string) error
+
+	// Retention returns the global retention policy and what was pruned so far
+	Retention() (domain.RetentionPolicy, PruneStats)
+
+	// RedactionRules returns the global redaction rules
+	RedactionRules() []domain.RedactionRule
+
+	CreateRoomToken(ctx context.Context, roomID string, name string, scope string, expiresAt *time.Time) (domain.IssuedToken, error)
+
+	ListRoomTokens(ctx context.Context, roomID string) ([]domain.RoomToken, error)
 
-	PushEvent(ctx context.Context, roomID string, event domain.Event) error
+	RotateRoomToken(ctx context.Context, roomID string, tokenID int64) (domain.IssuedToken, error)
+
+	RevokeRoomToken(ctx context.Context, roomID string, tokenID int64) (domain.RoomToken, error)
+
+	VerifyRoomToken(ctx context.Context, roomID string, scope string, token string) error
+
+	// ListenEvents subscribes a client to the room's events, streamed over t
+	ListenEvents(ctx context.Context, roomID string, opts ListenOptions, t ssehub.Transport) (*ssehub.Client, error)
+
+	// HubRooms lists the rooms with SSE clients connected to this instance, along with their clients
+	HubRooms() []ssehub.RoomStats
+
+	// HubClients lists the SSE clients of the room connected to this instance
+	HubClients(roomID string) []ssehub.ClientStats
+
+	// DisconnectClient closes an SSE client of the room connected to this instance
+	DisconnectClient(roomID string, clientID string) error
+
+	// DisconnectRoom closes every SSE client of the room connected to this instance and returns how many there were
+	DisconnectRoom(roomID string) int
+
+	PushEvent(ctx context.Context, roomID string, event domain.Event) (domain.EventResponse, error)
+
+	ListEvents(ctx context.Context, roomID string, filter ports.EventFilter, cursor ports.EventCursor) ([]domain.Event, bool, error)
+
+	// ExportEvents calls fn with every event of the room matching the filter, newest first, a page at a time
+	ExportEvents(ctx context.Context, roomID string, filter ports.EventFilter, fn func(domain.Event) error) error
+
+	// ImportEvents stores previously captured events in the room under fresh IDs, keeping their capture time,
+	// and sends them to the room's listeners when broadcast is set. It returns how many were stored.
+	ImportEvents(ctx context.Context, roomID string, events []domain.Event, broadcast bool) (int, error)
+
+	ReplayEvent(ctx context.Context, roomID string, eventID int64, req domain.ReplayRequest) (domain.ReplayAttempt, error)
+
+	ListReplayAttempts(ctx context.Context, roomID string, eventID int64) ([]domain.ReplayAttempt, error)
+}
 
-	ListEvents(ctx context.Context, roomID string, page int, size int) ([]domain.Event, bool, error)
+type ListenOptions struct {
+	// LastEventID is the ID of the last event the client received, events after it are replayed
+	LastEventID string
+	// DeliveryPolicy overrides the hub's policy for slow clients when set
+	DeliveryPolicy ssehub.DeliveryPolicy
+	// RemoteAddr and UserAgent tell who the client is, for the hub's stats
+	RemoteAddr string
+	UserAgent  string
+	// Filter keeps the captured events not matching it from being queued for the client
+	Filter ports.EventFilter
 }
 
 type service struct {
-	hub             *ssehub.Hub
-	roomRepository  ports.RoomRepository
-	eventRepository ports.EventRepository
+	hub              *ssehub.Hub
+	httpClient       *http.Client
+	roomRepository   ports.RoomRepository
+	eventRepository  ports.EventRepository
+	replayRepository ports.ReplayRepository
+	tokenRepository  ports.TokenRepository
+	forwarder        *Forwarder
+	pruner           *Pruner
+	redactor         *Redactor
+	responses        *responseTemplates
 }
 
-func NewService(roomRepository ports.RoomRepository, eventRepository ports.EventRepository) Service {
+func NewService(
+	hub *ssehub.Hub,
+	roomRepository ports.RoomRepository,
+	eventRepository ports.EventRepository,
+	replayRepository ports.ReplayRepository,
+	tokenRepository ports.TokenRepository,
+	forwarder *Forwarder,
+	pruner *Pruner,
+	redactor *Redactor,
+) Service {
 	return &service{
-		hub:             ssehub.NewHub(),
-		eventRepository: eventRepository,
-		roomRepository:  roomRepository,
+		hub:              hub,
+		httpClient:       NewHTTPClient(),
+		eventRepository:  eventRepository,
+		roomRepository:   roomRepository,
+		replayRepository: replayRepository,
+		tokenRepository:  tokenRepository,
+		forwarder:        forwarder,
+		pruner:           pruner,
+		redactor:         redactor,
+		responses:        newResponseTemplates(),
 	}
 }
 
-func (s *service) CreateRoom(ctx context.Context, name, avatar string) (domain.Room, string, error) {
+// NewHTTPClient returns the client used to send captured events to other services.
+func NewHTTPClient() *http.Client {
+	return &http.Client{
+		// Record what the target answered rather than where it points to
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func (s *service) CreateRoom(ctx context.Context, name, avatar string) (domain.Room, string, []domain.IssuedToken, error) {
 	id := uuidFunc()
 	s.hub.NewRoom(id.String())
 
@@ -48,25 +168,140 @@ func (s *service) CreateRoom(ctx context.Context, name, avatar string) (domain.R
 		Name:   name,
 		Avatar: avatar,
 	}
-	if err := s.roomRepository.SaveRoom(ctx, room); err != nil {
-		return domain.Room{}, "", err
+	scopes := []string{domain.TokenScopeIngest, domain.TokenScopeRead}
+	tokens := make([]domain.RoomToken, len(scopes))
+	values := make([]string, len(scopes))
+	for idx, scope := range scopes {
+		token, value, err := newToken(room.ID, "default", scope, nil)
+		if err != nil {
+			return domain.Room{}, "", nil, err
+		}
+		tokens[idx], values[idx] = token, value
+	}
+	if err := s.roomRepository.CreateRoom(ctx, &room, tokens); err != nil {
+		return domain.Room{}, "", nil, err
+	}
+
+	issued := make([]domain.IssuedToken, len(tokens))
+	for idx, token := range tokens {
+		issued[idx] = domain.IssuedToken{
+			RoomToken: token,
+			Token:     values[idx],
+		}
 	}
 
-	return room, buildRoomLink(room), nil
+	return room, buildRoomLink(room), issued, nil
+}
+
+func (s *service) GetRoom(ctx context.Context, roomID string) (domain.Room, error) {
+	return s.roomRepository.GetByID(ctx, roomID)
 }
 
 func (s *service) ListRoom(ctx context.Context) ([]domain.Room, error) {
 	return s.roomRepository.List(ctx, ports.RoomFilter{})
 }
 
+func (s *service) RenameRoom(ctx context.Context, roomID string, name string) (domain.Room, error) {
+	return s.roomRepository.UpdateName(ctx, roomID, name)
+}
+
+func (s *service) SetRoomResponse(ctx context.Context, roomID string, rule *domain.ResponseRule) (domain.Room, error) {
+	var tpl *template.Template
+	if rule != nil {
+		var err error
+		if tpl, err = validateResponseRule(*rule); err != nil {
+			return domain.Room{}, err
+		}
+	}
+
+	room, err := s.roomRepository.UpdateResponse(ctx, roomID, rule)
+	if err != nil {
+		return domain.Room{}, err
+	}
+
+	if rule == nil {
+		s.responses.forget(roomID)
+	} else {
+		s.responses.set(roomID, rule.Body, tpl)
+	}
+	return room, nil
+}
+
+func (s *service) SetRoomForwards(ctx context.Context, roomID string, rules []domain.ForwardRule) (domain.Room, error) {
+	if err := validateForwardRules(rules); err != nil {
+		return domain.Room{}, err
+	}
+
+	return s.roomRepository.UpdateForwards(ctx, roomID, rules)
+}
+
+func (s *service) SetRoomSignature(ctx context.Context, roomID string, profile *domain.SignatureProfile) (domain.Room, error) {
+	if profile != nil {
+		if err := validateSignatureProfile(*profile); err != nil {
+			return domain.Room{}, err
+		}
+	}
+
+	return s.roomRepository.UpdateSignature(ctx, roomID, profile)
+}
+
+func (s *service) SetRoomRetention(ctx context.Context, roomID string, policy *domain.RetentionPolicy) (domain.Room, error) {
+	if policy != nil {
+		if err := validateRetentionPolicy(*policy); err != nil {
+			return domain.Room{}, err
+		}
+	}
+
+	return s.roomRepository.UpdateRetention(ctx, roomID, policy)
+}
+
+func (s *service) SetRoomRedaction(ctx context.Context, roomID string, rules []domain.RedactionRule) (domain.Room, error) {
+	if err := validateRedactionRules(rules); err != nil {
+		return domain.Room{}, err
+	}
+
+	return s.roomRepository.UpdateRedaction(ctx, roomID, rules)
+}
+
+func (s *service) Retention() (domain.RetentionPolicy, PruneStats) {
+	if s.pruner == nil {
+		return domain.RetentionPolicy{}, PruneStats{}
+	}
+	return s.pruner.Policy(), s.pruner.Stats()
+}
+
+func (s *service) RedactionRules() []domain.RedactionRule {
+	return s.redactor.Rules()
+}
+
+func (s *service) DeleteRoom(ctx context.Context, roomID string) error {
+	if err := s.roomRepository.DeleteByID(ctx, roomID); err != nil {
+		return err
+	}
+
+	// Kick out everyone still watching the room
+	s.hub.CloseRoom(roomID)
+	s.responses.forget(roomID)
+	return nil
+}
+
 func buildRoomLink(room domain.Room) string {
-	return fmt.Sprintf("/rooms/%s/events", room.ID)
+	return fmt.Sprintf("/rooms/%s/views", room.ID)
 }
 
-func (s *service) ListenEvents(ctx context.Context, roomID string, w http.ResponseWriter) (*ssehub.Client, error) {
+func (s *service) ListenEvents(ctx context.Context, roomID string, opts ListenOptions, t ssehub.Transport) (*ssehub.Client, error) {
+	if _, err := s.roomRepository.GetByID(ctx, roomID); err != nil {
+		return nil, err
+	}
+
 	clientID := uuidFunc()
 
-	cl, err := s.hub.Subscribe(ctx, roomID, clientID.String(), w)
+	cl, err := s.hub.SubscribeTransport(ctx, roomID, clientID.String(), t,
+		ssehub.WithReplay(opts.LastEventID, s.replayEvents(roomID)),
+		ssehub.WithDeliveryPolicy(opts.DeliveryPolicy),
+		ssehub.WithRemote(opts.RemoteAddr, opts.UserAgent),
+		ssehub.WithFilter(StreamFilter(opts.Filter)),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to subscribe to client: %w", err)
 	}
@@ -74,44 +309,140 @@ func (s *service) ListenEvents(ctx context.Context, roomID string, w http.Respon
 	return cl, nil
 }
 
-func (s *service) PushEvent(ctx context.Context, roomID string, event domain.Event) error {
-	// Sanitize headers
-	for k := range event.Header {
-		if slices.Contains(secretHeaders, k) {
-			event.Header.Del(k)
+// replayEvents streams the stored events of the room that came after the client's last seen event.
+func (s *service) replayEvents(roomID string) ssehub.ReplayFunc {
+	return func(ctx context.Context, lastEventID string, send func(ssehub.Message) error) error {
+		afterID, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid last event id: %w", err)
 		}
-	}
-	for k := range event.QueryParams {
-		if slices.Contains(secretQueryParams, k) {
-			delete(event.QueryParams, k)
+
+		for {
+			events, err := s.eventRepository.ListAfter(ctx, roomID, afterID, replayPageSize)
+			if err != nil {
+				return fmt.Errorf("failed to list missed events: %w", err)
+			}
+
+			for _, event := range events {
+				msg, err := toMessage(event)
+				if err != nil {
+					return err
+				}
+				if err := send(msg); err != nil {
+					return err
+				}
+				afterID = event.ID
+			}
+
+			if len(events) < replayPageSize {
+				return nil
+			}
 		}
 	}
-	if len(event.Body) == 0 {
-		event.Body = []byte("{}") // Initial default value for body
+}
+
+func toMessage(event domain.Event) (ssehub.Message, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return ssehub.Message{}, fmt.Errorf("failed to marshal event: %w", err)
 	}
 
+	return ssehub.Message{
+		Event: EventTypeMessage,
+		Data:  string(payload),
+		ID:    strconv.FormatInt(event.ID, 10),
+		Value: event,
+	}, nil
+}
+
+func (s *service) PushEvent(ctx context.Context, roomID string, event domain.Event) (domain.EventResponse, error) {
+	room, err := s.roomRepository.GetByID(ctx, roomID)
+	if err != nil {
+		return domain.EventResponse{}, err
+	}
+
+	if room.Signature != nil {
+		check := verifySignature(*room.Signature, event)
+		event.Signature = &check
+	}
+
+	// Forward rules act as a tunnel, they get the request as it was sent, less the credentials it was let in with
+	forwarded := event
+	forwarded.Header = event.Header.Clone()
+	forwarded.QueryParams = maps.Clone(event.QueryParams)
+	stripCredentials(forwarded.Header, forwarded.QueryParams, func(string, string, string) {})
+
+	s.sanitizeEvent(room, &event)
+
+	// Render the response from the sanitized request, it's stored along the event and templates must not echo secrets
+	resp := s.responses.renderResponse(room, event)
+	event.Response = &resp
+	// The stored copy drops what the template itself adds, the client still gets the response as rendered
+	s.redactor.RedactResponse(room, &event)
+
 	// Save event
 	if err := s.eventRepository.Save(ctx, roomID, &event); err != nil {
-		return fmt.Errorf("failed to save event: %w", err)
+		return domain.EventResponse{}, fmt.Errorf("failed to save event: %w", err)
 	}
 
 	// Prepare message to send to SSE client
-	payload, err := json.Marshal(event)
+	msg, err := toMessage(event)
 	if err != nil {
-		return fmt.Errorf("failed to marshal event: %w", err)
+		return domain.EventResponse{}, err
 	}
 
-	return s.hub.SendToRoom(roomID, ssehub.Message{
-		Event: "message",
-		Data:  string(payload),
-	})
+	if err := s.hub.SendToRoom(roomID, msg); err != nil && !errors.Is(err, ssehub.ErrRoomNotFound) {
+		// Nobody listening to the room is not an error, and either way the event is kept in history
+		log.Printf("failed to send event %d to room %s: %v", event.ID, roomID, err)
+	}
+
+	if s.forwarder != nil {
+		forwarded.ID = event.ID
+		forwarded.CreatedAt = event.CreatedAt
+		s.forwarder.Forward(room, forwarded)
+	}
+	return resp, nil
+}
+
+// sanitizeEvent strips secrets from the event before it's stored.
+func (s *service) sanitizeEvent(room domain.Room, event *domain.Event) {
+	s.redactor.Redact(room, event)
+	// Imported events come with their response
+	s.redactor.RedactResponse(room, event)
+	// Binary payloads can't be rendered as text, flag them so they're encoded as base64
+	event.BodyBase64 = !utf8.Valid(event.Body)
 }
 
-func (s *service) ListEvents(ctx context.Context, roomID string, page int, size int) ([]domain.Event, bool, error) {
-	rs, hasMore, err := s.eventRepository.List(ctx, roomID, page, size)
+func (s *service) ListEvents(ctx context.Context, roomID string, filter ports.EventFilter, cursor ports.EventCursor) ([]domain.Event, bool, error) {
+	rs, hasMore, err := s.eventRepository.List(ctx, roomID, filter, cursor)
 	if err != nil {
 		return nil, false, err
 	}
 
 	return rs, hasMore, nil
 }
+
+func (s *service) ExportEvents(ctx context.Context, roomID string, filter ports.EventFilter, fn func(domain.Event) error) error {
+	if _, err := s.roomRepository.GetByID(ctx, roomID); err != nil {
+		return err
+	}
+
+	cursor := ports.EventCursor{Limit: exportPageSize}
+	for {
+		events, hasMore, err := s.eventRepository.List(ctx, roomID, filter, cursor)
+		if err != nil {
+			return fmt.Errorf("failed to list events: %w", err)
+		}
+
+		for _, event := range events {
+			if err := fn(event); err != nil {
+				return err
+			}
+		}
+
+		if !hasMore || len(events) == 0 {
+			return nil
+		}
+		cursor.Before = events[len(events)-1].ID
+	}
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/erwin-lovecraft/pistol/internal/core/domain"
+)
+
+const (
+	defaultSignatureTolerance = 5 * time.Minute
+)
+
+var (
+	timeNowFunc = time.Now
+)
+
+var signatureAlgorithms = map[string]func() hash.Hash{
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+func validateSignatureProfile(profile domain.SignatureProfile) error {
+	if profile.Secret == "" {
+		return fmt.Errorf("%w: secret is required", domain.ErrInvalidSignatureProfile)
+	}
+	if profile.ToleranceSeconds < 0 {
+		return fmt.Errorf("%w: tolerance must not be negative", domain.ErrInvalidSignatureProfile)
+	}
+
+	switch profile.Type {
+	case domain.SignatureTypeGitHub, domain.SignatureTypeStripe, domain.SignatureTypeSlack:
+		return nil
+	case domain.SignatureTypeHMAC:
+		if profile.Header == "" {
+			return fmt.Errorf("%w: header is required", domain.ErrInvalidSignatureProfile)
+		}
+		if _, ok := signatureAlgorithms[profile.Algorithm]; !ok {
+			return fmt.Errorf("%w: unsupported algorithm %q", domain.ErrInvalidSignatureProfile, profile.Algorithm)
+		}
+		if profile.Encoding != "" && profile.Encoding != "hex" && profile.Encoding != "base64" {
+			return fmt.Errorf("%w: unsupported encoding %q", domain.ErrInvalidSignatureProfile, profile.Encoding)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: unsupported type %q", domain.ErrInvalidSignatureProfile, profile.Type)
+	}
+}
+
+// verifySignature checks the event against the room's signature profile.
+func verifySignature(profile domain.SignatureProfile, event domain.Event) domain.SignatureCheck {
+	tolerance := defaultSignatureTolerance
+	if profile.ToleranceSeconds > 0 {
+		tolerance = time.Duration(profile.ToleranceSeconds) * time.Second
+	}
+
+	switch profile.Type {
+	case domain.SignatureTypeGitHub:
+		sig := event.Header.Get("X-Hub-Signature-256")
+		if sig == "" {
+			return missingSignature("X-Hub-Signature-256")
+		}
+		expected := "sha256=" + hex.EncodeToString(computeHMAC(sha256.New, profile.Secret, event.Body))
+		return compareSignatures([]string{sig}, expected)
+
+	case domain.SignatureTypeStripe:
+		header := event.Header.Get("Stripe-Signature")
+		if header == "" {
+			return missingSignature("Stripe-Signature")
+		}
+		var (
+			timestamp string
+			sigs      []string
+		)
+		for _, part := range strings.Split(header, ",") {
+			k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+			switch k {
+			case "t":
+				timestamp = v
+			case "v1":
+				sigs = append(sigs, v)
+			}
+		}
+		if timestamp == "" || len(sigs) == 0 {
+			return failedSignature("malformed Stripe-Signature header")
+		}
+		if check, ok := checkTimestamp(timestamp, tolerance); !ok {
+			return check
+		}
+		payload := append([]byte(timestamp+"."), event.Body...)
+		expected := hex.EncodeToString(computeHMAC(sha256.New, profile.Secret, payload))
+		return compareSignatures(sigs, expected)
+
+	case domain.SignatureTypeSlack:
+		sig := event.Header.Get("X-Slack-Signature")
+		if sig == "" {
+			return missingSignature("X-Slack-Signature")
+		}
+		timestamp := event.Header.Get("X-Slack-Request-Timestamp")
+		if timestamp == "" {
+			return missingSignature("X-Slack-Request-Timestamp")
+		}
+		if check, ok := checkTimestamp(timestamp, tolerance); !ok {
+			return check
+		}
+		payload := append([]byte("v0:"+timestamp+":"), event.Body...)
+		expected := "v0=" + hex.EncodeToString(computeHMAC(sha256.New, profile.Secret, payload))
+		return compareSignatures([]string{sig}, expected)
+
+	case domain.SignatureTypeHMAC:
+		sig := event.Header.Get(profile.Header)
+		if sig == "" {
+			return missingSignature(profile.Header)
+		}
+		newHash, ok := signatureAlgorithms[profile.Algorithm]
+		if !ok {
+			return failedSignature(fmt.Sprintf("unsupported algorithm %q", profile.Algorithm))
+		}
+		mac := computeHMAC(newHash, profile.Secret, event.Body)
+		var expected string
+		if profile.Encoding == "base64" {
+			expected = profile.Prefix + base64.StdEncoding.EncodeToString(mac)
+		} else {
+			expected = profile.Prefix + hex.EncodeToString(mac)
+		}
+		return compareSignatures([]string{sig}, expected)
+
+	default:
+		return failedSignature(fmt.Sprintf("unsupported signature type %q", profile.Type))
+	}
+}
+
+func computeHMAC(newHash func() hash.Hash, secret string, payload []byte) []byte {
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+func compareSignatures(candidates []string, expected string) domain.SignatureCheck {
+	for _, sig := range candidates {
+		if hmac.Equal([]byte(strings.TrimSpace(sig)), []byte(expected)) {
+			return domain.SignatureCheck{Verdict: domain.SignatureVerified}
+		}
+	}
+	return failedSignature("signature mismatch")
+}
+
+// checkTimestamp rejects signed timestamps too far from now, to prevent replays.
+func checkTimestamp(raw string, tolerance time.Duration) (domain.SignatureCheck, bool) {
+	sec, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return failedSignature("malformed timestamp"), false
+	}
+	age := timeNowFunc().Sub(time.Unix(sec, 0))
+	if age > tolerance || age < -tolerance {
+		return failedSignature(fmt.Sprintf("timestamp outside tolerance of %s", tolerance)), false
+	}
+	return domain.SignatureCheck{}, true
+}
+
+func missingSignature(header string) domain.SignatureCheck {
+	return domain.SignatureCheck{
+		Verdict: domain.SignatureMissing,
+		Reason:  fmt.Sprintf("%s header not found", header),
+	}
+}
+
+func failedSignature(reason string) domain.SignatureCheck {
+	return domain.SignatureCheck{
+		Verdict: domain.SignatureFailed,
+		Reason:  reason,
+	}
+}
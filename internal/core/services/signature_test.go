@@ -0,0 +1,187 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/erwin-lovecraft/pistol/internal/core/domain"
+)
+
+// sign returns the HMAC of the payload, computed apart from the code under test.
+func sign(newHash func() hash.Hash, secret, payload string) []byte {
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+func TestVerifySignature(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	timeNowFunc = func() time.Time { return now }
+	t.Cleanup(func() { timeNowFunc = time.Now })
+
+	const (
+		secret = "It's a Secret to Everybody"
+		body   = "Hello, World!"
+	)
+	ts := strconv.FormatInt(now.Unix(), 10)
+	stale := strconv.FormatInt(now.Add(-10*time.Minute).Unix(), 10)
+	github := domain.SignatureProfile{Type: domain.SignatureTypeGitHub, Secret: secret}
+	stripe := domain.SignatureProfile{Type: domain.SignatureTypeStripe, Secret: secret}
+	slack := domain.SignatureProfile{Type: domain.SignatureTypeSlack, Secret: secret}
+	stripeSig := func(ts string) string {
+		return hex.EncodeToString(sign(sha256.New, secret, ts+"."+body))
+	}
+	slackSig := func(ts string) string {
+		return "v0=" + hex.EncodeToString(sign(sha256.New, secret, "v0:"+ts+":"+body))
+	}
+
+	tests := []struct {
+		name    string
+		profile domain.SignatureProfile
+		header  http.Header
+		want    string
+	}{
+		{
+			// The example of GitHub's documentation
+			name:    "github",
+			profile: github,
+			header:  http.Header{"X-Hub-Signature-256": {"sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"}},
+			want:    domain.SignatureVerified,
+		},
+		{
+			name:    "github, wrong secret",
+			profile: domain.SignatureProfile{Type: domain.SignatureTypeGitHub, Secret: "other"},
+			header:  http.Header{"X-Hub-Signature-256": {"sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"}},
+			want:    domain.SignatureFailed,
+		},
+		{name: "github, missing", profile: github, header: http.Header{}, want: domain.SignatureMissing},
+		{
+			name:    "stripe",
+			profile: stripe,
+			header:  http.Header{"Stripe-Signature": {"t=" + ts + ",v1=" + stripeSig(ts)}},
+			want:    domain.SignatureVerified,
+		},
+		{
+			// Stripe sends a signature per secret while one is rolled
+			name:    "stripe, several signatures",
+			profile: stripe,
+			header:  http.Header{"Stripe-Signature": {"t=" + ts + ", v1=deadbeef, v1=" + stripeSig(ts) + ", v0=ignored"}},
+			want:    domain.SignatureVerified,
+		},
+		{
+			name:    "stripe, stale",
+			profile: stripe,
+			header:  http.Header{"Stripe-Signature": {"t=" + stale + ",v1=" + stripeSig(stale)}},
+			want:    domain.SignatureFailed,
+		},
+		{
+			name:    "stripe, stale within the room's tolerance",
+			profile: domain.SignatureProfile{Type: domain.SignatureTypeStripe, Secret: secret, ToleranceSeconds: 900},
+			header:  http.Header{"Stripe-Signature": {"t=" + stale + ",v1=" + stripeSig(stale)}},
+			want:    domain.SignatureVerified,
+		},
+		{
+			name:    "stripe, malformed",
+			profile: stripe,
+			header:  http.Header{"Stripe-Signature": {"v1=" + stripeSig(ts)}},
+			want:    domain.SignatureFailed,
+		},
+		{
+			name:    "slack",
+			profile: slack,
+			header:  http.Header{"X-Slack-Signature": {slackSig(ts)}, "X-Slack-Request-Timestamp": {ts}},
+			want:    domain.SignatureVerified,
+		},
+		{
+			name:    "slack, missing timestamp",
+			profile: slack,
+			header:  http.Header{"X-Slack-Signature": {slackSig(ts)}},
+			want:    domain.SignatureMissing,
+		},
+		{
+			name:    "slack, malformed timestamp",
+			profile: slack,
+			header:  http.Header{"X-Slack-Signature": {slackSig("now")}, "X-Slack-Request-Timestamp": {"now"}},
+			want:    domain.SignatureFailed,
+		},
+		{
+			name:    "hmac, hex",
+			profile: domain.SignatureProfile{Type: domain.SignatureTypeHMAC, Secret: secret, Header: "X-Signature", Algorithm: "sha1", Prefix: "sha1="},
+			header:  http.Header{"X-Signature": {"sha1=" + hex.EncodeToString(sign(sha1.New, secret, body))}},
+			want:    domain.SignatureVerified,
+		},
+		{
+			name:    "hmac, base64",
+			profile: domain.SignatureProfile{Type: domain.SignatureTypeHMAC, Secret: secret, Header: "X-Signature", Algorithm: "sha256", Encoding: "base64"},
+			header:  http.Header{"X-Signature": {base64.StdEncoding.EncodeToString(sign(sha256.New, secret, body))}},
+			want:    domain.SignatureVerified,
+		},
+		{
+			name:    "hmac, tampered",
+			profile: domain.SignatureProfile{Type: domain.SignatureTypeHMAC, Secret: secret, Header: "X-Signature", Algorithm: "sha256"},
+			header:  http.Header{"X-Signature": {hex.EncodeToString(sign(sha256.New, secret, body+"!"))}},
+			want:    domain.SignatureFailed,
+		},
+		{
+			name:    "unsupported type",
+			profile: domain.SignatureProfile{Type: "pgp", Secret: secret},
+			header:  http.Header{},
+			want:    domain.SignatureFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := verifySignature(tt.profile, domain.Event{Header: tt.header, Body: []byte(body)})
+			if check.Verdict != tt.want {
+				t.Errorf("verdict = %s (%s), want %s", check.Verdict, check.Reason, tt.want)
+			}
+			if tt.want != domain.SignatureVerified && check.Reason == "" {
+				t.Error("no reason given")
+			}
+		})
+	}
+}
+
+func TestValidateSignatureProfile(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile domain.SignatureProfile
+		valid   bool
+	}{
+		{name: "github", profile: domain.SignatureProfile{Type: domain.SignatureTypeGitHub, Secret: "s"}, valid: true},
+		{name: "no secret", profile: domain.SignatureProfile{Type: domain.SignatureTypeGitHub}},
+		{name: "negative tolerance", profile: domain.SignatureProfile{Type: domain.SignatureTypeSlack, Secret: "s", ToleranceSeconds: -1}},
+		{name: "unsupported type", profile: domain.SignatureProfile{Type: "pgp", Secret: "s"}},
+		{
+			name:    "hmac",
+			profile: domain.SignatureProfile{Type: domain.SignatureTypeHMAC, Secret: "s", Header: "X-Signature", Algorithm: "sha512", Encoding: "hex"},
+			valid:   true,
+		},
+		{name: "hmac, no header", profile: domain.SignatureProfile{Type: domain.SignatureTypeHMAC, Secret: "s", Algorithm: "sha256"}},
+		{name: "hmac, unsupported algorithm", profile: domain.SignatureProfile{Type: domain.SignatureTypeHMAC, Secret: "s", Header: "X-Signature", Algorithm: "md5"}},
+		{
+			name:    "hmac, unsupported encoding",
+			profile: domain.SignatureProfile{Type: domain.SignatureTypeHMAC, Secret: "s", Header: "X-Signature", Algorithm: "sha256", Encoding: "base32"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSignatureProfile(tt.profile)
+			if tt.valid && err != nil {
+				t.Errorf("validate: %v", err)
+			}
+			if !tt.valid && !errors.Is(err, domain.ErrInvalidSignatureProfile) {
+				t.Errorf("validate = %v, want %v", err, domain.ErrInvalidSignatureProfile)
+			}
+		})
+	}
+}
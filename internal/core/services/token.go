@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/erwin-lovecraft/pistol/internal/core/domain"
+)
+
+const (
+	tokenPrefix      = "pst_"
+	tokenBytes       = 32
+	tokenPrefixChars = len(tokenPrefix) + 6
+
+	maxTokenNameLength = 100
+)
+
+func (s *service) CreateRoomToken(ctx context.Context, roomID string, name string, scope string, expiresAt *time.Time) (domain.IssuedToken, error) {
+	name = strings.TrimSpace(name)
+	if len(name) > maxTokenNameLength {
+		return domain.IssuedToken{}, fmt.Errorf("%w: name is too long", domain.ErrInvalidToken)
+	}
+	switch scope {
+	case domain.TokenScopeIngest, domain.TokenScopeRead:
+	default:
+		return domain.IssuedToken{}, fmt.Errorf("%w: unknown scope %q", domain.ErrInvalidToken, scope)
+	}
+	if expiresAt != nil && !expiresAt.After(timeNowFunc()) {
+		return domain.IssuedToken{}, fmt.Errorf("%w: expiry is in the past", domain.ErrInvalidToken)
+	}
+
+	if _, err := s.roomRepository.GetByID(ctx, roomID); err != nil {
+		return domain.IssuedToken{}, err
+	}
+
+	return s.issueToken(ctx, roomID, name, scope, expiresAt)
+}
+
+func (s *service) ListRoomTokens(ctx context.Context, roomID string) ([]domain.RoomToken, error) {
+	if _, err := s.roomRepository.GetByID(ctx, roomID); err != nil {
+		return nil, err
+	}
+
+	return s.tokenRepository.List(ctx, roomID)
+}
+
+// RotateRoomToken revokes the token and issues a new one with the same name, scope and expiry.
+func (s *service) RotateRoomToken(ctx context.Context, roomID string, tokenID int64) (domain.IssuedToken, error) {
+	token, value, err := newToken(roomID, "", "", nil)
+	if err != nil {
+		return domain.IssuedToken{}, err
+	}
+	if err := s.tokenRepository.Rotate(ctx, roomID, tokenID, &token); err != nil {
+		return domain.IssuedToken{}, err
+	}
+
+	return domain.IssuedToken{
+		RoomToken: token,
+		Token:     value,
+	}, nil
+}
+
+func (s *service) RevokeRoomToken(ctx context.Context, roomID string, tokenID int64) (domain.RoomToken, error) {
+	return s.tokenRepository.Revoke(ctx, roomID, tokenID)
+}
+
+// VerifyRoomToken checks the token grants the scope on the room, it returns domain.ErrUnauthorized otherwise.
+func (s *service) VerifyRoomToken(ctx context.Context, roomID string, scope string, token string) error {
+	if !strings.HasPrefix(token, tokenPrefix) {
+		return domain.ErrUnauthorized
+	}
+
+	tokens, err := s.tokenRepository.ListActive(ctx, roomID, scope)
+	if err != nil {
+		return fmt.Errorf("failed to list room tokens: %w", err)
+	}
+
+	hash := hashToken(token)
+	// Compare against every token so the time taken doesn't tell which one matched
+	var matched int
+	for _, t := range tokens {
+		matched |= subtle.ConstantTimeCompare(hash, t.Hash)
+	}
+	if matched != 1 {
+		return domain.ErrUnauthorized
+	}
+	return nil
+}
+
+func (s *service) issueToken(ctx context.Context, roomID string, name string, scope string, expiresAt *time.Time) (domain.IssuedToken, error) {
+	token, value, err := newToken(roomID, name, scope, expiresAt)
+	if err != nil {
+		return domain.IssuedToken{}, err
+	}
+	if err := s.tokenRepository.Save(ctx, &token); err != nil {
+		return domain.IssuedToken{}, fmt.Errorf("failed to save token: %w", err)
+	}
+
+	return domain.IssuedToken{
+		RoomToken: token,
+		Token:     value,
+	}, nil
+}
+
+// newToken generates a token value and the token to store for it, only its prefix and hash are kept.
+func newToken(roomID string, name string, scope string, expiresAt *time.Time) (domain.RoomToken, string, error) {
+	raw := make([]byte, tokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return domain.RoomToken{}, "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	value := tokenPrefix + base64.RawURLEncoding.EncodeToString(raw)
+
+	return domain.RoomToken{
+		RoomID:    roomID,
+		Name:      name,
+		Scope:     scope,
+		Prefix:    value[:tokenPrefixChars],
+		Hash:      hashToken(value),
+		ExpiresAt: expiresAt,
+	}, value, nil
+}
+
+func hashToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Bodies are raw bytes of any content type, only the ones holding a JSON document can be searched with jsonpath
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION "event_body_json"("body" BYTEA) RETURNS JSONB AS $$
+BEGIN
+    RETURN convert_from("body", 'UTF8')::JSONB;
+EXCEPTION WHEN OTHERS THEN
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION IF EXISTS "event_body_json"(BYTEA);
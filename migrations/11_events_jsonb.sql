@@ -0,0 +1,11 @@
+-- +goose Up
+-- Changing the type rewrites the table under an exclusive lock, pushes wait until it's done. Large tables are best
+-- pruned first or migrated in a quiet window, the indexes are built concurrently by 15_events_jsonb_indexes.
+ALTER TABLE "events"
+    ALTER COLUMN "header" TYPE JSONB USING "header"::JSONB,
+    ALTER COLUMN "query_params" TYPE JSONB USING "query_params"::JSONB;
+
+-- +goose Down
+ALTER TABLE "events"
+    ALTER COLUMN "header" TYPE JSON USING "header"::JSON,
+    ALTER COLUMN "query_params" TYPE JSON USING "query_params"::JSON;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE "rooms" ADD COLUMN IF NOT EXISTS "retention" JSONB NULL;
+
+-- +goose Down
+ALTER TABLE "rooms" DROP COLUMN IF EXISTS "retention";
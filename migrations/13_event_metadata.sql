@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE "events" ADD COLUMN IF NOT EXISTS "path" TEXT NOT NULL DEFAULT '';
+ALTER TABLE "events" ADD COLUMN IF NOT EXISTS "remote_addr" TEXT NOT NULL DEFAULT '';
+ALTER TABLE "events" ADD COLUMN IF NOT EXISTS "proto" TEXT NOT NULL DEFAULT '';
+ALTER TABLE "events" ADD COLUMN IF NOT EXISTS "host" TEXT NOT NULL DEFAULT '';
+ALTER TABLE "events" ADD COLUMN IF NOT EXISTS "tls" JSONB NULL;
+ALTER TABLE "events" ADD COLUMN IF NOT EXISTS "duration_us" BIGINT NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE "events" DROP COLUMN IF EXISTS "duration_us";
+ALTER TABLE "events" DROP COLUMN IF EXISTS "tls";
+ALTER TABLE "events" DROP COLUMN IF EXISTS "host";
+ALTER TABLE "events" DROP COLUMN IF EXISTS "proto";
+ALTER TABLE "events" DROP COLUMN IF EXISTS "remote_addr";
+ALTER TABLE "events" DROP COLUMN IF EXISTS "path";
@@ -0,0 +1,12 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+-- Built concurrently so large rooms keep taking pushes while they run
+CREATE INDEX CONCURRENTLY IF NOT EXISTS "events_header_idx" ON "events" USING GIN ("header");
+CREATE INDEX CONCURRENTLY IF NOT EXISTS "events_query_params_idx" ON "events" USING GIN ("query_params");
+-- The body column keeps the raw bytes as sent, JSON bodies are indexed through the document they hold
+CREATE INDEX CONCURRENTLY IF NOT EXISTS "events_body_json_idx" ON "events" USING GIN (event_body_json("body") jsonb_path_ops);
+
+-- +goose Down
+DROP INDEX CONCURRENTLY IF EXISTS "events_body_json_idx";
+DROP INDEX CONCURRENTLY IF EXISTS "events_query_params_idx";
+DROP INDEX CONCURRENTLY IF EXISTS "events_header_idx";
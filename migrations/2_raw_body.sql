@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE "events" ALTER COLUMN "body" TYPE BYTEA USING convert_to("body"::TEXT, 'UTF8');
+ALTER TABLE "events" ADD COLUMN IF NOT EXISTS "body_base64" BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE "events" ADD COLUMN IF NOT EXISTS "content_type" TEXT NOT NULL DEFAULT '';
+ALTER TABLE "events" ADD COLUMN IF NOT EXISTS "content_length" BIGINT NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE "events" DROP COLUMN IF EXISTS "content_length";
+ALTER TABLE "events" DROP COLUMN IF EXISTS "content_type";
+ALTER TABLE "events" DROP COLUMN IF EXISTS "body_base64";
+ALTER TABLE "events" ALTER COLUMN "body" TYPE JSON USING convert_from("body", 'UTF8')::JSON;
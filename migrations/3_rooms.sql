@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS "rooms" (
+    "id" UUID PRIMARY KEY,
+    "name" TEXT NOT NULL,
+    "avatar" TEXT NOT NULL DEFAULT '',
+    "created_at" TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    "updated_at" TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Rooms used to be created on demand, keep events that were pushed before rooms were persisted
+INSERT INTO "rooms" ("id", "name")
+SELECT DISTINCT "room_id", "room_id"::TEXT FROM "events"
+ON CONFLICT ("id") DO NOTHING;
+
+ALTER TABLE "events" ADD CONSTRAINT "events_room_id_fkey"
+    FOREIGN KEY ("room_id") REFERENCES "rooms" ("id") ON DELETE CASCADE;
+
+-- +goose Down
+ALTER TABLE "events" DROP CONSTRAINT IF EXISTS "events_room_id_fkey";
+DROP TABLE IF EXISTS "rooms";
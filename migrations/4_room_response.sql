@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE "rooms" ADD COLUMN IF NOT EXISTS "response" JSONB NULL;
+ALTER TABLE "events" ADD COLUMN IF NOT EXISTS "response" JSONB NULL;
+
+-- +goose Down
+ALTER TABLE "events" DROP COLUMN IF EXISTS "response";
+ALTER TABLE "rooms" DROP COLUMN IF EXISTS "response";
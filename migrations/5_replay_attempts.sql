@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS "replay_attempts" (
+    "id" BIGINT PRIMARY KEY,
+    "event_id" BIGINT NOT NULL REFERENCES "events" ("id") ON DELETE CASCADE,
+    "target_url" TEXT NOT NULL,
+    "request_header" JSONB NULL,
+    "status_code" INTEGER NOT NULL DEFAULT 0,
+    "response_header" JSONB NULL,
+    "response_body" TEXT NOT NULL DEFAULT '',
+    "response_body_base64" BOOLEAN NOT NULL DEFAULT FALSE,
+    "latency_ms" BIGINT NOT NULL DEFAULT 0,
+    "error" TEXT NOT NULL DEFAULT '',
+    "created_at" TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS "replay_attempts_event_id_idx" ON "replay_attempts" ("event_id", "id" DESC);
+
+-- +goose Down
+DROP TABLE IF EXISTS "replay_attempts";
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE "rooms" ADD COLUMN IF NOT EXISTS "forwards" JSONB NULL;
+ALTER TABLE "replay_attempts" ADD COLUMN IF NOT EXISTS "source" TEXT NOT NULL DEFAULT 'replay';
+ALTER TABLE "replay_attempts" ADD COLUMN IF NOT EXISTS "attempt" INTEGER NOT NULL DEFAULT 1;
+
+-- +goose Down
+ALTER TABLE "replay_attempts" DROP COLUMN IF EXISTS "attempt";
+ALTER TABLE "replay_attempts" DROP COLUMN IF EXISTS "source";
+ALTER TABLE "rooms" DROP COLUMN IF EXISTS "forwards";
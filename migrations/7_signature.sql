@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE "rooms" ADD COLUMN IF NOT EXISTS "signature" JSONB NULL;
+ALTER TABLE "events" ADD COLUMN IF NOT EXISTS "signature_verdict" TEXT NOT NULL DEFAULT '';
+ALTER TABLE "events" ADD COLUMN IF NOT EXISTS "signature_reason" TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE "events" DROP COLUMN IF EXISTS "signature_reason";
+ALTER TABLE "events" DROP COLUMN IF EXISTS "signature_verdict";
+ALTER TABLE "rooms" DROP COLUMN IF EXISTS "signature";
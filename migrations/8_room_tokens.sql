@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS "room_tokens" (
+    "id" BIGINT PRIMARY KEY,
+    "room_id" UUID NOT NULL REFERENCES "rooms" ("id") ON DELETE CASCADE,
+    "name" TEXT NOT NULL DEFAULT '',
+    "scope" TEXT NOT NULL,
+    "prefix" TEXT NOT NULL,
+    "token_hash" BYTEA NOT NULL,
+    "expires_at" TIMESTAMPTZ NULL,
+    "revoked_at" TIMESTAMPTZ NULL,
+    "created_at" TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS "room_tokens_room_id_scope_idx" ON "room_tokens" ("room_id", "scope");
+
+-- +goose Down
+DROP TABLE IF EXISTS "room_tokens";
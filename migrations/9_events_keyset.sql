@@ -0,0 +1,7 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+-- Built concurrently so large rooms keep taking pushes while it runs
+CREATE INDEX CONCURRENTLY IF NOT EXISTS "events_room_id_id_idx" ON "events" ("room_id", "id" DESC);
+
+-- +goose Down
+DROP INDEX CONCURRENTLY IF EXISTS "events_room_id_id_idx";
@@ -6,3 +6,8 @@ import (
 
 //go:embed *.sql
 var MigrationFS embed.FS
+
+// SQLiteFS holds the migrations of the SQLite storage, in the sqlite directory.
+//
+//go:embed sqlite/*.sql
+var SQLiteFS embed.FS
@@ -0,0 +1,78 @@
+-- +goose Up
+-- SQLite mirrors the Postgres schema: JSON is kept as TEXT and times as DATETIME, always written in UTC so they
+-- compare as strings
+CREATE TABLE IF NOT EXISTS "rooms" (
+    "id" TEXT PRIMARY KEY,
+    "name" TEXT NOT NULL,
+    "avatar" TEXT NOT NULL DEFAULT '',
+    "response" TEXT,
+    "forwards" TEXT,
+    "signature" TEXT,
+    "retention" TEXT,
+    "redaction" TEXT,
+    "created_at" DATETIME NOT NULL,
+    "updated_at" DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS "events" (
+    "id" INTEGER PRIMARY KEY,
+    "room_id" TEXT NOT NULL REFERENCES "rooms" ("id") ON DELETE CASCADE,
+    "method" TEXT NOT NULL,
+    "header" TEXT,
+    "query_params" TEXT,
+    "body" BLOB,
+    "body_base64" BOOLEAN NOT NULL DEFAULT FALSE,
+    "content_type" TEXT NOT NULL DEFAULT '',
+    "content_length" INTEGER NOT NULL DEFAULT 0,
+    "response" TEXT,
+    "signature_verdict" TEXT NOT NULL DEFAULT '',
+    "signature_reason" TEXT NOT NULL DEFAULT '',
+    "path" TEXT NOT NULL DEFAULT '',
+    "remote_addr" TEXT NOT NULL DEFAULT '',
+    "proto" TEXT NOT NULL DEFAULT '',
+    "host" TEXT NOT NULL DEFAULT '',
+    "tls" TEXT,
+    "duration_us" INTEGER NOT NULL DEFAULT 0,
+    "redacted" TEXT,
+    "created_at" DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS "events_room_id_id_idx" ON "events" ("room_id", "id" DESC);
+
+CREATE TABLE IF NOT EXISTS "replay_attempts" (
+    "id" INTEGER PRIMARY KEY,
+    "event_id" INTEGER NOT NULL REFERENCES "events" ("id") ON DELETE CASCADE,
+    "target_url" TEXT NOT NULL,
+    "request_header" TEXT,
+    "status_code" INTEGER NOT NULL DEFAULT 0,
+    "response_header" TEXT,
+    "response_body" TEXT NOT NULL DEFAULT '',
+    "response_body_base64" BOOLEAN NOT NULL DEFAULT FALSE,
+    "latency_ms" INTEGER NOT NULL DEFAULT 0,
+    "error" TEXT NOT NULL DEFAULT '',
+    "source" TEXT NOT NULL DEFAULT 'replay',
+    "attempt" INTEGER NOT NULL DEFAULT 1,
+    "created_at" DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS "replay_attempts_event_id_idx" ON "replay_attempts" ("event_id", "id" DESC);
+
+CREATE TABLE IF NOT EXISTS "room_tokens" (
+    "id" INTEGER PRIMARY KEY,
+    "room_id" TEXT NOT NULL REFERENCES "rooms" ("id") ON DELETE CASCADE,
+    "name" TEXT NOT NULL DEFAULT '',
+    "scope" TEXT NOT NULL,
+    "prefix" TEXT NOT NULL,
+    "token_hash" BLOB NOT NULL,
+    "expires_at" DATETIME,
+    "revoked_at" DATETIME,
+    "created_at" DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS "room_tokens_room_id_scope_idx" ON "room_tokens" ("room_id", "scope");
+
+-- +goose Down
+DROP TABLE IF EXISTS "room_tokens";
+DROP TABLE IF EXISTS "replay_attempts";
+DROP TABLE IF EXISTS "events";
+DROP TABLE IF EXISTS "rooms";
@@ -0,0 +1,277 @@
+// Package jsonpath evaluates a subset of SQL/JSON path expressions, the ones Postgres runs with its jsonpath type,
+// so the same filter can be matched in memory or handed to the database.
+//
+// An expression is a path, optionally compared with a literal:
+//
+//	$.repository.name
+//	$.action == "opened"
+//	$.data.object.amount_due >= 1000
+//	$.commits[*].author.name != "bot"
+//
+// Paths follow Postgres' lax mode: arrays are unwrapped when a key is looked up, and a comparison holds when any
+// of the values the path selects satisfies it.
+package jsonpath
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidExpr = errors.New("invalid jsonpath expression")
+)
+
+type segmentKind int
+
+const (
+	segmentKey segmentKind = iota
+	segmentAnyKey
+	segmentIndex
+	segmentAnyIndex
+)
+
+type segment struct {
+	kind  segmentKind
+	key   string
+	index int
+}
+
+// Expr is a parsed expression, safe for concurrent use.
+type Expr struct {
+	path []segment
+	// op is empty when the expression only checks the path exists
+	op string
+	// value is the literal compared against, as decoded from JSON
+	value any
+}
+
+// Parse parses an expression, it returns an error wrapping ErrInvalidExpr when it's not supported.
+func Parse(s string) (*Expr, error) {
+	p := parser{src: s}
+	expr, err := p.parse()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidExpr, err)
+	}
+	return expr, nil
+}
+
+// String returns the expression as a Postgres jsonpath predicate, usable with the @@ operator.
+func (e *Expr) String() string {
+	var b strings.Builder
+	path := e.pathString()
+	if e.op == "" {
+		b.WriteString("exists(")
+		b.WriteString(path)
+		b.WriteString(")")
+		return b.String()
+	}
+
+	b.WriteString(path)
+	b.WriteString(" ")
+	b.WriteString(e.op)
+	b.WriteString(" ")
+	b.WriteString(literalString(e.value))
+	return b.String()
+}
+
+func (e *Expr) pathString() string {
+	var b strings.Builder
+	b.WriteString("$")
+	for _, seg := range e.path {
+		switch seg.kind {
+		case segmentKey:
+			b.WriteString(".")
+			b.WriteString(literalString(seg.key))
+		case segmentAnyKey:
+			b.WriteString(".*")
+		case segmentIndex:
+			b.WriteString("[")
+			b.WriteString(strconv.Itoa(seg.index))
+			b.WriteString("]")
+		case segmentAnyIndex:
+			b.WriteString("[*]")
+		}
+	}
+	return b.String()
+}
+
+func literalString(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case json.Number:
+		return v.String()
+	default:
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		_ = enc.Encode(v)
+		return strings.TrimSuffix(buf.String(), "\n")
+	}
+}
+
+// MatchJSON reports whether the JSON document satisfies the expression, documents that aren't JSON never do.
+func (e *Expr) MatchJSON(data []byte) bool {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return false
+	}
+	// Trailing data means it isn't a single JSON document
+	if _, err := dec.Token(); err == nil {
+		return false
+	}
+	return e.Match(doc)
+}
+
+// Match reports whether the decoded document satisfies the expression.
+// Numbers are expected as json.Number or float64.
+func (e *Expr) Match(doc any) bool {
+	values := []any{doc}
+	for _, seg := range e.path {
+		values = step(values, seg)
+		if len(values) == 0 {
+			return false
+		}
+	}
+
+	if e.op == "" {
+		return true
+	}
+	for _, v := range values {
+		// Comparisons unwrap arrays, as in lax mode
+		if arr, ok := v.([]any); ok {
+			for _, item := range arr {
+				if compare(item, e.op, e.value) {
+					return true
+				}
+			}
+			continue
+		}
+		if compare(v, e.op, e.value) {
+			return true
+		}
+	}
+	return false
+}
+
+func step(values []any, seg segment) []any {
+	var out []any
+	for _, v := range values {
+		switch seg.kind {
+		case segmentKey, segmentAnyKey:
+			for _, obj := range unwrap(v) {
+				m, ok := obj.(map[string]any)
+				if !ok {
+					continue
+				}
+				if seg.kind == segmentKey {
+					if child, ok := m[seg.key]; ok {
+						out = append(out, child)
+					}
+					continue
+				}
+				for _, child := range m {
+					out = append(out, child)
+				}
+			}
+		case segmentIndex:
+			arr, ok := v.([]any)
+			if !ok {
+				// A single value acts as an array of itself
+				if seg.index == 0 {
+					out = append(out, v)
+				}
+				continue
+			}
+			if seg.index < len(arr) {
+				out = append(out, arr[seg.index])
+			}
+		case segmentAnyIndex:
+			out = append(out, unwrap(v)...)
+		}
+	}
+	return out
+}
+
+func unwrap(v any) []any {
+	if arr, ok := v.([]any); ok {
+		return arr
+	}
+	return []any{v}
+}
+
+func compare(left any, op string, right any) bool {
+	var cmp int
+	switch r := right.(type) {
+	case nil:
+		if left != nil {
+			return false
+		}
+	case bool:
+		l, ok := left.(bool)
+		if !ok {
+			return false
+		}
+		switch {
+		case l == r:
+		case !l:
+			cmp = -1
+		default:
+			cmp = 1
+		}
+	case string:
+		l, ok := left.(string)
+		if !ok {
+			return false
+		}
+		cmp = strings.Compare(l, r)
+	case json.Number:
+		l, ok := toFloat(left)
+		if !ok {
+			return false
+		}
+		rf, _ := r.Float64()
+		switch {
+		case l < rf:
+			cmp = -1
+		case l > rf:
+			cmp = 1
+		}
+	default:
+		return false
+	}
+
+	switch op {
+	case "==":
+		return cmp == 0
+	case "!=", "<>":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+func toFloat(v any) (float64, bool) {
+	switch v := v.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
@@ -0,0 +1,115 @@
+package jsonpath
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		expr string
+		// want is the expression as a Postgres predicate
+		want string
+	}{
+		{expr: "$", want: "exists($)"},
+		{expr: "$.repository.name", want: `exists($."repository"."name")`},
+		{expr: `$.action == "opened"`, want: `$."action" == "opened"`},
+		{expr: `$.action=='opened'`, want: `$."action" == "opened"`},
+		{expr: "$.data.object.amount_due >= 1000", want: `$."data"."object"."amount_due" >= 1000`},
+		{expr: `$.commits[*].author.name != "bot"`, want: `$."commits"[*]."author"."name" != "bot"`},
+		{expr: `$.items[0]."odd key" <> null`, want: `$."items"[0]."odd key" <> null`},
+		{expr: "$.*.enabled == true", want: `$.*."enabled" == true`},
+		{expr: `$["a b"] < -1.5e3`, want: `$."a b" < -1.5e3`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			expr, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if got := expr.String(); got != tt.want {
+				t.Errorf("String() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"repository.name",
+		"$.",
+		"$.1abc",
+		"$[",
+		`$.a == "unterminated`,
+		"$.a ==",
+		"$.a == 1 2",
+		"$.a ~ 1",
+		"$.a == 01",
+		"$.a == yes",
+	} {
+		if _, err := Parse(expr); !errors.Is(err, ErrInvalidExpr) {
+			t.Errorf("Parse(%q) = %v, want %v", expr, err, ErrInvalidExpr)
+		}
+	}
+}
+
+func TestMatchJSON(t *testing.T) {
+	doc := `{
+		"action": "opened",
+		"number": 42,
+		"draft": false,
+		"labels": [{"name": "bug"}, {"name": "urgent"}],
+		"commits": [{"author": {"name": "bot"}}, {"author": {"name": "ada"}}],
+		"milestone": null,
+		"matrix": [[1, 2], [3]]
+	}`
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{expr: "$.action", want: true},
+		{expr: "$.missing", want: false},
+		{expr: "$.milestone", want: true},
+		{expr: `$.action == "opened"`, want: true},
+		{expr: `$.action != "opened"`, want: false},
+		{expr: "$.number > 41", want: true},
+		{expr: "$.number <= 41", want: false},
+		{expr: "$.number == 42.0", want: true},
+		{expr: `$.number == "42"`, want: false},
+		{expr: "$.draft == false", want: true},
+		{expr: "$.milestone == null", want: true},
+		// Keys are looked up in array items and comparisons hold when any value does, as in lax mode
+		{expr: `$.labels.name == "urgent"`, want: true},
+		{expr: `$.labels[*].name == "bug"`, want: true},
+		{expr: `$.labels[1].name == "bug"`, want: false},
+		{expr: `$.commits[*].author.name != "bot"`, want: true},
+		{expr: "$.matrix[*] == 3", want: true},
+		{expr: "$.number[0] == 42", want: true},
+		{expr: "$.number[1]", want: false},
+		{expr: `$.* == "opened"`, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			expr, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if got := expr.MatchJSON([]byte(doc)); got != tt.want {
+				t.Errorf("MatchJSON() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchJSONRejectsNonJSON(t *testing.T) {
+	expr, err := Parse("$")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	for _, doc := range []string{"", "plain text", `{"a": 1} {"b": 2}`, `{"a": }`} {
+		if expr.MatchJSON([]byte(doc)) {
+			t.Errorf("MatchJSON(%q) = true, want false", doc)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	// Longest first, so "<=" isn't read as "<"
+	operators = []string{"==", "!=", "<>", "<=", ">=", "<", ">"}
+)
+
+type parser struct {
+	src string
+	pos int
+}
+
+func (p *parser) parse() (*Expr, error) {
+	p.skipSpaces()
+	if !p.consume("$") {
+		return nil, errors.New("expression must start with $")
+	}
+
+	var expr Expr
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case '.':
+			p.pos++
+			seg, err := p.parseMember()
+			if err != nil {
+				return nil, err
+			}
+			expr.path = append(expr.path, seg)
+			continue
+		case '[':
+			p.pos++
+			seg, err := p.parseSubscript()
+			if err != nil {
+				return nil, err
+			}
+			expr.path = append(expr.path, seg)
+			continue
+		}
+		break
+	}
+
+	p.skipSpaces()
+	if p.pos == len(p.src) {
+		return &expr, nil
+	}
+
+	for _, op := range operators {
+		if p.consume(op) {
+			expr.op = op
+			break
+		}
+	}
+	if expr.op == "" {
+		return nil, fmt.Errorf("unexpected %q at %d", p.src[p.pos:], p.pos)
+	}
+
+	p.skipSpaces()
+	value, err := p.parseLiteral()
+	if err != nil {
+		return nil, err
+	}
+	expr.value = value
+
+	p.skipSpaces()
+	if p.pos != len(p.src) {
+		return nil, fmt.Errorf("unexpected %q at %d", p.src[p.pos:], p.pos)
+	}
+	return &expr, nil
+}
+
+func (p *parser) parseMember() (segment, error) {
+	if p.consume("*") {
+		return segment{kind: segmentAnyKey}, nil
+	}
+	if p.peek() == '"' {
+		key, err := p.parseString()
+		if err != nil {
+			return segment{}, err
+		}
+		return segment{kind: segmentKey, key: key}, nil
+	}
+
+	start := p.pos
+	for p.pos < len(p.src) && isIdentChar(p.src[p.pos], p.pos == start) {
+		p.pos++
+	}
+	if p.pos == start {
+		return segment{}, fmt.Errorf("missing key at %d", start)
+	}
+	return segment{kind: segmentKey, key: p.src[start:p.pos]}, nil
+}
+
+func (p *parser) parseSubscript() (segment, error) {
+	p.skipSpaces()
+
+	var seg segment
+	switch c := p.peek(); {
+	case c == '*':
+		p.pos++
+		seg = segment{kind: segmentAnyIndex}
+	case c == '"' || c == '\'':
+		key, err := p.parseString()
+		if err != nil {
+			return segment{}, err
+		}
+		seg = segment{kind: segmentKey, key: key}
+	case c >= '0' && c <= '9':
+		start := p.pos
+		for p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+			p.pos++
+		}
+		index, err := strconv.Atoi(p.src[start:p.pos])
+		if err != nil {
+			return segment{}, fmt.Errorf("invalid index at %d", start)
+		}
+		seg = segment{kind: segmentIndex, index: index}
+	default:
+		return segment{}, fmt.Errorf("invalid subscript at %d", p.pos)
+	}
+
+	p.skipSpaces()
+	if !p.consume("]") {
+		return segment{}, fmt.Errorf("missing ] at %d", p.pos)
+	}
+	return seg, nil
+}
+
+func (p *parser) parseLiteral() (any, error) {
+	switch c := p.peek(); {
+	case c == '"' || c == '\'':
+		return p.parseString()
+	case c == '-' || (c >= '0' && c <= '9'):
+		start := p.pos
+		p.pos++
+		for p.pos < len(p.src) && strings.IndexByte("0123456789.eE+-", p.src[p.pos]) >= 0 {
+			p.pos++
+		}
+		num := json.Number(p.src[start:p.pos])
+		if !json.Valid([]byte(num)) {
+			return nil, fmt.Errorf("invalid number %q", num)
+		}
+		return num, nil
+	}
+
+	for word, value := range map[string]any{"true": true, "false": false, "null": nil} {
+		if p.consume(word) {
+			return value, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid literal at %d", p.pos)
+}
+
+// parseString reads a double or single quoted string, with JSON escapes.
+func (p *parser) parseString() (string, error) {
+	quote := p.src[p.pos]
+	start := p.pos
+	p.pos++
+
+	var raw strings.Builder
+	raw.WriteByte('"')
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == '\\' && p.pos+1 < len(p.src):
+			raw.WriteString(p.src[p.pos : p.pos+2])
+			p.pos += 2
+			continue
+		case c == quote:
+			p.pos++
+			raw.WriteByte('"')
+
+			var s string
+			if err := json.Unmarshal([]byte(raw.String()), &s); err != nil {
+				return "", fmt.Errorf("invalid string at %d", start)
+			}
+			return s, nil
+		case c == '"':
+			// Only possible in single quoted strings
+			raw.WriteString(`\"`)
+		default:
+			raw.WriteByte(c)
+		}
+		p.pos++
+	}
+	return "", fmt.Errorf("unterminated string at %d", start)
+}
+
+func (p *parser) peek() byte {
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+func (p *parser) consume(s string) bool {
+	if strings.HasPrefix(p.src[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (p *parser) skipSpaces() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func isIdentChar(c byte, first bool) bool {
+	switch {
+	case c == '_', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		return true
+	case c >= '0' && c <= '9':
+		return !first
+	}
+	return false
+}
@@ -0,0 +1,237 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// IsPath reports whether the expression is a path alone, without comparison.
+func (e *Expr) IsPath() bool {
+	return e.op == ""
+}
+
+// UpdateJSON replaces every value the path selects in the JSON document with the JSON fn returns for it, or removes
+// it from its object or array when fn returns false. A removed document becomes null. The comparison of the
+// expression, if any, is ignored.
+//
+// The document is edited where the values are, the rest of it is kept byte for byte: key order, duplicate keys,
+// spacing and number formats. UpdateJSON returns the updated document and how many values were selected, data itself
+// when none was. It fails when data isn't a single JSON document.
+func (e *Expr) UpdateJSON(data []byte, fn func(raw []byte) ([]byte, bool)) ([]byte, int, error) {
+	// Valid also rejects anything trailing the document
+	if !json.Valid(data) {
+		return nil, 0, errors.New("not a single JSON document")
+	}
+
+	u := updater{src: data, fn: fn}
+	start := u.skipSpaces(0)
+	value, end, keep := u.update(start, e.path)
+	if u.n == 0 {
+		return data, 0, nil
+	}
+	if !keep {
+		value = []byte("null")
+	}
+
+	rs := make([]byte, 0, len(data))
+	rs = append(rs, data[:start]...)
+	rs = append(rs, value...)
+	rs = append(rs, data[end:]...)
+	return rs, u.n, nil
+}
+
+// updater rewrites the values a path selects in a valid JSON document.
+type updater struct {
+	src []byte
+	fn  func(raw []byte) ([]byte, bool)
+	// n counts the values selected
+	n int
+}
+
+// update returns the value starting at pos with the path applied, the position after it in src and whether it's
+// kept. Values off the path are returned as they are in src.
+func (u *updater) update(pos int, path []segment) ([]byte, int, bool) {
+	if len(path) == 0 {
+		end := u.skipValue(pos)
+		u.n++
+		rs, keep := u.fn(u.src[pos:end])
+		return rs, end, keep
+	}
+
+	seg, rest := path[0], path[1:]
+	switch seg.kind {
+	case segmentKey, segmentAnyKey:
+		switch u.src[pos] {
+		case '[':
+			// Keys are looked up in the items, as in lax mode
+			return u.updateArray(pos, func(int) ([]segment, bool) { return path, true })
+		case '{':
+			return u.updateObject(pos, seg, rest)
+		}
+	case segmentIndex, segmentAnyIndex:
+		if u.src[pos] == '[' {
+			return u.updateArray(pos, func(i int) ([]segment, bool) {
+				return rest, seg.kind == segmentAnyIndex || i == seg.index
+			})
+		}
+		// A single value acts as an array of itself
+		if seg.kind == segmentAnyIndex || seg.index == 0 {
+			return u.update(pos, rest)
+		}
+	}
+	end := u.skipValue(pos)
+	return u.src[pos:end], end, true
+}
+
+// updateObject applies the rest of the path to the members matching seg, removed members take their comma along.
+func (u *updater) updateObject(pos int, seg segment, rest []segment) ([]byte, int, bool) {
+	rs := []byte{'{'}
+	first := true
+	i := pos + 1
+	for {
+		// Members keep the spaces around them
+		start := i
+		i = u.skipSpaces(i)
+		if u.src[i] == '}' {
+			rs = append(rs, u.src[start:i]...)
+			return append(rs, '}'), i + 1, true
+		}
+
+		keyEnd := u.skipString(i)
+		match := seg.kind == segmentAnyKey
+		if !match {
+			var key string
+			match = json.Unmarshal(u.src[i:keyEnd], &key) == nil && key == seg.key
+		}
+		valueStart := u.skipSpaces(u.skipSpaces(keyEnd) + 1)
+
+		var (
+			value    []byte
+			valueEnd int
+			keep     = true
+		)
+		if match {
+			value, valueEnd, keep = u.update(valueStart, rest)
+		} else {
+			valueEnd = u.skipValue(valueStart)
+			value = u.src[valueStart:valueEnd]
+		}
+		next := u.skipSpaces(valueEnd)
+
+		if keep {
+			if !first {
+				rs = append(rs, ',')
+			}
+			rs = append(rs, u.src[start:valueStart]...)
+			rs = append(rs, value...)
+			rs = append(rs, u.src[valueEnd:next]...)
+			first = false
+		}
+		if u.src[next] == '}' {
+			return append(rs, '}'), next + 1, true
+		}
+		i = next + 1
+	}
+}
+
+// updateArray applies the path selected for each item, removed items take their comma along.
+func (u *updater) updateArray(pos int, selected func(i int) ([]segment, bool)) ([]byte, int, bool) {
+	rs := []byte{'['}
+	first := true
+	i := pos + 1
+	for idx := 0; ; idx++ {
+		start := i
+		i = u.skipSpaces(i)
+		if u.src[i] == ']' {
+			rs = append(rs, u.src[start:i]...)
+			return append(rs, ']'), i + 1, true
+		}
+
+		var (
+			item []byte
+			end  int
+			keep = true
+		)
+		if path, ok := selected(idx); ok {
+			item, end, keep = u.update(i, path)
+		} else {
+			end = u.skipValue(i)
+			item = u.src[i:end]
+		}
+		next := u.skipSpaces(end)
+
+		if keep {
+			if !first {
+				rs = append(rs, ',')
+			}
+			rs = append(rs, u.src[start:i]...)
+			rs = append(rs, item...)
+			rs = append(rs, u.src[end:next]...)
+			first = false
+		}
+		if u.src[next] == ']' {
+			return append(rs, ']'), next + 1, true
+		}
+		i = next + 1
+	}
+}
+
+func (u *updater) skipSpaces(i int) int {
+	for i < len(u.src) {
+		switch u.src[i] {
+		case ' ', '\t', '\n', '\r':
+			i++
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// skipString returns the position after the string starting at i.
+func (u *updater) skipString(i int) int {
+	for i++; i < len(u.src); i++ {
+		switch u.src[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return i
+}
+
+// skipValue returns the position after the value starting at i.
+func (u *updater) skipValue(i int) int {
+	switch u.src[i] {
+	case '"':
+		return u.skipString(i)
+	case '{', '[':
+		depth := 0
+		for i < len(u.src) {
+			switch u.src[i] {
+			case '"':
+				i = u.skipString(i)
+				continue
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+				if depth == 0 {
+					return i + 1
+				}
+			}
+			i++
+		}
+		return i
+	}
+	// Numbers, true, false and null run until a delimiter
+	for i < len(u.src) {
+		switch u.src[i] {
+		case ',', '}', ']', ' ', '\t', '\n', '\r':
+			return i
+		}
+		i++
+	}
+	return i
+}
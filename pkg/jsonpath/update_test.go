@@ -0,0 +1,166 @@
+package jsonpath
+
+import (
+	"testing"
+)
+
+func TestUpdateJSON(t *testing.T) {
+	mask := func([]byte) ([]byte, bool) { return []byte(`"****"`), true }
+	remove := func([]byte) ([]byte, bool) { return nil, false }
+
+	tests := []struct {
+		name  string
+		path  string
+		doc   string
+		fn    func([]byte) ([]byte, bool)
+		want  string
+		wantN int
+	}{
+		{
+			name:  "key",
+			path:  "$.card.number",
+			doc:   `{"card": {"number": 4111111111111111, "exp": "12/30"}, "amount": 1.50}`,
+			fn:    mask,
+			want:  `{"card": {"number": "****", "exp": "12/30"}, "amount": 1.50}`,
+			wantN: 1,
+		},
+		{
+			name:  "duplicate keys",
+			path:  "$.secret",
+			doc:   `{"secret":"a","other":1,"secret":"b"}`,
+			fn:    mask,
+			want:  `{"secret":"****","other":1,"secret":"****"}`,
+			wantN: 2,
+		},
+		{
+			name:  "keys in array items",
+			path:  "$.items.cvv",
+			doc:   `{"items":[{"cvv":123,"n":1},{"n":2},{"cvv":"456"}]}`,
+			fn:    mask,
+			want:  `{"items":[{"cvv":"****","n":1},{"n":2},{"cvv":"****"}]}`,
+			wantN: 2,
+		},
+		{
+			name:  "index",
+			path:  "$.list[1]",
+			doc:   `{"list": [1, 2e10, 3]}`,
+			fn:    mask,
+			want:  `{"list": [1, "****", 3]}`,
+			wantN: 1,
+		},
+		{
+			name:  "single value as an array",
+			path:  "$.token[0]",
+			doc:   `{"token": "abc"}`,
+			fn:    mask,
+			want:  `{"token": "****"}`,
+			wantN: 1,
+		},
+		{
+			name:  "any key",
+			path:  "$.*",
+			doc:   "{\n  \"a\": 1,\n  \"b\": {\"c\": [true]}\n}",
+			fn:    mask,
+			want:  "{\n  \"a\": \"****\",\n  \"b\": \"****\"\n}",
+			wantN: 2,
+		},
+		{
+			name:  "escaped strings",
+			path:  `$."k\"ey"`,
+			doc:   `{"k\"ey": "va\"l}", "next": "]"}`,
+			fn:    mask,
+			want:  `{"k\"ey": "****", "next": "]"}`,
+			wantN: 1,
+		},
+		{
+			name:  "remove members",
+			path:  "$.a",
+			doc:   `{"a": 1, "b": 2, "a": 3}`,
+			fn:    remove,
+			want:  `{ "b": 2}`,
+			wantN: 2,
+		},
+		{
+			name:  "remove items",
+			path:  "$[*].cvv",
+			doc:   `[{"cvv": 1, "n": 2}, {"cvv": 3}]`,
+			fn:    remove,
+			want:  `[{ "n": 2}, {}]`,
+			wantN: 2,
+		},
+		{
+			name:  "remove array items",
+			path:  "$.list[0]",
+			doc:   `{"list": [1, 2]}`,
+			fn:    remove,
+			want:  `{"list": [ 2]}`,
+			wantN: 1,
+		},
+		{
+			name:  "remove the document",
+			path:  "$",
+			doc:   ` {"a": 1} `,
+			fn:    remove,
+			want:  ` null `,
+			wantN: 1,
+		},
+		{
+			name: "no match",
+			path: "$.missing",
+			doc:  `{"a":  1.0}`,
+			fn:   mask,
+			want: `{"a":  1.0}`,
+		},
+		{
+			name: "comparison ignored",
+			path: `$.a == 2`,
+			doc:  `{"a": 1}`,
+			fn:   mask,
+			want: `{"a": "****"}`,
+			// The comparison doesn't hold, the path alone selects
+			wantN: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Parse(tt.path)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			got, n, err := expr.UpdateJSON([]byte(tt.doc), tt.fn)
+			if err != nil {
+				t.Fatalf("update: %v", err)
+			}
+			if string(got) != tt.want || n != tt.wantN {
+				t.Errorf("UpdateJSON() = %s, %d, want %s, %d", got, n, tt.want, tt.wantN)
+			}
+		})
+	}
+}
+
+func TestUpdateJSONKeepsUnmatchedDocument(t *testing.T) {
+	expr, err := Parse("$.missing")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	doc := []byte(`{"a": 1}`)
+	got, _, err := expr.UpdateJSON(doc, func([]byte) ([]byte, bool) { return nil, false })
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if &got[0] != &doc[0] {
+		t.Error("UpdateJSON() copied a document it didn't change")
+	}
+}
+
+func TestUpdateJSONRejectsNonJSON(t *testing.T) {
+	expr, err := Parse("$.a")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	for _, doc := range []string{"", "plain text", `{"a": 1} {"a": 2}`, `{"a": 1}]`, `{"a": }`} {
+		if _, _, err := expr.UpdateJSON([]byte(doc), func([]byte) ([]byte, bool) { return nil, false }); err == nil {
+			t.Errorf("UpdateJSON(%q) succeeded, want an error", doc)
+		}
+	}
+}
@@ -1,19 +1,39 @@
 package middleware
 
 import (
+	"crypto/subtle"
 	"net/http"
-	"os"
 )
 
-func AuthKey(next http.Handler) http.Handler {
-	secretKey := os.Getenv("SECRET_KEY")
+const (
+	secretKeyHeader = "X-API-Secret"
+	secretKeyParam  = "x-api-secret"
+)
+
+// AuthKey returns a middleware only letting through requests carrying the admin secretKey.
+// Every request is denied when secretKey is empty.
+func AuthKey(secretKey string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !HasSecretKey(r, secretKey) {
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
 
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Query().Get("x-api-secret") != secretKey {
-			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
-			return
-		}
+// HasSecretKey reports whether the request carries the admin secretKey, in header or query.
+func HasSecretKey(r *http.Request, secretKey string) bool {
+	if secretKey == "" {
+		return false
+	}
 
-		next.ServeHTTP(w, r)
-	})
+	key := r.Header.Get(secretKeyHeader)
+	if key == "" {
+		key = r.URL.Query().Get(secretKeyParam)
+	}
+	return subtle.ConstantTimeCompare([]byte(key), []byte(secretKey)) == 1
 }
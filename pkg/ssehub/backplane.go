@@ -0,0 +1,71 @@
+package ssehub
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+const (
+	publishTimeout = 5 * time.Second
+)
+
+// Backplane fans messages out to every hub sharing it, so clients connected to
+// any instance receive what was sent on another one.
+type Backplane interface {
+	// Publish sends the message to every hub listening on the backplane, including the sender.
+	// An empty room means the message is broadcast to all rooms.
+	Publish(ctx context.Context, room string, e Message) error
+
+	// Listen calls deliver for each published message until ctx is done. Messages published while it reconnects may
+	// be missed, it calls resync once listening again.
+	Listen(ctx context.Context, deliver func(room string, e Message), resync func()) error
+}
+
+// WithBackplane makes the hub publish through the backplane and deliver what it receives from it.
+func WithBackplane(bp Backplane) HubOption {
+	return func(h *Hub) {
+		h.backplane = bp
+	}
+}
+
+// Run delivers messages coming from the backplane to local clients until ctx is done.
+// It returns immediately when the hub has no backplane.
+func (h *Hub) Run(ctx context.Context) error {
+	if h.backplane == nil {
+		return nil
+	}
+
+	return h.backplane.Listen(ctx, func(room string, e Message) {
+		if room == "" {
+			h.broadcastLocal(e)
+			return
+		}
+		if err := h.sendToRoomLocal(room, e); err != nil && err != ErrRoomNotFound {
+			log.Printf("[SSE] Error delivering backplane message to room %s: %s", room, err)
+		}
+	}, h.resync)
+}
+
+// resync makes every client replay what it may have missed after the last message written to it.
+func (h *Hub) resync() {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, clients := range h.rooms {
+		for _, cl := range clients {
+			select {
+			case cl.resyncCh <- struct{}{}:
+			default:
+				// already resyncing
+			}
+		}
+	}
+}
+
+func (h *Hub) publish(room string, e Message) error {
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+
+	return h.backplane.Publish(ctx, room, e)
+}
@@ -0,0 +1,134 @@
+package ssehub
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeBackplane hands its listener to the test, which plays the messages coming back and the reconnections.
+type fakeBackplane struct {
+	listening chan struct{}
+	deliver   func(room string, e Message)
+	resync    func()
+}
+
+func (b *fakeBackplane) Publish(ctx context.Context, room string, e Message) error {
+	b.deliver(room, e)
+	return nil
+}
+
+func (b *fakeBackplane) Listen(ctx context.Context, deliver func(room string, e Message), resync func()) error {
+	b.deliver, b.resync = deliver, resync
+	close(b.listening)
+	<-ctx.Done()
+	return nil
+}
+
+// recordingTransport keeps what's written, in order.
+type recordingTransport struct {
+	mu      sync.Mutex
+	written []Message
+	notify  chan struct{}
+}
+
+func newRecordingTransport() *recordingTransport {
+	return &recordingTransport{notify: make(chan struct{}, 64)}
+}
+
+func (t *recordingTransport) Open() error {
+	return nil
+}
+
+func (t *recordingTransport) Write(e Message) error {
+	t.mu.Lock()
+	t.written = append(t.written, e)
+	t.mu.Unlock()
+	t.notify <- struct{}{}
+	return nil
+}
+
+// waitIDs waits for n messages and returns their IDs.
+func (t *recordingTransport) waitIDs(tb testing.TB, n int) []string {
+	tb.Helper()
+
+	timeout := time.After(2 * time.Second)
+	for {
+		t.mu.Lock()
+		if len(t.written) >= n {
+			ids := make([]string, 0, len(t.written))
+			for _, e := range t.written {
+				ids = append(ids, e.ID)
+			}
+			t.mu.Unlock()
+			return ids
+		}
+		t.mu.Unlock()
+
+		select {
+		case <-t.notify:
+		case <-timeout:
+			tb.Fatalf("got %d messages, want %d", len(t.written), n)
+		}
+	}
+}
+
+func TestHubResyncsClientsAfterBackplaneGap(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bp := &fakeBackplane{listening: make(chan struct{})}
+	hub := NewHub(WithBackplane(bp), WithHeartbeatInterval(time.Hour))
+	go hub.Run(ctx)
+	<-bp.listening
+
+	// The store holds every message, the replay reads it after the given ID
+	stored := []Message{{ID: "1"}, {ID: "2"}, {ID: "3"}}
+	replay := func(ctx context.Context, lastEventID string, send func(Message) error) error {
+		after, _ := strconv.Atoi(lastEventID)
+		for _, e := range stored {
+			if id, _ := strconv.Atoi(e.ID); id > after {
+				if err := send(e); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	tr := newRecordingTransport()
+	if _, err := hub.SubscribeTransport(ctx, "room", "client", tr, WithReplay("", replay)); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	if err := hub.SendToRoom("room", stored[0]); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	tr.waitIDs(t, 1)
+
+	// 2 and 3 are lost while reconnecting, the resync replays them and the live copies coming late are skipped,
+	// whatever their order
+	bp.resync()
+	tr.waitIDs(t, 3)
+	for _, e := range []Message{stored[0], stored[2], stored[1]} {
+		if err := hub.SendToRoom("room", e); err != nil {
+			t.Fatalf("send: %v", err)
+		}
+	}
+	if err := hub.SendToRoom("room", Message{ID: "4"}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	ids := tr.waitIDs(t, 4)
+	want := []string{"1", "2", "3", "4"}
+	if len(ids) != len(want) {
+		t.Fatalf("written %v, want %v", ids, want)
+	}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Fatalf("written %v, want %v", ids, want)
+		}
+	}
+}
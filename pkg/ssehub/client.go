@@ -4,12 +4,14 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 const (
-	heartbeatInterval = 25 * time.Second
+	defaultHeartbeatInterval = 25 * time.Second
 )
 
 type Client struct {
@@ -18,84 +20,184 @@ type Client struct {
 	ctx        context.Context
 	cancel     context.CancelFunc
 	sendCh     chan Message
+	lastCh     chan Message  // the message written before closing, ahead of what's still queued
+	done       chan struct{} // closed once the writer is done with the transport
 	connected  time.Time
-	lastActive time.Time
+	lastActive atomic.Int64 // unix nano
+	remoteAddr string
+	userAgent  string
+
+	policy            DeliveryPolicy
+	blockTimeout      time.Duration
+	heartbeatInterval time.Duration
+	heartbeats        *atomic.Int64 // the hub's count of heartbeats sent
+	dropped           atomic.Int64
+
+	transport Transport
+	// paused, skipped, resumeCh and filter are set by the client's commands, see control.go
+	paused   atomic.Bool
+	skipMu   sync.Mutex
+	skipped  *Message
+	resumeCh chan struct{}
+	filter   atomic.Pointer[Filter]
+
+	lastEventID string
+	lastID      string // of the last message written, only used by the writer
+	resyncCh    chan struct{}
+	replay      ReplayFunc
+	replaying   atomic.Bool
+	replayedTo  int64 // highest ID replayed, live copies of messages up to it may still be queued
 }
 
 func (c *Client) String() string {
 	return fmt.Sprintf("client[%s] room=%s", c.id, c.room)
 }
 
+// Wait is closed once the client is disconnected and nothing more will be written to its transport.
 func (c *Client) Wait() <-chan struct{} {
-	return c.ctx.Done()
+	return c.done
+}
+
+func (c *Client) LastActive() time.Time {
+	return time.Unix(0, c.lastActive.Load())
+}
+
+// Dropped returns how many messages were not delivered to the client because it was too slow.
+func (c *Client) Dropped() int64 {
+	return c.dropped.Load()
+}
+
+func (c *Client) touch() {
+	c.lastActive.Store(time.Now().UnixNano())
 }
 
-func (c *Client) writerLoop(w http.ResponseWriter, flusher http.Flusher) {
-	// send initial comment to establish connection
-	fmt.Fprintf(w, ": connected\n\n")
-	flusher.Flush()
+func (c *Client) writerLoop() {
+	defer close(c.done)
+
+	if err := c.transport.Open(); err != nil {
+		log.Printf("[SSE] Error opening %s: %s", c, err)
+		c.cancel()
+		return
+	}
+
+	c.lastID = c.lastEventID
+	c.replayAfter(c.lastEventID)
 
 	for {
 		select {
 		case <-c.ctx.Done():
 			return
+		case ev := <-c.lastCh:
+			c.write(ev)
+			c.cancel()
+			return
+		case <-c.resumeCh:
+			c.resumeWriting()
+		case <-c.resyncCh:
+			c.resync()
 		case ev := <-c.sendCh:
-			c.lastActive = time.Now()
-			writeEvent(w, ev)
-			flusher.Flush()
+			if c.wasReplayed(ev.ID) {
+				// already delivered by the replay
+				continue
+			}
+			c.touch()
+			c.write(ev)
 		}
 	}
 }
 
-// writeEvent writes event payload to client
-// https://html.spec.whatwg.org/multipage/server-sent-events.html#event-stream-interpretation
-func writeEvent(w http.ResponseWriter, ev Message) {
-	var err error
-	if ev.Event != "" {
-		_, err = fmt.Fprintf(w, "event: %s\n", ev.Event)
-	}
-	for _, line := range splitLines(ev.Data) {
-		_, err = fmt.Fprintf(w, "data: %s\n", line)
+// write hands the message to the transport, a client that can't be written to anymore is disconnected.
+func (c *Client) write(ev Message) {
+	if err := c.transport.Write(ev); err != nil {
+		log.Printf("[SSE] Error writing to %s: %s", c, err)
+		c.cancel()
+		return
 	}
 	if ev.ID != "" {
-		_, err = fmt.Fprintf(w, "id: %s\n", ev.ID)
+		c.lastID = ev.ID
 	}
-	if ev.Retry != 0 {
-		_, err = fmt.Fprintf(w, "retry: %d\n", ev.Retry)
+}
+
+// resync replays what the client may have missed while the hub's backplane was reconnecting.
+func (c *Client) resync() {
+	if c.paused.Load() {
+		// it replays from what it skipped when resuming
+		return
+	}
+	if c.replay == nil || c.lastID == "" {
+		log.Printf("[SSE] %s may have missed messages while the backplane was reconnecting", c)
+		return
 	}
+	c.replayAfter(c.lastID)
+}
 
-	_, err = fmt.Fprint(w, "\n")
-	if err != nil {
-		log.Printf("[SSE] Error writing data: %s", err)
+// closeWith writes the message to the client then disconnects it, whatever is still queued is left for the
+// client to replay when it reconnects.
+func (c *Client) closeWith(ev Message) {
+	select {
+	case c.lastCh <- ev:
+	default:
+		// already closing
 	}
 }
 
-func splitLines(s string) []string {
-	// naive split; could use strings.Split if no special behavior needed
-	var lines []string
-	current := ""
-	for _, r := range s {
-		if r == '\n' {
-			lines = append(lines, current)
-			current = ""
-		} else {
-			current += string(r)
+// replayAfter writes the messages the client missed after lastEventID,
+// remembering the highest ID so the live copies queued meanwhile can be skipped.
+func (c *Client) replayAfter(lastEventID string) {
+	if c.replay == nil || lastEventID == "" {
+		return
+	}
+
+	c.replaying.Store(true)
+	defer c.replaying.Store(false)
+	err := c.replay(c.ctx, lastEventID, func(ev Message) error {
+		if err := c.ctx.Err(); err != nil {
+			return err
 		}
+		c.noteReplayed(ev.ID)
+		if !c.accepts(ev) {
+			return nil
+		}
+		c.touch()
+		c.write(ev)
+		return nil
+	})
+	if err != nil {
+		log.Printf("[SSE] Error replaying %s from %s: %s", c, lastEventID, err)
 	}
-	if current != "" {
-		lines = append(lines, current)
+}
+
+// noteReplayed records the ID of a message replayed.
+func (c *Client) noteReplayed(id string) {
+	if n, err := strconv.ParseInt(id, 10, 64); err == nil && n > c.replayedTo {
+		c.replayedTo = n
 	}
-	return lines
+}
+
+// wasReplayed tells whether a live message was delivered by a replay already. Replays go in ID order, every
+// message up to the highest ID replayed was, however late its live copy comes.
+func (c *Client) wasReplayed(id string) bool {
+	if c.replayedTo == 0 {
+		return false
+	}
+	n, err := strconv.ParseInt(id, 10, 64)
+	return err == nil && n <= c.replayedTo
 }
 
 func (c *Client) heartbeat() {
-	ticker := time.NewTicker(heartbeatInterval)
+	ticker := time.NewTicker(c.heartbeatInterval)
 	defer ticker.Stop()
 	for {
 		select {
 		case <-c.ctx.Done():
 			return
 		case <-ticker.C:
+			// A writer replaying or with messages queued keeps the connection alive already, a full queue is left
+			// to the client's delivery policy when the next message comes
+			if c.replaying.Load() || len(c.sendCh) > 0 {
+				continue
+			}
+
 			ev := Message{
 				Event: EventTypeHeartbeat,
 				Data:  fmt.Sprintf("heartbeat %d", time.Now().Unix()),
@@ -103,11 +205,9 @@ func (c *Client) heartbeat() {
 
 			select {
 			case c.sendCh <- ev:
+				c.heartbeats.Add(1)
 			default:
-				// if even heartbeat can't be enqueued, treat as slow/unresponsive and cancel
-				log.Printf("[SSE] client unresponsive, closing %s", c)
-				c.cancel()
-				return
+				// filled meanwhile
 			}
 		}
 	}
@@ -2,4 +2,6 @@ package ssehub
 
 const (
 	EventTypeHeartbeat = "heartbeat"
+	// EventTypeShutdown is the last message of a hub shutting down, its retry tells clients when to reconnect
+	EventTypeShutdown = "shutdown"
 )
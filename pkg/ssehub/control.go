@@ -0,0 +1,81 @@
+package ssehub
+
+// Filter reports whether a client wants the message. Heartbeats and the shutdown message always get through.
+type Filter func(e Message) bool
+
+// WithFilter only delivers the messages accepted by the filter to the client.
+func WithFilter(f Filter) SubscribeOption {
+	return func(c *Client) {
+		c.SetFilter(f)
+	}
+}
+
+// SetFilter replaces the client's filter, nil lets every message through.
+func (c *Client) SetFilter(f Filter) {
+	if f == nil {
+		c.filter.Store(nil)
+		return
+	}
+	c.filter.Store(&f)
+}
+
+func (c *Client) accepts(e Message) bool {
+	f := c.filter.Load()
+	return f == nil || (*f)(e)
+}
+
+// Pause stops delivering messages to the client until Resume, heartbeats aside. The messages sent meanwhile are not
+// queued, so a paused client never falls behind.
+func (c *Client) Pause() {
+	c.skipMu.Lock()
+	defer c.skipMu.Unlock()
+
+	if !c.paused.Load() {
+		c.skipped = nil
+		c.paused.Store(true)
+	}
+}
+
+// Resume delivers messages to the client again, starting with those it missed while paused when it was subscribed
+// WithReplay.
+func (c *Client) Resume() {
+	select {
+	case c.resumeCh <- struct{}{}:
+	default:
+		// already resuming
+	}
+}
+
+// skip records a message sent while the client is paused, it reports whether the client is.
+func (c *Client) skip(e Message) bool {
+	if !c.paused.Load() {
+		return false
+	}
+
+	c.skipMu.Lock()
+	defer c.skipMu.Unlock()
+
+	// Replays start after a known ID, the first message missed is kept to start from it
+	if c.skipped == nil && e.ID != "" && c.accepts(e) {
+		c.skipped = &e
+	}
+	return true
+}
+
+// resumeWriting runs in the writer, it writes what was missed while paused before the live messages queued again.
+func (c *Client) resumeWriting() {
+	c.skipMu.Lock()
+	first := c.skipped
+	c.skipped = nil
+	c.paused.Store(false)
+	c.skipMu.Unlock()
+
+	if first == nil || c.replay == nil {
+		return
+	}
+
+	c.noteReplayed(first.ID)
+	c.touch()
+	c.write(*first)
+	c.replayAfter(first.ID)
+}
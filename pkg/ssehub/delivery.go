@@ -0,0 +1,82 @@
+package ssehub
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// DeliveryPolicy decides what happens to a message when a client's send queue is full.
+type DeliveryPolicy string
+
+const (
+	// DropOldest discards the oldest queued message to make room for the new one.
+	DropOldest DeliveryPolicy = "drop-oldest"
+	// DisconnectSlow drops the message and closes the client, letting it reconnect and resume from its Last-Event-ID.
+	DisconnectSlow DeliveryPolicy = "disconnect-slow"
+	// BlockWithTimeout waits for room in the queue up to the hub's block timeout, then drops the message.
+	BlockWithTimeout DeliveryPolicy = "block"
+)
+
+const (
+	defaultDeliveryPolicy = DisconnectSlow
+	defaultBlockTimeout   = 2 * time.Second
+)
+
+func ParseDeliveryPolicy(s string) (DeliveryPolicy, error) {
+	switch p := DeliveryPolicy(s); p {
+	case DropOldest, DisconnectSlow, BlockWithTimeout:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown delivery policy %q", s)
+	}
+}
+
+// enqueue queues the message for the client according to its delivery policy,
+// it reports whether the message was queued.
+func (c *Client) enqueue(e Message) bool {
+	if !c.accepts(e) || c.skip(e) {
+		return false
+	}
+
+	select {
+	case c.sendCh <- e:
+		return true
+	default:
+	}
+
+	switch c.policy {
+	case DropOldest:
+		for {
+			select {
+			case <-c.sendCh:
+				c.dropped.Add(1)
+			default:
+			}
+
+			select {
+			case c.sendCh <- e:
+				return true
+			default:
+				// writer is racing with us, try again
+			}
+		}
+	case BlockWithTimeout:
+		timer := time.NewTimer(c.blockTimeout)
+		defer timer.Stop()
+
+		select {
+		case c.sendCh <- e:
+			return true
+		case <-timer.C:
+		case <-c.ctx.Done():
+		}
+		c.dropped.Add(1)
+		return false
+	default:
+		c.dropped.Add(1)
+		log.Printf("[SSE] client too slow, closing %s", c)
+		c.cancel()
+		return false
+	}
+}
@@ -0,0 +1,42 @@
+package ssehub
+
+import (
+	"errors"
+	"log"
+)
+
+var (
+	ErrClientNotFound = errors.New("client not found")
+)
+
+// Disconnect closes the client of the room. Like any disconnected client it may reconnect, EventSource does so by
+// itself.
+func (h *Hub) Disconnect(room string, clientID string) error {
+	h.mu.RLock()
+	cl, ok := h.rooms[room][clientID]
+	h.mu.RUnlock()
+	if !ok {
+		return ErrClientNotFound
+	}
+
+	log.Printf("[SSE] Disconnecting %s", cl)
+	cl.cancel()
+	return nil
+}
+
+// DisconnectRoom closes every client of the room and returns how many there were. Unlike CloseRoom the room is
+// kept, so clients may subscribe again.
+func (h *Hub) DisconnectRoom(room string) int {
+	h.mu.RLock()
+	clients := make([]*Client, 0, len(h.rooms[room]))
+	for _, cl := range h.rooms[room] {
+		clients = append(clients, cl)
+	}
+	h.mu.RUnlock()
+
+	for _, cl := range clients {
+		log.Printf("[SSE] Disconnecting %s", cl)
+		cl.cancel()
+	}
+	return len(clients)
+}
@@ -1,7 +1,49 @@
 package ssehub
 
-func NewHub() *Hub {
-	return &Hub{
-		rooms: make(map[string]map[string]*Client),
+import (
+	"time"
+)
+
+type HubOption func(h *Hub)
+
+// WithDefaultDeliveryPolicy sets the delivery policy of clients that don't pick their own.
+func WithDefaultDeliveryPolicy(policy DeliveryPolicy) HubOption {
+	return func(h *Hub) {
+		h.policy = policy
+	}
+}
+
+// WithBlockTimeout sets how long BlockWithTimeout clients may hold up a message.
+func WithBlockTimeout(timeout time.Duration) HubOption {
+	return func(h *Hub) {
+		h.blockTimeout = timeout
+	}
+}
+
+// WithHeartbeatInterval sets how often clients get a heartbeat.
+func WithHeartbeatInterval(interval time.Duration) HubOption {
+	return func(h *Hub) {
+		h.heartbeatInterval = interval
+	}
+}
+
+// WithSendBuffer sets how many messages a client may fall behind before its delivery policy applies.
+func WithSendBuffer(size int) HubOption {
+	return func(h *Hub) {
+		h.sendBuffer = size
+	}
+}
+
+func NewHub(opts ...HubOption) *Hub {
+	h := &Hub{
+		rooms:             make(map[string]map[string]*Client),
+		policy:            defaultDeliveryPolicy,
+		blockTimeout:      defaultBlockTimeout,
+		heartbeatInterval: defaultHeartbeatInterval,
+		sendBuffer:        defaultSendBuffer,
+	}
+	for _, opt := range opts {
+		opt(h)
 	}
+	return h
 }
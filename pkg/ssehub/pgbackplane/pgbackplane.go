@@ -0,0 +1,247 @@
+// Package pgbackplane implements an ssehub.Backplane on top of Postgres LISTEN/NOTIFY.
+package pgbackplane
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/erwin-lovecraft/pistol/pkg/ssehub"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	DefaultChannel = "pistol_hub"
+
+	// NOTIFY payloads must be shorter than 8000 bytes, bigger messages are split in chunks
+	// sent in a single transaction so they're delivered together and in order.
+	chunkSize = 7000
+
+	reconnectDelay = time.Second
+	partialTTL     = time.Minute
+)
+
+var _ ssehub.Backplane = (*Backplane)(nil)
+
+type Backplane struct {
+	pool    *pgxpool.Pool
+	channel string
+}
+
+func New(pool *pgxpool.Pool) *Backplane {
+	return &Backplane{
+		pool:    pool,
+		channel: DefaultChannel,
+	}
+}
+
+// WithChannel returns a copy of the backplane using another notification channel,
+// hubs only see each other when they share the channel.
+func (b *Backplane) WithChannel(channel string) *Backplane {
+	return &Backplane{
+		pool:    b.pool,
+		channel: channel,
+	}
+}
+
+type envelope struct {
+	Room  string `json:"room"`
+	Event string `json:"event,omitempty"`
+	Data  string `json:"data"`
+	ID    string `json:"id,omitempty"`
+	Retry int64  `json:"retry,omitempty"`
+}
+
+func (b *Backplane) Publish(ctx context.Context, room string, e ssehub.Message) error {
+	payload, err := json.Marshal(envelope{
+		Room:  room,
+		Event: e.Event,
+		Data:  e.Data,
+		ID:    e.ID,
+		Retry: e.Retry,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal message: %w", err)
+	}
+
+	chunks := splitChunks(string(payload), chunkSize)
+	msgID := uuid.NewString()
+
+	tx, err := b.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for idx, chunk := range chunks {
+		// Format: <message id>:<chunk index>:<chunk count>:<chunk>
+		notification := fmt.Sprintf("%s:%d:%d:%s", msgID, idx, len(chunks), chunk)
+		if _, err := tx.Exec(ctx, "SELECT pg_notify($1, $2)", b.channel, notification); err != nil {
+			return fmt.Errorf("notify: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+func (b *Backplane) Listen(ctx context.Context, deliver func(room string, e ssehub.Message), resync func()) error {
+	asm := assembler{partials: make(map[string]*partial)}
+
+	reconnecting := false
+	for {
+		err := b.listen(ctx, func() {
+			if reconnecting {
+				// Notifications aren't queued for connections that aren't listening
+				log.Printf("[pgbackplane] listening again, resyncing clients over the gap")
+				resync()
+			}
+		}, func(payload string) {
+			data, ok, err := asm.add(payload)
+			if err != nil {
+				log.Printf("[pgbackplane] invalid notification: %v", err)
+				return
+			}
+			if !ok {
+				return
+			}
+
+			var env envelope
+			if err := json.Unmarshal([]byte(data), &env); err != nil {
+				log.Printf("[pgbackplane] unmarshal message: %v", err)
+				return
+			}
+			deliver(env.Room, ssehub.Message{
+				Event: env.Event,
+				Data:  env.Data,
+				ID:    env.ID,
+				Retry: env.Retry,
+			})
+		})
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		reconnecting = true
+		log.Printf("[pgbackplane] listen interrupted, reconnecting: %v", err)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+// listen calls ready once listening then handle for each notification, until the connection fails or ctx is done.
+func (b *Backplane) listen(ctx context.Context, ready func(), handle func(payload string)) error {
+	pooled, err := b.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire conn: %w", err)
+	}
+	// The connection is left in LISTEN state, don't give it back to the pool
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+quoteIdentifier(b.channel)); err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+	ready()
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("wait for notification: %w", err)
+		}
+		handle(n.Payload)
+	}
+}
+
+type partial struct {
+	chunks   []string
+	received int
+	started  time.Time
+}
+
+// assembler puts chunked notifications back together.
+type assembler struct {
+	mu       sync.Mutex
+	partials map[string]*partial
+}
+
+func (a *assembler) add(payload string) (string, bool, error) {
+	parts := strings.SplitN(payload, ":", 4)
+	if len(parts) != 4 {
+		return "", false, errors.New("malformed payload")
+	}
+	idx, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return "", false, fmt.Errorf("chunk index: %w", err)
+	}
+	total, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return "", false, fmt.Errorf("chunk count: %w", err)
+	}
+	if total < 1 || idx < 0 || idx >= total {
+		return "", false, errors.New("chunk out of range")
+	}
+	if total == 1 {
+		return parts[3], true, nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	for id, p := range a.partials {
+		if now.Sub(p.started) > partialTTL {
+			delete(a.partials, id)
+		}
+	}
+
+	p, ok := a.partials[parts[0]]
+	if !ok {
+		p = &partial{chunks: make([]string, total), started: now}
+		a.partials[parts[0]] = p
+	}
+	if len(p.chunks) != total {
+		return "", false, errors.New("chunk count mismatch")
+	}
+	if p.chunks[idx] == "" {
+		p.received++
+	}
+	p.chunks[idx] = parts[3]
+	if p.received < total {
+		return "", false, nil
+	}
+
+	delete(a.partials, parts[0])
+	return strings.Join(p.chunks, ""), true, nil
+}
+
+// splitChunks splits s in chunks of at most size bytes without breaking UTF-8 sequences.
+func splitChunks(s string, size int) []string {
+	var chunks []string
+	for len(s) > size {
+		cut := size
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		chunks = append(chunks, s[:cut])
+		s = s[cut:]
+	}
+	return append(chunks, s)
+}
+
+func quoteIdentifier(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
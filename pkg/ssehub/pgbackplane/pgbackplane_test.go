@@ -0,0 +1,159 @@
+package pgbackplane
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/erwin-lovecraft/pistol/pkg/ssehub"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// testPGURLEnv names the database the integration tests run against, they're skipped when it's unset.
+const testPGURLEnv = "PISTOL_TEST_PG_URL"
+
+func TestSplitChunks(t *testing.T) {
+	s := strings.Repeat("a", 5) + strings.Repeat("é", 10) + "z"
+	chunks := splitChunks(s, 4)
+
+	if got := strings.Join(chunks, ""); got != s {
+		t.Fatalf("joined chunks = %q, want %q", got, s)
+	}
+	for _, chunk := range chunks {
+		if len(chunk) > 4 {
+			t.Errorf("chunk %q is longer than 4 bytes", chunk)
+		}
+		if !utf8.ValidString(chunk) {
+			t.Errorf("chunk %q splits a UTF-8 sequence", chunk)
+		}
+	}
+}
+
+func TestAssembler(t *testing.T) {
+	asm := assembler{partials: make(map[string]*partial)}
+
+	data, ok, err := asm.add("m1:0:1:whole")
+	if err != nil || !ok || data != "whole" {
+		t.Fatalf("single chunk = %q, %v, %v", data, ok, err)
+	}
+
+	// Chunks may arrive out of order and twice
+	for _, payload := range []string{"m2:2:3:c", "m2:0:3:a", "m2:0:3:a"} {
+		if _, ok, err := asm.add(payload); err != nil || ok {
+			t.Fatalf("add(%q) = %v, %v, want incomplete", payload, ok, err)
+		}
+	}
+	data, ok, err = asm.add("m2:1:3:b:with:colons")
+	if err != nil || !ok || data != "ab:with:colonsc" {
+		t.Fatalf("last chunk = %q, %v, %v", data, ok, err)
+	}
+
+	for _, payload := range []string{"m3", "m3:x:1:a", "m3:0:x:a", "m3:1:1:a", "m3:0:0:a"} {
+		if _, _, err := asm.add(payload); err == nil {
+			t.Errorf("add(%q) succeeded, want an error", payload)
+		}
+	}
+}
+
+type delivery struct {
+	room string
+	msg  ssehub.Message
+}
+
+func TestBackplane(t *testing.T) {
+	pgURL := os.Getenv(testPGURLEnv)
+	if pgURL == "" {
+		t.Skipf("%s is not set", testPGURLEnv)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	pool, err := pgxpool.New(ctx, pgURL)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer pool.Close()
+
+	channel := "pistol_test_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	bp := New(pool).WithChannel(channel)
+
+	deliveries := make(chan delivery, 16)
+	resyncs := make(chan struct{}, 1)
+	listenCtx, stopListening := context.WithCancel(ctx)
+	defer stopListening()
+	go bp.Listen(listenCtx, func(room string, e ssehub.Message) {
+		deliveries <- delivery{room: room, msg: e}
+	}, func() {
+		resyncs <- struct{}{}
+	})
+
+	waitListening(t, ctx, bp, deliveries)
+
+	big := strings.Repeat("0123456789é", 2000)
+	if err := bp.Publish(ctx, "room-1", ssehub.Message{Event: "message", Data: big, ID: "42"}); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	got := receive(t, ctx, deliveries)
+	if got.room != "room-1" || got.msg.ID != "42" || got.msg.Event != "message" || got.msg.Data != big {
+		t.Fatalf("received %+v, want the chunked message back", got)
+	}
+
+	// Drop the listening connection, the backplane reconnects and asks for a resync
+	if _, err := pool.Exec(ctx, "SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE query = $1",
+		"LISTEN "+quoteIdentifier(channel)); err != nil {
+		t.Fatalf("terminate listener: %v", err)
+	}
+	select {
+	case <-resyncs:
+	case <-ctx.Done():
+		t.Fatal("no resync after reconnecting")
+	}
+
+	if err := bp.Publish(ctx, "", ssehub.Message{Data: "after"}); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	if got := receive(t, ctx, deliveries); got.room != "" || got.msg.Data != "after" {
+		t.Fatalf("received %+v after reconnecting, want the broadcast", got)
+	}
+}
+
+// waitListening publishes pings until the listener gets one, then drops those still in flight.
+func waitListening(t *testing.T, ctx context.Context, bp *Backplane, deliveries chan delivery) {
+	t.Helper()
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		if err := bp.Publish(ctx, "ping", ssehub.Message{Data: "ping"}); err != nil {
+			t.Fatalf("publish ping: %v", err)
+		}
+		select {
+		case <-deliveries:
+			time.Sleep(200 * time.Millisecond)
+			for len(deliveries) > 0 {
+				<-deliveries
+			}
+			return
+		case <-ticker.C:
+		case <-ctx.Done():
+			t.Fatal("listener never got a ping")
+		}
+	}
+}
+
+func receive(t *testing.T, ctx context.Context, deliveries chan delivery) delivery {
+	t.Helper()
+
+	select {
+	case d := <-deliveries:
+		return d
+	case <-ctx.Done():
+		t.Fatal("no message received")
+		return delivery{}
+	}
+}
@@ -2,39 +2,84 @@ package ssehub
 
 import (
 	"errors"
+	"fmt"
+	"sync"
 )
 
+var (
+	ErrRoomNotFound = errors.New("room not found")
+)
+
+// SendToRoom delivers the message to every client of the room. A slow client never
+// prevents delivery to the others, it's handled by its own delivery policy.
+// With a backplane the message is published cluster-wide and delivered once it comes back.
 func (h *Hub) SendToRoom(room string, e Message) error {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
+	if h.backplane != nil {
+		if err := h.publish(room, e); err != nil {
+			return fmt.Errorf("publish to backplane: %w", err)
+		}
+		return nil
+	}
+
+	return h.sendToRoomLocal(room, e)
+}
+
+func (h *Hub) Broadcast(e Message) error {
+	if h.backplane != nil {
+		if err := h.publish("", e); err != nil {
+			return fmt.Errorf("publish to backplane: %w", err)
+		}
+		return nil
+	}
+
+	h.broadcastLocal(e)
+	return nil
+}
 
+func (h *Hub) sendToRoomLocal(room string, e Message) error {
+	h.mu.RLock()
 	clients, exists := h.rooms[room]
 	if !exists {
-		return errors.New("room not found")
+		h.mu.RUnlock()
+		return ErrRoomNotFound
 	}
-
+	targets := make([]*Client, 0, len(clients))
 	for _, cl := range clients {
-		select {
-		case cl.sendCh <- e:
-		default:
-			return errors.New("send channel is full")
-		}
+		targets = append(targets, cl)
 	}
+	h.mu.RUnlock()
+
+	deliver(targets, e)
 	return nil
 }
 
-func (h *Hub) Broadcast(e Message) error {
+func (h *Hub) broadcastLocal(e Message) {
 	h.mu.RLock()
-	defer h.mu.RUnlock()
-
+	var targets []*Client
 	for _, clients := range h.rooms {
 		for _, cl := range clients {
-			select {
-			case cl.sendCh <- e:
-			default:
-				return errors.New("send channel is full")
-			}
+			targets = append(targets, cl)
 		}
 	}
-	return nil
+	h.mu.RUnlock()
+
+	deliver(targets, e)
+}
+
+// deliver enqueues the message to the clients outside the hub lock, blocking clients
+// wait in parallel so the slowest one bounds the call rather than their sum.
+func deliver(targets []*Client, e Message) {
+	var wg sync.WaitGroup
+	for _, cl := range targets {
+		if cl.policy == BlockWithTimeout {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				cl.enqueue(e)
+			}()
+			continue
+		}
+		cl.enqueue(e)
+	}
+	wg.Wait()
 }
@@ -0,0 +1,47 @@
+package ssehub
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrHubClosed = errors.New("hub is shutting down")
+)
+
+// Shutdown refuses new subscriptions and sends every client a last EventTypeShutdown message asking it to reconnect
+// after retry, to another instance hopefully, then disconnects it. Clients that haven't got the message when ctx is
+// done are cut off. Messages still queued are not sent, clients get them back by resuming from their Last-Event-ID.
+func (h *Hub) Shutdown(ctx context.Context, retry time.Duration) error {
+	h.mu.Lock()
+	h.closed = true
+	var clients []*Client
+	for _, room := range h.rooms {
+		for _, cl := range room {
+			clients = append(clients, cl)
+		}
+	}
+	h.mu.Unlock()
+
+	ev := Message{
+		Event: EventTypeShutdown,
+		Data:  "server shutting down",
+		Retry: retry.Milliseconds(),
+	}
+	for _, cl := range clients {
+		cl.closeWith(ev)
+	}
+
+	for _, cl := range clients {
+		select {
+		case <-cl.done:
+		case <-ctx.Done():
+			for _, cl := range clients {
+				cl.cancel()
+			}
+			return ctx.Err()
+		}
+	}
+	return nil
+}
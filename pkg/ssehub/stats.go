@@ -1,5 +1,11 @@
 package ssehub
 
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
 func (h *Hub) TotalConnections() int {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
@@ -20,3 +26,125 @@ func (h *Hub) RoomConnections(roomID string) int {
 	}
 	return 0
 }
+
+// HubStats sums up the hub's activity, the counters start with the hub.
+type HubStats struct {
+	// Rooms is how many rooms have at least one client
+	Rooms       int
+	Connections int
+	Connects    int64
+	Disconnects int64
+	Heartbeats  int64
+	Dropped     int64
+}
+
+func (h *Hub) Stats() HubStats {
+	h.mu.RLock()
+	stats := HubStats{
+		Connects:    h.connects.Load(),
+		Disconnects: h.disconnects.Load(),
+		Heartbeats:  h.heartbeats.Load(),
+		Dropped:     h.dropped.Load(),
+	}
+	for _, clients := range h.rooms {
+		if len(clients) > 0 {
+			stats.Rooms++
+		}
+		stats.Connections += len(clients)
+		for _, cl := range clients {
+			stats.Dropped += cl.Dropped()
+		}
+	}
+	h.mu.RUnlock()
+	return stats
+}
+
+type ClientStats struct {
+	ID         string         `json:"id"`
+	Room       string         `json:"room"`
+	RemoteAddr string         `json:"remote_addr"`
+	UserAgent  string         `json:"user_agent"`
+	Policy     DeliveryPolicy `json:"policy"`
+	Connected  time.Time      `json:"connected_at"`
+	LastActive time.Time      `json:"last_active_at"`
+	QueueDepth int            `json:"queue_depth"`
+	Dropped    int64          `json:"dropped"`
+}
+
+// ClientStats returns the clients of the room, longest connected first.
+func (h *Hub) ClientStats(roomID string) []ClientStats {
+	h.mu.RLock()
+	clients := h.rooms[roomID]
+	stats := make([]ClientStats, 0, len(clients))
+	for _, cl := range clients {
+		stats = append(stats, cl.stats())
+	}
+	h.mu.RUnlock()
+
+	sortClientStats(stats)
+	return stats
+}
+
+func sortClientStats(stats []ClientStats) {
+	slices.SortFunc(stats, func(a, b ClientStats) int {
+		return a.Connected.Compare(b.Connected)
+	})
+}
+
+func (c *Client) stats() ClientStats {
+	return ClientStats{
+		ID:         c.id,
+		Room:       c.room,
+		RemoteAddr: c.remoteAddr,
+		UserAgent:  c.userAgent,
+		Policy:     c.policy,
+		Connected:  c.connected,
+		LastActive: c.LastActive(),
+		QueueDepth: len(c.sendCh),
+		Dropped:    c.Dropped(),
+	}
+}
+
+type RoomStats struct {
+	ID      string        `json:"id"`
+	Clients []ClientStats `json:"clients"`
+}
+
+// RoomStats returns the rooms with at least one client, by ID, along with their clients longest connected first.
+func (h *Hub) RoomStats() []RoomStats {
+	h.mu.RLock()
+	stats := make([]RoomStats, 0, len(h.rooms))
+	for id, clients := range h.rooms {
+		if len(clients) == 0 {
+			continue
+		}
+		room := RoomStats{ID: id, Clients: make([]ClientStats, 0, len(clients))}
+		for _, cl := range clients {
+			room.Clients = append(room.Clients, cl.stats())
+		}
+		stats = append(stats, room)
+	}
+	h.mu.RUnlock()
+
+	slices.SortFunc(stats, func(a, b RoomStats) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	for _, room := range stats {
+		sortClientStats(room.Clients)
+	}
+	return stats
+}
+
+// DroppedMessages returns how many messages were dropped by slow clients since the hub started.
+func (h *Hub) DroppedMessages() int64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	total := h.dropped.Load()
+	for _, clients := range h.rooms {
+		for _, cl := range clients {
+			total += cl.Dropped()
+		}
+	}
+	return total
+}
@@ -2,52 +2,102 @@ package ssehub
 
 import (
 	"context"
-	"errors"
 	"log"
 	"net/http"
 	"time"
 )
 
 const (
-	sendBuffer = 64
+	defaultSendBuffer = 64
 )
 
-func (h *Hub) Subscribe(ctx context.Context, room string, clientID string, w http.ResponseWriter) (*Client, error) {
-	// Setup SSE headers
-	w.Header().Set("Content-Type", "text/event-stream")
-	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("Connection", "keep-alive")
-	w.Header().Set("X-Accel-Buffering", "no")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-
-	flusher, ok := w.(http.Flusher)
-	if !ok {
-		return nil, errors.New("streaming is not supported")
+// ReplayFunc streams, oldest first, the messages a client missed after lastEventID.
+type ReplayFunc func(ctx context.Context, lastEventID string, send func(Message) error) error
+
+type SubscribeOption func(c *Client)
+
+// WithReplay makes the client receive what it missed since lastEventID, when set, before the live stream, and what
+// it missed while paused when resuming. Live messages already delivered by the replay, with an integer ID up to the
+// highest one replayed, are skipped, so the client sees no gap or duplicate.
+func WithReplay(lastEventID string, replay ReplayFunc) SubscribeOption {
+	return func(c *Client) {
+		if replay == nil {
+			return
+		}
+		c.lastEventID = lastEventID
+		c.replay = replay
+	}
+}
+
+// WithDeliveryPolicy overrides the hub's delivery policy for the client.
+func WithDeliveryPolicy(policy DeliveryPolicy) SubscribeOption {
+	return func(c *Client) {
+		if policy != "" {
+			c.policy = policy
+		}
+	}
+}
+
+// WithRemote records who the client is, for the hub's stats.
+func WithRemote(addr string, userAgent string) SubscribeOption {
+	return func(c *Client) {
+		c.remoteAddr = addr
+		c.userAgent = userAgent
 	}
+}
 
+// Subscribe streams the room's messages to w as server-sent events.
+func (h *Hub) Subscribe(ctx context.Context, room string, clientID string, w http.ResponseWriter, opts ...SubscribeOption) (*Client, error) {
+	t, err := NewSSETransport(w)
+	if err != nil {
+		return nil, err
+	}
+	return h.SubscribeTransport(ctx, room, clientID, t, opts...)
+}
+
+// SubscribeTransport streams the room's messages over t, until ctx is done or the client is disconnected.
+func (h *Hub) SubscribeTransport(ctx context.Context, room string, clientID string, t Transport, opts ...SubscribeOption) (*Client, error) {
 	ctx, cancel := context.WithCancel(ctx)
 	client := &Client{
-		id:         clientID,
-		room:       room,
-		ctx:        ctx,
-		cancel:     cancel,
-		sendCh:     make(chan Message, sendBuffer), // buffered to absorb burst
-		connected:  time.Now(),
-		lastActive: time.Now(),
+		id:                clientID,
+		room:              room,
+		ctx:               ctx,
+		cancel:            cancel,
+		sendCh:            make(chan Message, h.sendBuffer), // buffered to absorb burst
+		lastCh:            make(chan Message, 1),
+		resumeCh:          make(chan struct{}, 1),
+		resyncCh:          make(chan struct{}, 1),
+		done:              make(chan struct{}),
+		connected:         time.Now(),
+		policy:            h.policy,
+		blockTimeout:      h.blockTimeout,
+		heartbeatInterval: h.heartbeatInterval,
+		heartbeats:        &h.heartbeats,
+		transport:         t,
+	}
+	client.touch()
+	for _, opt := range opts {
+		opt(client)
 	}
 
-	// Register
+	// Register before replaying so that nothing pushed in the meantime is missed
 	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		cancel()
+		return nil, ErrHubClosed
+	}
 	if _, exists := h.rooms[room]; !exists {
 		h.rooms[room] = make(map[string]*Client)
 	}
 	h.rooms[room][clientID] = client
 	h.mu.Unlock()
+	h.connects.Add(1)
 
 	log.Printf("[SSE] Subcribed %s, rooms size: %d", client, h.RoomConnections(room))
 
 	// Start writer goroutine
-	go client.writerLoop(w, flusher)
+	go client.writerLoop()
 
 	// Start a heartbeat to keep connection alive / detect silent drop
 	go client.heartbeat()
@@ -56,6 +106,7 @@ func (h *Hub) Subscribe(ctx context.Context, room string, clientID string, w htt
 	go func() {
 		<-ctx.Done()
 		h.unregister(room, clientID)
+		h.disconnects.Add(1)
 	}()
 
 	return client, nil
@@ -70,7 +121,8 @@ func (h *Hub) unregister(room string, clientID string) {
 		return
 	}
 
-	if _, exists := clients[clientID]; exists {
+	if cl, exists := clients[clientID]; exists {
+		h.dropped.Add(cl.Dropped())
 		delete(clients, clientID)
 	}
 
@@ -0,0 +1,90 @@
+package ssehub
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Transport carries a client's messages, as server-sent events or over any other stream. Its methods are only
+// called from the client's writer goroutine.
+type Transport interface {
+	// Open starts the stream, before any message is written
+	Open() error
+
+	Write(e Message) error
+}
+
+type sseTransport struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+// NewSSETransport streams messages as server-sent events to w, setting its headers. Nothing is written until the
+// client is subscribed, so a failed subscription may still be answered with an error.
+func NewSSETransport(w http.ResponseWriter) (Transport, error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, errors.New("streaming is not supported")
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	return sseTransport{w: w, flusher: flusher}, nil
+}
+
+func (t sseTransport) Open() error {
+	// send initial comment to establish connection
+	if _, err := fmt.Fprintf(t.w, ": connected\n\n"); err != nil {
+		return err
+	}
+	t.flusher.Flush()
+	return nil
+}
+
+// Write writes event payload to client
+// https://html.spec.whatwg.org/multipage/server-sent-events.html#event-stream-interpretation
+func (t sseTransport) Write(ev Message) error {
+	var b strings.Builder
+	if ev.Event != "" {
+		fmt.Fprintf(&b, "event: %s\n", ev.Event)
+	}
+	for _, line := range splitLines(ev.Data) {
+		fmt.Fprintf(&b, "data: %s\n", line)
+	}
+	if ev.ID != "" {
+		fmt.Fprintf(&b, "id: %s\n", ev.ID)
+	}
+	if ev.Retry != 0 {
+		fmt.Fprintf(&b, "retry: %d\n", ev.Retry)
+	}
+	b.WriteString("\n")
+
+	if _, err := fmt.Fprint(t.w, b.String()); err != nil {
+		return err
+	}
+	t.flusher.Flush()
+	return nil
+}
+
+func splitLines(s string) []string {
+	// naive split; could use strings.Split if no special behavior needed
+	var lines []string
+	current := ""
+	for _, r := range s {
+		if r == '\n' {
+			lines = append(lines, current)
+			current = ""
+		} else {
+			current += string(r)
+		}
+	}
+	if current != "" {
+		lines = append(lines, current)
+	}
+	return lines
+}
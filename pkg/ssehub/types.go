@@ -2,6 +2,8 @@ package ssehub
 
 import (
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type Message struct {
@@ -9,11 +11,26 @@ type Message struct {
 	Data  string
 	ID    string
 	Retry int64
+	// Value is what Data encodes, for filters to look at without decoding it. It's not sent, nor shared through the
+	// backplane.
+	Value any
 }
 
 type Hub struct {
 	rooms map[string]map[string]*Client
 	mu    sync.RWMutex
+
+	policy       DeliveryPolicy
+	blockTimeout time.Duration
+	// heartbeatInterval is how often idle clients get a heartbeat, so proxies keep the connection open
+	heartbeatInterval time.Duration
+	sendBuffer        int
+	dropped           atomic.Int64 // messages dropped by clients no longer connected
+	connects          atomic.Int64
+	disconnects       atomic.Int64
+	heartbeats        atomic.Int64 // shared by the clients, see Client.heartbeat
+	backplane         Backplane
+	closed            bool // set by Shutdown, no client may subscribe anymore
 }
 
 func (h *Hub) NewRoom(id string) {
@@ -24,3 +41,16 @@ func (h *Hub) NewRoom(id string) {
 		h.rooms[id] = make(map[string]*Client)
 	}
 }
+
+// CloseRoom disconnects every client subscribed to the room and forgets it.
+func (h *Hub) CloseRoom(id string) {
+	h.mu.Lock()
+	clients := h.rooms[id]
+	delete(h.rooms, id)
+	h.mu.Unlock()
+
+	for _, cl := range clients {
+		h.dropped.Add(cl.Dropped())
+		cl.cancel()
+	}
+}
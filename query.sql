@@ -1,12 +1,151 @@
 -- name: SaveEvent :one
-INSERT INTO events (id, method, header, query_params, body, room_id)
-VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (id) DO
+INSERT INTO events (id, method, header, query_params, body, body_base64, content_type, content_length, response, signature_verdict, signature_reason, room_id, created_at, path, remote_addr, proto, host, tls, duration_us, redacted)
+VALUES (sqlc.arg('id'), sqlc.arg('method'), sqlc.arg('header'), sqlc.arg('query_params'), sqlc.arg('body'), sqlc.arg('body_base64'), sqlc.arg('content_type'), sqlc.arg('content_length'), sqlc.arg('response'), sqlc.arg('signature_verdict'), sqlc.arg('signature_reason'), sqlc.arg('room_id'), COALESCE(sqlc.narg('created_at')::TIMESTAMPTZ, NOW()), sqlc.arg('path'), sqlc.arg('remote_addr'), sqlc.arg('proto'), sqlc.arg('host'), sqlc.arg('tls'), sqlc.arg('duration_us'), sqlc.arg('redacted')) ON CONFLICT (id) DO
 UPDATE SET method = EXCLUDED.method,
     header = EXCLUDED.header,
     query_params = EXCLUDED.query_params,
     body = EXCLUDED.body,
-    room_id = EXCLUDED.room_id
+    body_base64 = EXCLUDED.body_base64,
+    content_type = EXCLUDED.content_type,
+    content_length = EXCLUDED.content_length,
+    response = EXCLUDED.response,
+    signature_verdict = EXCLUDED.signature_verdict,
+    signature_reason = EXCLUDED.signature_reason,
+    room_id = EXCLUDED.room_id,
+    path = EXCLUDED.path,
+    remote_addr = EXCLUDED.remote_addr,
+    proto = EXCLUDED.proto,
+    host = EXCLUDED.host,
+    tls = EXCLUDED.tls,
+    duration_us = EXCLUDED.duration_us,
+    redacted = EXCLUDED.redacted
 RETURNING created_at;
 
 -- name: ListEvents :many
-SELECT * FROM events WHERE room_id = $1 ORDER BY created_at DESC OFFSET $2 LIMIT $3;
+SELECT * FROM events
+WHERE room_id = sqlc.arg('room_id')
+    AND (sqlc.narg('before')::BIGINT IS NULL OR id < sqlc.narg('before'))
+    AND (sqlc.narg('signature_verdict')::TEXT IS NULL OR signature_verdict = sqlc.narg('signature_verdict'))
+    AND (sqlc.narg('method')::TEXT IS NULL OR method = sqlc.narg('method'))
+    AND (sqlc.narg('created_from')::TIMESTAMPTZ IS NULL OR created_at >= sqlc.narg('created_from'))
+    AND (sqlc.narg('created_to')::TIMESTAMPTZ IS NULL OR created_at < sqlc.narg('created_to'))
+    AND (sqlc.narg('header_names')::TEXT[] IS NULL OR header ?& sqlc.narg('header_names'))
+    AND (sqlc.narg('header_values')::JSONB IS NULL OR header @> sqlc.narg('header_values'))
+    AND (sqlc.narg('query_names')::TEXT[] IS NULL OR query_params ?& sqlc.narg('query_names'))
+    AND (sqlc.narg('query_values')::JSONB IS NULL OR query_params @> sqlc.narg('query_values'))
+    AND (sqlc.narg('body_contains')::BYTEA IS NULL OR position(sqlc.narg('body_contains') IN body) > 0)
+    AND (sqlc.narg('body_path')::TEXT IS NULL OR event_body_json(body) @@ sqlc.narg('body_path')::TEXT::JSONPATH)
+    AND (sqlc.narg('path_suffix')::TEXT IS NULL OR right(path, length(sqlc.narg('path_suffix'))) = sqlc.narg('path_suffix'))
+ORDER BY id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListEventsNewer :many
+SELECT * FROM events
+WHERE room_id = sqlc.arg('room_id')
+    AND id > sqlc.arg('after')
+    AND (sqlc.narg('signature_verdict')::TEXT IS NULL OR signature_verdict = sqlc.narg('signature_verdict'))
+    AND (sqlc.narg('method')::TEXT IS NULL OR method = sqlc.narg('method'))
+    AND (sqlc.narg('created_from')::TIMESTAMPTZ IS NULL OR created_at >= sqlc.narg('created_from'))
+    AND (sqlc.narg('created_to')::TIMESTAMPTZ IS NULL OR created_at < sqlc.narg('created_to'))
+    AND (sqlc.narg('header_names')::TEXT[] IS NULL OR header ?& sqlc.narg('header_names'))
+    AND (sqlc.narg('header_values')::JSONB IS NULL OR header @> sqlc.narg('header_values'))
+    AND (sqlc.narg('query_names')::TEXT[] IS NULL OR query_params ?& sqlc.narg('query_names'))
+    AND (sqlc.narg('query_values')::JSONB IS NULL OR query_params @> sqlc.narg('query_values'))
+    AND (sqlc.narg('body_contains')::BYTEA IS NULL OR position(sqlc.narg('body_contains') IN body) > 0)
+    AND (sqlc.narg('body_path')::TEXT IS NULL OR event_body_json(body) @@ sqlc.narg('body_path')::TEXT::JSONPATH)
+    AND (sqlc.narg('path_suffix')::TEXT IS NULL OR right(path, length(sqlc.narg('path_suffix'))) = sqlc.narg('path_suffix'))
+ORDER BY id ASC
+LIMIT sqlc.arg('limit');
+
+-- name: SaveRoom :one
+INSERT INTO rooms (id, name, avatar)
+VALUES ($1, $2, $3) ON CONFLICT (id) DO
+UPDATE SET name = EXCLUDED.name,
+    avatar = EXCLUDED.avatar,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: GetRoom :one
+SELECT * FROM rooms WHERE id = $1;
+
+-- name: ListRooms :many
+SELECT * FROM rooms ORDER BY created_at DESC;
+
+-- name: UpdateRoomName :one
+UPDATE rooms SET name = $2, updated_at = NOW() WHERE id = $1 RETURNING *;
+
+-- name: UpdateRoomResponse :one
+UPDATE rooms SET response = $2, updated_at = NOW() WHERE id = $1 RETURNING *;
+
+-- name: UpdateRoomForwards :one
+UPDATE rooms SET forwards = $2, updated_at = NOW() WHERE id = $1 RETURNING *;
+
+-- name: UpdateRoomSignature :one
+UPDATE rooms SET signature = $2, updated_at = NOW() WHERE id = $1 RETURNING *;
+
+-- name: DeleteRoom :execrows
+DELETE FROM rooms WHERE id = $1;
+
+-- name: ListEventsAfter :many
+SELECT * FROM events WHERE room_id = $1 AND id > $2 ORDER BY id ASC LIMIT $3;
+
+-- name: GetEvent :one
+SELECT * FROM events WHERE room_id = $1 AND id = $2;
+
+-- name: SaveReplayAttempt :one
+INSERT INTO replay_attempts (id, event_id, target_url, request_header, status_code, response_header, response_body, response_body_base64, latency_ms, error, source, attempt)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+RETURNING created_at;
+
+-- name: ListReplayAttempts :many
+SELECT * FROM replay_attempts WHERE event_id = $1 ORDER BY id DESC LIMIT $2;
+
+-- name: SaveRoomToken :one
+INSERT INTO room_tokens (id, room_id, name, scope, prefix, token_hash, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetRoomToken :one
+SELECT * FROM room_tokens WHERE room_id = $1 AND id = $2;
+
+-- name: ListRoomTokens :many
+SELECT * FROM room_tokens WHERE room_id = $1 ORDER BY id DESC;
+
+-- name: ListActiveRoomTokens :many
+SELECT * FROM room_tokens
+WHERE room_id = $1 AND scope = $2 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW());
+
+-- name: RevokeRoomToken :one
+UPDATE room_tokens SET revoked_at = NOW()
+WHERE room_id = $1 AND id = $2 AND revoked_at IS NULL
+RETURNING *;
+
+-- name: UpdateRoomRetention :one
+UPDATE rooms SET retention = $2, updated_at = NOW() WHERE id = $1 RETURNING *;
+
+-- name: UpdateRoomRedaction :one
+UPDATE rooms SET redaction = $2, updated_at = NOW() WHERE id = $1 RETURNING *;
+
+-- name: GetEventCountCutoff :one
+SELECT id FROM events WHERE room_id = sqlc.arg('room_id') ORDER BY id DESC OFFSET sqlc.arg('max_events')::BIGINT LIMIT 1;
+
+-- name: GetEventBytesCutoff :one
+SELECT id FROM (
+    SELECT id, SUM(content_length) OVER (ORDER BY id DESC) AS total_bytes
+    FROM events WHERE room_id = sqlc.arg('room_id')
+) AS sized
+WHERE total_bytes > sqlc.arg('max_bytes')::BIGINT
+ORDER BY id DESC
+LIMIT 1;
+
+-- name: PruneEvents :many
+DELETE FROM events
+WHERE id IN (
+    SELECT pruned.id FROM events AS pruned
+    WHERE pruned.room_id = sqlc.arg('room_id')
+        AND (pruned.id <= sqlc.narg('max_id') OR pruned.created_at < sqlc.narg('created_before'))
+    ORDER BY pruned.id
+    LIMIT sqlc.arg('limit')
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING content_length;
@@ -0,0 +1,140 @@
+-- name: SaveEvent :one
+INSERT INTO events (id, room_id, method, header, query_params, body, body_base64, content_type, content_length, response, signature_verdict, signature_reason, path, remote_addr, proto, host, tls, duration_us, redacted, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT (id) DO
+UPDATE SET method = excluded.method,
+    header = excluded.header,
+    query_params = excluded.query_params,
+    body = excluded.body,
+    body_base64 = excluded.body_base64,
+    content_type = excluded.content_type,
+    content_length = excluded.content_length,
+    response = excluded.response,
+    signature_verdict = excluded.signature_verdict,
+    signature_reason = excluded.signature_reason,
+    room_id = excluded.room_id,
+    path = excluded.path,
+    remote_addr = excluded.remote_addr,
+    proto = excluded.proto,
+    host = excluded.host,
+    tls = excluded.tls,
+    duration_us = excluded.duration_us,
+    redacted = excluded.redacted
+RETURNING created_at;
+
+-- name: ListEvents :many
+-- Header, query param and body path criteria have no SQLite equivalent, they're matched on the listed rows
+SELECT * FROM events
+WHERE room_id = sqlc.arg('room_id')
+    AND (sqlc.narg('before') IS NULL OR id < sqlc.narg('before'))
+    AND (sqlc.narg('signature_verdict') IS NULL OR signature_verdict = sqlc.narg('signature_verdict'))
+    AND (sqlc.narg('method') IS NULL OR method = sqlc.narg('method'))
+    AND (sqlc.narg('created_from') IS NULL OR created_at >= sqlc.narg('created_from'))
+    AND (sqlc.narg('created_to') IS NULL OR created_at < sqlc.narg('created_to'))
+    AND (sqlc.narg('body_contains') IS NULL OR instr(body, sqlc.narg('body_contains')) > 0)
+    AND (sqlc.narg('path_suffix') IS NULL OR substr(path, 0 - length(sqlc.narg('path_suffix'))) = sqlc.narg('path_suffix'))
+ORDER BY id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListEventsNewer :many
+-- Header, query param and body path criteria have no SQLite equivalent, they're matched on the listed rows
+SELECT * FROM events
+WHERE room_id = sqlc.arg('room_id')
+    AND id > sqlc.arg('after')
+    AND (sqlc.narg('signature_verdict') IS NULL OR signature_verdict = sqlc.narg('signature_verdict'))
+    AND (sqlc.narg('method') IS NULL OR method = sqlc.narg('method'))
+    AND (sqlc.narg('created_from') IS NULL OR created_at >= sqlc.narg('created_from'))
+    AND (sqlc.narg('created_to') IS NULL OR created_at < sqlc.narg('created_to'))
+    AND (sqlc.narg('body_contains') IS NULL OR instr(body, sqlc.narg('body_contains')) > 0)
+    AND (sqlc.narg('path_suffix') IS NULL OR substr(path, 0 - length(sqlc.narg('path_suffix'))) = sqlc.narg('path_suffix'))
+ORDER BY id ASC
+LIMIT sqlc.arg('limit');
+
+-- name: SaveRoom :one
+INSERT INTO rooms (id, name, avatar, created_at, updated_at)
+VALUES (sqlc.arg('id'), sqlc.arg('name'), sqlc.arg('avatar'), sqlc.arg('now'), sqlc.arg('now')) ON CONFLICT (id) DO
+UPDATE SET name = excluded.name,
+    avatar = excluded.avatar,
+    updated_at = excluded.updated_at
+RETURNING *;
+
+-- name: GetRoom :one
+SELECT * FROM rooms WHERE id = ?;
+
+-- name: ListRooms :many
+SELECT * FROM rooms ORDER BY created_at DESC;
+
+-- name: UpdateRoomName :one
+UPDATE rooms SET name = ?, updated_at = ? WHERE id = ? RETURNING *;
+
+-- name: UpdateRoomResponse :one
+UPDATE rooms SET response = ?, updated_at = ? WHERE id = ? RETURNING *;
+
+-- name: UpdateRoomForwards :one
+UPDATE rooms SET forwards = ?, updated_at = ? WHERE id = ? RETURNING *;
+
+-- name: UpdateRoomSignature :one
+UPDATE rooms SET signature = ?, updated_at = ? WHERE id = ? RETURNING *;
+
+-- name: UpdateRoomRetention :one
+UPDATE rooms SET retention = ?, updated_at = ? WHERE id = ? RETURNING *;
+
+-- name: UpdateRoomRedaction :one
+UPDATE rooms SET redaction = ?, updated_at = ? WHERE id = ? RETURNING *;
+
+-- name: DeleteRoom :execrows
+DELETE FROM rooms WHERE id = ?;
+
+-- name: ListEventsAfter :many
+SELECT * FROM events WHERE room_id = ? AND id > ? ORDER BY id ASC LIMIT ?;
+
+-- name: GetEvent :one
+SELECT * FROM events WHERE room_id = ? AND id = ?;
+
+-- name: SaveReplayAttempt :exec
+INSERT INTO replay_attempts (id, event_id, target_url, request_header, status_code, response_header, response_body, response_body_base64, latency_ms, error, source, attempt, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: ListReplayAttempts :many
+SELECT * FROM replay_attempts WHERE event_id = ? ORDER BY id DESC LIMIT ?;
+
+-- name: SaveRoomToken :one
+INSERT INTO room_tokens (id, room_id, name, scope, prefix, token_hash, expires_at, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetRoomToken :one
+SELECT * FROM room_tokens WHERE room_id = ? AND id = ?;
+
+-- name: ListRoomTokens :many
+SELECT * FROM room_tokens WHERE room_id = ? ORDER BY id DESC;
+
+-- name: ListActiveRoomTokens :many
+SELECT * FROM room_tokens
+WHERE room_id = sqlc.arg('room_id') AND scope = sqlc.arg('scope') AND revoked_at IS NULL
+    AND (expires_at IS NULL OR expires_at > sqlc.arg('now'))
+ORDER BY id DESC;
+
+-- name: RevokeRoomToken :one
+UPDATE room_tokens SET revoked_at = sqlc.arg('now')
+WHERE room_id = sqlc.arg('room_id') AND id = sqlc.arg('id') AND revoked_at IS NULL
+RETURNING *;
+
+-- name: GetEventCountCutoff :one
+SELECT id FROM events WHERE room_id = sqlc.arg('room_id') ORDER BY id DESC LIMIT 1 OFFSET sqlc.arg('max_events');
+
+-- name: ListEventSizes :many
+SELECT id, content_length FROM events
+WHERE room_id = sqlc.arg('room_id') AND id < sqlc.arg('before_id')
+ORDER BY id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: PruneEvents :many
+DELETE FROM events
+WHERE id IN (
+    SELECT pruned.id FROM events AS pruned
+    WHERE pruned.room_id = sqlc.arg('room_id')
+        AND (pruned.id <= sqlc.narg('max_id') OR pruned.created_at < sqlc.narg('created_before'))
+    ORDER BY pruned.id
+    LIMIT sqlc.arg('limit')
+)
+RETURNING content_length;